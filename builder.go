@@ -0,0 +1,778 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultVersion of the documents built, the builders set it as version
+const DefaultVersion = "2.0"
+
+var (
+	// ErrRequired is the error of a required field left empty
+	ErrRequired = errors.New("required field is missing")
+	// ErrDuplicateID is the error of an id used by another entry of the feed
+	ErrDuplicateID = errors.New("duplicate id")
+	// ErrUnknownID is the error of a reference to an id no entry is built with
+	ErrUnknownID = errors.New("unknown id")
+	// ErrInconsistent is the error of fields contradicting each other, e.g. more
+	// bikes available than the capacity
+	ErrInconsistent = errors.New("inconsistent fields")
+)
+
+// FieldError is the validation error of a field, e.g. stations[2].lat
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the field
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError aggregates the errors found building a feed
+type ValidationError struct {
+	Feed   string
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Feed, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the field errors
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validator collects the errors of a feed
+type validator struct {
+	feed string
+	errs []FieldError
+}
+
+func (v *validator) add(field string, err error) {
+	v.errs = append(v.errs, FieldError{Field: field, Err: err})
+}
+
+// require adds ErrRequired when missing
+func (v *validator) require(field string, missing bool) {
+	if missing {
+		v.add(field, ErrRequired)
+	}
+}
+
+// marshals adds the error of encoding val, the fields types validate their
+// range or enum when encoded
+func (v *validator) marshals(field string, val any) {
+	if _, err := json.Marshal(val); err != nil {
+		var merr *json.MarshalerError
+		if errors.As(err, &merr) {
+			err = merr.Unwrap()
+		}
+		v.add(field, err)
+	}
+}
+
+// unique adds ErrDuplicateID when id was seen
+func (v *validator) unique(field string, id f.ID, seen map[f.ID]bool) {
+	if id == "" {
+		return
+	}
+	if seen[id] {
+		v.add(field, fmt.Errorf("%w: %s", ErrDuplicateID, id))
+	}
+	seen[id] = true
+}
+
+// known adds ErrUnknownID when ids is set and id is not in it
+func (v *validator) known(field string, id f.ID, ids map[f.ID]bool) {
+	if ids != nil && id != "" && !ids[id] {
+		v.add(field, fmt.Errorf("%w: %s", ErrUnknownID, id))
+	}
+}
+
+// located adds ErrRequired when p is 0,0, the position of unset coordinates
+func (v *validator) located(field string, p f.Point) {
+	if p == (f.Point{}) {
+		v.add(field, fmt.Errorf("%w: lat and lon are 0", ErrRequired))
+	}
+}
+
+// parse decodes s into dst as a JSON string, validating it
+func (v *validator) parse(field, s string, dst any) {
+	if err := json.Unmarshal([]byte(strconv.Quote(s)), dst); err != nil {
+		v.add(field, err)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Feed: v.feed, Errors: v.errs}
+}
+
+// header builds the Output of a feed
+type header struct {
+	lastUpdated time.Time
+	ttl         int
+}
+
+func (h header) output(v *validator) Output {
+	v.require("last_updated", h.lastUpdated.IsZero())
+	if h.ttl < 0 {
+		v.add("ttl", f.ErrNonNegativeInt)
+	}
+	return Output{
+		LastUpdated: f.NewTimestamp(h.lastUpdated, f.UnixSeconds),
+		TTL:         f.NonNegativeInt(h.ttl),
+		Version:     DefaultVersion,
+	}
+}
+
+// stationIDs returns the set of station ids of si
+func stationIDs(si StationInformation) map[f.ID]bool {
+	ids := make(map[f.ID]bool, len(si.Data.Stations))
+	for _, st := range si.Data.Stations {
+		ids[st.StationID] = true
+	}
+	return ids
+}
+
+// regionIDs returns the set of region ids of sr
+func regionIDs(sr SystemRegions) map[f.ID]bool {
+	ids := make(map[f.ID]bool, len(sr.Data.Regions))
+	for _, r := range sr.Data.Regions {
+		ids[r.RegionID] = true
+	}
+	return ids
+}
+
+// SystemInformationBuilder builds a SystemInformation
+type SystemInformationBuilder struct {
+	header
+	si      SystemInformation
+	v       validator
+	setLang bool
+}
+
+// NewSystemInformation returns a SystemInformationBuilder
+func NewSystemInformation() *SystemInformationBuilder {
+	return &SystemInformationBuilder{v: validator{feed: "system_information"}}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemInformationBuilder) Output(lastUpdated time.Time, ttl int) *SystemInformationBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// SystemID sets the required system_id
+func (b *SystemInformationBuilder) SystemID(id f.ID) *SystemInformationBuilder {
+	b.si.Data.SystemID = id
+	return b
+}
+
+// Language sets the required language
+func (b *SystemInformationBuilder) Language(tag language.Tag) *SystemInformationBuilder {
+	b.si.Data.Language, b.setLang = f.Language{Tag: tag}, true
+	return b
+}
+
+// Name sets the required name
+func (b *SystemInformationBuilder) Name(name string) *SystemInformationBuilder {
+	b.si.Data.Name = name
+	return b
+}
+
+// Timezone sets the required timezone
+func (b *SystemInformationBuilder) Timezone(loc *time.Location) *SystemInformationBuilder {
+	b.si.Data.Timezone = f.Timezone{Location: loc}
+	return b
+}
+
+// ShortName sets the short_name
+func (b *SystemInformationBuilder) ShortName(name string) *SystemInformationBuilder {
+	b.si.Data.ShortName = name
+	return b
+}
+
+// Operator sets the operator
+func (b *SystemInformationBuilder) Operator(operator string) *SystemInformationBuilder {
+	b.si.Data.Operator = operator
+	return b
+}
+
+// URL sets the url of the system
+func (b *SystemInformationBuilder) URL(u string) *SystemInformationBuilder {
+	b.si.Data.URL = new(f.URL)
+	b.v.parse("url", u, b.si.Data.URL)
+	return b
+}
+
+// PhoneNumber sets the phone_number
+func (b *SystemInformationBuilder) PhoneNumber(n string) *SystemInformationBuilder {
+	b.si.Data.PhoneNumber = f.PhoneNumber(n)
+	return b
+}
+
+// Email sets the email of customer service
+func (b *SystemInformationBuilder) Email(address string) *SystemInformationBuilder {
+	b.si.Data.Email = new(f.Email)
+	b.v.parse("email", address, b.si.Data.Email)
+	return b
+}
+
+// PurchaseURL sets the purchase_url of passes
+func (b *SystemInformationBuilder) PurchaseURL(u string) *SystemInformationBuilder {
+	b.si.Data.PurchaseURL = new(f.URL)
+	b.v.parse("purchase_url", u, b.si.Data.PurchaseURL)
+	return b
+}
+
+// StartDate sets the start_date of the system
+func (b *SystemInformationBuilder) StartDate(d time.Time) *SystemInformationBuilder {
+	b.si.Data.StartDate = &f.Date{Time: d}
+	return b
+}
+
+// FeedContactEmail sets the feed_contact_email of the feed maintainer
+func (b *SystemInformationBuilder) FeedContactEmail(address string) *SystemInformationBuilder {
+	b.si.Data.FeedContactEmail = new(f.Email)
+	b.v.parse("feed_contact_email", address, b.si.Data.FeedContactEmail)
+	return b
+}
+
+// LicenseURL sets the license_url of the data
+func (b *SystemInformationBuilder) LicenseURL(u string) *SystemInformationBuilder {
+	b.si.Data.LicenseURL = new(f.URL)
+	b.v.parse("license_url", u, b.si.Data.LicenseURL)
+	return b
+}
+
+// RentalApp sets the rental app of platform in rental_apps
+func (b *SystemInformationBuilder) RentalApp(platform f.Mobile, storeURI, discoveryURI string) *SystemInformationBuilder {
+	field := "rental_apps." + string(platform)
+	b.v.marshals(field, platform)
+
+	var app RentalApp
+	b.v.parse(field+".store_uri", storeURI, &app.StoreURI)
+	b.v.parse(field+".discovery_uri", discoveryURI, &app.DiscoveryURI)
+	if b.si.Data.RentalApps == nil {
+		b.si.Data.RentalApps = make(map[f.Mobile]RentalApp)
+	}
+	b.si.Data.RentalApps[platform] = app
+	return b
+}
+
+// Build validates and returns the SystemInformation
+func (b *SystemInformationBuilder) Build() (SystemInformation, error) {
+	// setters may have recorded errors, Build must not modify them
+	v := validator{feed: b.v.feed, errs: append([]FieldError(nil), b.v.errs...)}
+	si := b.si
+	si.Output = b.output(&v)
+	if b.si.Data.RentalApps != nil {
+		si.Data.RentalApps = make(map[f.Mobile]RentalApp, len(b.si.Data.RentalApps))
+		for platform, app := range b.si.Data.RentalApps {
+			si.Data.RentalApps[platform] = app
+		}
+	}
+
+	v.require("system_id", si.Data.SystemID == "")
+	v.require("language", !b.setLang)
+	v.require("name", si.Data.Name == "")
+	v.require("timezone", si.Data.Timezone.Location == nil)
+	if si.Data.PhoneNumber != "" {
+		v.marshals("phone_number", si.Data.PhoneNumber)
+	}
+	return si, v.err()
+}
+
+// StationInformationBuilder builds a StationInformation
+type StationInformationBuilder struct {
+	header
+	stations []Station
+	regions  map[f.ID]bool
+}
+
+// NewStationInformation returns a StationInformationBuilder
+func NewStationInformation() *StationInformationBuilder {
+	return &StationInformationBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *StationInformationBuilder) Output(lastUpdated time.Time, ttl int) *StationInformationBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddStation adds stations
+func (b *StationInformationBuilder) AddStation(stations ...Station) *StationInformationBuilder {
+	b.stations = append(b.stations, stations...)
+	return b
+}
+
+// Regions requires the region_id of stations to be a region of sr
+func (b *StationInformationBuilder) Regions(sr SystemRegions) *StationInformationBuilder {
+	b.regions = regionIDs(sr)
+	return b
+}
+
+// Build validates and returns the StationInformation
+func (b *StationInformationBuilder) Build() (StationInformation, error) {
+	v := validator{feed: "station_information"}
+
+	var si StationInformation
+	si.Output = b.output(&v)
+	si.Data.Stations = append([]Station{}, b.stations...)
+
+	seen := make(map[f.ID]bool, len(b.stations))
+	for i, st := range si.Data.Stations {
+		field := func(name string) string { return fmt.Sprintf("stations[%d].%s", i, name) }
+
+		v.require(field("station_id"), st.StationID == "")
+		v.unique(field("station_id"), st.StationID, seen)
+		v.require(field("name"), st.Name == "")
+		v.marshals(field("lat"), st.Latitutde)
+		v.marshals(field("lon"), st.Longitude)
+		v.located(field("lat"), st.Point())
+		v.known(field("region_id"), st.RegionID, b.regions)
+		for j, m := range st.RentalMethods {
+			v.marshals(fmt.Sprintf("%s[%d]", field("rental_methods"), j), m)
+		}
+	}
+	return si, v.err()
+}
+
+// StationStatusBuilder builds a StationStatus
+type StationStatusBuilder struct {
+	header
+	stations []StationState
+	info     map[f.ID]Station
+}
+
+// NewStationStatus returns a StationStatusBuilder
+func NewStationStatus() *StationStatusBuilder {
+	return &StationStatusBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *StationStatusBuilder) Output(lastUpdated time.Time, ttl int) *StationStatusBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddStation adds the state of stations
+func (b *StationStatusBuilder) AddStation(states ...StationState) *StationStatusBuilder {
+	b.stations = append(b.stations, states...)
+	return b
+}
+
+// Stations requires every station to be a station of si, with no more
+// bikes and docks than its capacity
+func (b *StationStatusBuilder) Stations(si StationInformation) *StationStatusBuilder {
+	b.info = make(map[f.ID]Station, len(si.Data.Stations))
+	for _, st := range si.Data.Stations {
+		b.info[st.StationID] = st
+	}
+	return b
+}
+
+// Build validates and returns the StationStatus
+func (b *StationStatusBuilder) Build() (StationStatus, error) {
+	v := validator{feed: "station_status"}
+
+	var ss StationStatus
+	ss.Output = b.output(&v)
+	ss.Data.Stations = append([]StationState{}, b.stations...)
+
+	seen := make(map[f.ID]bool, len(b.stations))
+	for i, st := range ss.Data.Stations {
+		field := func(name string) string { return fmt.Sprintf("stations[%d].%s", i, name) }
+
+		v.require(field("station_id"), st.StationID == "")
+		v.unique(field("station_id"), st.StationID, seen)
+		v.require(field("last_reported"), st.LastReported.IsZero())
+		if !st.IsInstalled && (st.IsRenting || st.IsReturning) {
+			v.add(field("is_installed"), fmt.Errorf("%w: a station not installed can not be renting or returning", ErrInconsistent))
+		}
+
+		if b.info == nil || st.StationID == "" {
+			continue
+		}
+		info, ok := b.info[st.StationID]
+		if !ok {
+			v.add(field("station_id"), fmt.Errorf("%w: %s", ErrUnknownID, st.StationID))
+			continue
+		}
+		total := st.NumBikesAvailable + st.NumBikesDisabled.Value() + st.NumDocksAvailable + st.NumDocsDisabled.Value()
+		if info.Capacity != nil && total > *info.Capacity {
+			v.add(field("num_bikes_available"), fmt.Errorf("%w: %d bikes and docks exceed a capacity of %d", ErrInconsistent, total, *info.Capacity))
+		}
+	}
+	return ss, v.err()
+}
+
+// FreeBikeStatusBuilder builds a FreeBikeStatus
+type FreeBikeStatusBuilder struct {
+	header
+	bikes []Bike
+}
+
+// NewFreeBikeStatus returns a FreeBikeStatusBuilder
+func NewFreeBikeStatus() *FreeBikeStatusBuilder {
+	return &FreeBikeStatusBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *FreeBikeStatusBuilder) Output(lastUpdated time.Time, ttl int) *FreeBikeStatusBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddBike adds bikes
+func (b *FreeBikeStatusBuilder) AddBike(bikes ...Bike) *FreeBikeStatusBuilder {
+	b.bikes = append(b.bikes, bikes...)
+	return b
+}
+
+// Build validates and returns the FreeBikeStatus
+func (b *FreeBikeStatusBuilder) Build() (FreeBikeStatus, error) {
+	v := validator{feed: "free_bike_status"}
+
+	var fb FreeBikeStatus
+	fb.Output = b.output(&v)
+	fb.Data.Bikes = append([]Bike{}, b.bikes...)
+
+	seen := make(map[f.ID]bool, len(b.bikes))
+	for i, bike := range fb.Data.Bikes {
+		field := func(name string) string { return fmt.Sprintf("bikes[%d].%s", i, name) }
+
+		v.require(field("bike_id"), bike.BikeID == "")
+		v.unique(field("bike_id"), bike.BikeID, seen)
+		v.marshals(field("lat"), bike.Latitude)
+		v.marshals(field("lon"), bike.Longitude)
+		v.located(field("lat"), bike.Point())
+	}
+	return fb, v.err()
+}
+
+// SystemRegionsBuilder builds a SystemRegions
+type SystemRegionsBuilder struct {
+	header
+	regions []Region
+}
+
+// NewSystemRegions returns a SystemRegionsBuilder
+func NewSystemRegions() *SystemRegionsBuilder {
+	return &SystemRegionsBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemRegionsBuilder) Output(lastUpdated time.Time, ttl int) *SystemRegionsBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddRegion adds a region
+func (b *SystemRegionsBuilder) AddRegion(id f.ID, name string) *SystemRegionsBuilder {
+	b.regions = append(b.regions, Region{RegionID: id, Name: name})
+	return b
+}
+
+// Build validates and returns the SystemRegions
+func (b *SystemRegionsBuilder) Build() (SystemRegions, error) {
+	v := validator{feed: "system_regions"}
+
+	var sr SystemRegions
+	sr.Output = b.output(&v)
+	sr.Data.Regions = append([]Region{}, b.regions...)
+
+	seen := make(map[f.ID]bool, len(b.regions))
+	for i, r := range sr.Data.Regions {
+		field := func(name string) string { return fmt.Sprintf("regions[%d].%s", i, name) }
+
+		v.require(field("region_id"), r.RegionID == "")
+		v.unique(field("region_id"), r.RegionID, seen)
+		v.require(field("name"), r.Name == "")
+	}
+	return sr, v.err()
+}
+
+// SystemAlertsBuilder builds a SystemAlerts
+type SystemAlertsBuilder struct {
+	header
+	alerts   []Alert
+	stations map[f.ID]bool
+	regions  map[f.ID]bool
+}
+
+// NewSystemAlerts returns a SystemAlertsBuilder
+func NewSystemAlerts() *SystemAlertsBuilder {
+	return &SystemAlertsBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemAlertsBuilder) Output(lastUpdated time.Time, ttl int) *SystemAlertsBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddAlert adds alerts
+func (b *SystemAlertsBuilder) AddAlert(alerts ...Alert) *SystemAlertsBuilder {
+	b.alerts = append(b.alerts, alerts...)
+	return b
+}
+
+// Stations requires the station_ids of alerts to be stations of si
+func (b *SystemAlertsBuilder) Stations(si StationInformation) *SystemAlertsBuilder {
+	b.stations = stationIDs(si)
+	return b
+}
+
+// Regions requires the region_ids of alerts to be regions of sr
+func (b *SystemAlertsBuilder) Regions(sr SystemRegions) *SystemAlertsBuilder {
+	b.regions = regionIDs(sr)
+	return b
+}
+
+// Build validates and returns the SystemAlerts
+func (b *SystemAlertsBuilder) Build() (SystemAlerts, error) {
+	v := validator{feed: "system_alerts"}
+
+	var sa SystemAlerts
+	sa.Output = b.output(&v)
+	sa.Data.Alerts = append([]Alert{}, b.alerts...)
+
+	seen := make(map[f.ID]bool, len(b.alerts))
+	for i, a := range sa.Data.Alerts {
+		field := func(name string) string { return fmt.Sprintf("alerts[%d].%s", i, name) }
+
+		v.require(field("alert_id"), a.AlertID == "")
+		v.unique(field("alert_id"), a.AlertID, seen)
+		v.require(field("type"), a.Type == "")
+		if a.Type != "" {
+			v.marshals(field("type"), a.Type)
+		}
+		v.require(field("summary"), a.Summary == "")
+		for j, t := range a.Times {
+			tf := fmt.Sprintf("%s[%d]", field("times"), j)
+			v.require(tf+".start", t.Start.IsZero())
+			if t.End != nil && !t.Start.IsZero() && !t.End.After(t.Start.Time) {
+				v.add(tf+".end", fmt.Errorf("%w: end must be after start", ErrInconsistent))
+			}
+		}
+		for j, id := range a.StationIds {
+			v.known(fmt.Sprintf("%s[%d]", field("station_ids"), j), id, b.stations)
+		}
+		for j, id := range a.RegionIds {
+			v.known(fmt.Sprintf("%s[%d]", field("region_ids"), j), id, b.regions)
+		}
+	}
+	return sa, v.err()
+}
+
+// VersionsBuilder builds the Versions of gbfs_versions.json
+type VersionsBuilder struct {
+	header
+	versions []Version
+	v        validator
+}
+
+// NewVersions returns a VersionsBuilder
+func NewVersions() *VersionsBuilder {
+	return &VersionsBuilder{v: validator{feed: "gbfs_versions"}}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *VersionsBuilder) Output(lastUpdated time.Time, ttl int) *VersionsBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddVersion adds the gbfs.json of version at gbfsURL
+func (b *VersionsBuilder) AddVersion(version, gbfsURL string) *VersionsBuilder {
+	ver := Version{Version: version}
+	b.v.parse(fmt.Sprintf("versions[%d].url", len(b.versions)), gbfsURL, &ver.URL)
+	b.versions = append(b.versions, ver)
+	return b
+}
+
+// Build validates and returns the Versions
+func (b *VersionsBuilder) Build() (Versions, error) {
+	// setters may have recorded errors, Build must not modify them
+	v := validator{feed: b.v.feed, errs: append([]FieldError(nil), b.v.errs...)}
+
+	var vs Versions
+	vs.Output = b.output(&v)
+	vs.Data.Versions = append([]Version{}, b.versions...)
+
+	seen := make(map[f.ID]bool, len(b.versions))
+	for i, ver := range vs.Data.Versions {
+		field := func(name string) string { return fmt.Sprintf("versions[%d].%s", i, name) }
+
+		v.require(field("version"), ver.Version == "")
+		v.unique(field("version"), f.ID(ver.Version), seen)
+	}
+	return vs, v.err()
+}
+
+// SystemHoursBuilder builds a SystemHours
+type SystemHoursBuilder struct {
+	header
+	hours []RentalHours
+}
+
+// NewSystemHours returns a SystemHoursBuilder
+func NewSystemHours() *SystemHoursBuilder {
+	return &SystemHoursBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemHoursBuilder) Output(lastUpdated time.Time, ttl int) *SystemHoursBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddRentalHours adds rental hours
+func (b *SystemHoursBuilder) AddRentalHours(hours ...RentalHours) *SystemHoursBuilder {
+	b.hours = append(b.hours, hours...)
+	return b
+}
+
+// Build validates and returns the SystemHours
+func (b *SystemHoursBuilder) Build() (SystemHours, error) {
+	v := validator{feed: "system_hours"}
+
+	var sh SystemHours
+	sh.Output = b.output(&v)
+	sh.Data.RentalHours = append([]RentalHours{}, b.hours...)
+
+	for i, h := range sh.Data.RentalHours {
+		field := func(name string) string { return fmt.Sprintf("rental_hours[%d].%s", i, name) }
+
+		v.require(field("user_types"), len(h.UserTypes) == 0)
+		for j, u := range h.UserTypes {
+			v.marshals(fmt.Sprintf("%s[%d]", field("user_types"), j), u)
+		}
+		v.require(field("days"), len(h.Days) == 0)
+		for j, d := range h.Days {
+			v.marshals(fmt.Sprintf("%s[%d]", field("days"), j), d)
+		}
+		v.marshals(field("start_time"), h.StartTime)
+		v.marshals(field("end_time"), h.EndTime)
+	}
+	return sh, v.err()
+}
+
+// SystemCalendarBuilder builds a SystemCalendar
+type SystemCalendarBuilder struct {
+	header
+	calendars []Calendar
+}
+
+// NewSystemCalendar returns a SystemCalendarBuilder
+func NewSystemCalendar() *SystemCalendarBuilder {
+	return &SystemCalendarBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemCalendarBuilder) Output(lastUpdated time.Time, ttl int) *SystemCalendarBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddCalendar adds calendars
+func (b *SystemCalendarBuilder) AddCalendar(calendars ...Calendar) *SystemCalendarBuilder {
+	b.calendars = append(b.calendars, calendars...)
+	return b
+}
+
+// Build validates and returns the SystemCalendar
+func (b *SystemCalendarBuilder) Build() (SystemCalendar, error) {
+	v := validator{feed: "system_calendar"}
+
+	var sc SystemCalendar
+	sc.Output = b.output(&v)
+	sc.Data.Calendars = append([]Calendar{}, b.calendars...)
+
+	for i, c := range sc.Data.Calendars {
+		field := func(name string) string { return fmt.Sprintf("calendars[%d].%s", i, name) }
+
+		v.marshals(field("start_day"), c.StartDay)
+		v.marshals(field("start_month"), c.StartMonth)
+		v.marshals(field("start_year"), c.StartYear)
+		v.marshals(field("end_day"), c.EndDay)
+		v.marshals(field("end_month"), c.EndMonth)
+		v.marshals(field("end_year"), c.EndYear)
+	}
+	return sc, v.err()
+}
+
+// SystemPricingPlansBuilder builds a SystemPricingPlans
+type SystemPricingPlansBuilder struct {
+	header
+	plans []PricingPlan
+}
+
+// NewSystemPricingPlans returns a SystemPricingPlansBuilder
+func NewSystemPricingPlans() *SystemPricingPlansBuilder {
+	return &SystemPricingPlansBuilder{}
+}
+
+// Output sets the last_updated and ttl of the document
+func (b *SystemPricingPlansBuilder) Output(lastUpdated time.Time, ttl int) *SystemPricingPlansBuilder {
+	b.lastUpdated, b.ttl = lastUpdated, ttl
+	return b
+}
+
+// AddPlan adds pricing plans
+func (b *SystemPricingPlansBuilder) AddPlan(plans ...PricingPlan) *SystemPricingPlansBuilder {
+	b.plans = append(b.plans, plans...)
+	return b
+}
+
+// Build validates and returns the SystemPricingPlans
+func (b *SystemPricingPlansBuilder) Build() (SystemPricingPlans, error) {
+	v := validator{feed: "system_pricing_plans"}
+
+	var sp SystemPricingPlans
+	sp.Output = b.output(&v)
+	sp.Data.Plans = append([]PricingPlan{}, b.plans...)
+
+	seen := make(map[f.ID]bool, len(b.plans))
+	for i, p := range sp.Data.Plans {
+		field := func(name string) string { return fmt.Sprintf("plans[%d].%s", i, name) }
+
+		v.require(field("plan_id"), p.PlanID == "")
+		v.unique(field("plan_id"), p.PlanID, seen)
+		v.require(field("name"), p.Name == "")
+		v.require(field("currency"), p.Currency.Unit == nil)
+		v.require(field("price"), p.Price.Number == "")
+		v.marshals(field("price"), p.Price)
+		v.require(field("description"), p.Description == "")
+	}
+	return sp, v.err()
+}
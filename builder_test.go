@@ -0,0 +1,284 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+var builtAt = time.Unix(1609866247, 0)
+
+// fieldErrors returns the field errors of err by field
+func fieldErrors(t *testing.T, err error) map[string]error {
+	t.Helper()
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr), "not a ValidationError: %v", err)
+
+	errs := make(map[string]error, len(verr.Errors))
+	for _, fe := range verr.Errors {
+		errs[fe.Field] = fe.Err
+	}
+	return errs
+}
+
+// TestSystemInformationBuilder ...
+func TestSystemInformationBuilder(t *testing.T) {
+	si, err := NewSystemInformation().
+		Output(builtAt, 3600).
+		SystemID("city").
+		Language(language.English).
+		Name("City Bikes").
+		Timezone(time.UTC).
+		URL("https://example.com").
+		Email("bikes@example.com").
+		PurchaseURL("https://example.com/passes").
+		StartDate(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)).
+		FeedContactEmail("data@example.com").
+		LicenseURL("https://example.com/license").
+		RentalApp(f.Android, "https://play.google.com/store/apps/details?id=com.example", "com.example.android://").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, f.ID("city"), si.Data.SystemID)
+	assert.Equal(t, "https://example.com", si.Data.URL.String())
+	assert.Equal(t, "https://example.com/passes", si.Data.PurchaseURL.String())
+	assert.Equal(t, "2020-05-01", si.Data.StartDate.String())
+	assert.Equal(t, "data@example.com", si.Data.FeedContactEmail.Address.Address)
+	assert.Equal(t, "https://example.com/license", si.Data.LicenseURL.String())
+	assert.Equal(t, "https://play.google.com/store/apps/details?id=com.example", si.Data.RentalApps[f.Android].StoreURI.String())
+	assert.Equal(t, DefaultVersion, si.Version)
+	assert.EqualValues(t, 3600, si.TTL)
+
+	_, err = NewSystemInformation().Output(builtAt, -1).URL("ftp://example.com").Build()
+	errs := fieldErrors(t, err)
+	assert.Len(t, errs, 6)
+	for _, field := range []string{"system_id", "language", "name", "timezone"} {
+		assert.ErrorIs(t, errs[field], ErrRequired, field)
+	}
+	assert.ErrorIs(t, errs["ttl"], f.ErrNonNegativeInt)
+	assert.Error(t, errs["url"])
+
+	_, err = NewSystemInformation().Output(builtAt, 60).SystemID("city").Language(language.English).Name("City Bikes").Timezone(time.UTC).
+		FeedContactEmail("nobody").
+		RentalApp("windows", "https://example.com", "example://").
+		Build()
+	errs = fieldErrors(t, err)
+	assert.Error(t, errs["feed_contact_email"])
+	assert.ErrorIs(t, errs["rental_apps.windows"], f.ErrUnknownMobile)
+	assert.Len(t, errs, 2)
+}
+
+// TestStationInformationBuilder ...
+func TestStationInformationBuilder(t *testing.T) {
+	sr, err := NewSystemRegions().Output(builtAt, 60).AddRegion("north", "North").Build()
+	require.NoError(t, err)
+
+	b := NewStationInformation().Output(builtAt, 60).Regions(sr).
+		AddStation(Station{StationID: "1", Name: "One", Latitutde: 43.6, Longitude: -79.4, RegionID: "north"}).
+		AddStation(Station{StationID: "2", Name: "Two", Latitutde: 43.7, Longitude: -79.3})
+
+	si, err := b.Build()
+	require.NoError(t, err)
+	assert.Len(t, si.Data.Stations, 2)
+
+	_, err = b.AddStation(
+		Station{StationID: "1", Latitutde: 91, Longitude: -79.4, RegionID: "south"},
+		Station{Name: "Nowhere", Longitude: 181, RentalMethods: []f.RentalMethod{"TELEPATHY"}},
+	).Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["stations[2].station_id"], ErrDuplicateID)
+	assert.ErrorIs(t, errs["stations[2].name"], ErrRequired)
+	assert.ErrorIs(t, errs["stations[2].lat"], f.ErrLatitude)
+	assert.ErrorIs(t, errs["stations[2].region_id"], ErrUnknownID)
+	assert.ErrorIs(t, errs["stations[3].station_id"], ErrRequired)
+	assert.ErrorIs(t, errs["stations[3].lon"], f.ErrLongitude)
+	assert.ErrorIs(t, errs["stations[3].rental_methods[0]"], f.ErrUnknownRentalMethod)
+	assert.Len(t, errs, 7)
+
+	// every error is reachable from the aggregate
+	assert.ErrorIs(t, err, ErrDuplicateID)
+	assert.ErrorIs(t, err, f.ErrLongitude)
+	assert.Contains(t, err.Error(), "invalid station_information: stations[2].station_id: duplicate id: 1")
+
+	// unset coordinates are not a location
+	_, err = NewStationInformation().Output(builtAt, 60).AddStation(Station{StationID: "3", Name: "Three"}).Build()
+	assert.ErrorIs(t, fieldErrors(t, err)["stations[0].lat"], ErrRequired)
+}
+
+// TestStationStatusBuilder ...
+func TestStationStatusBuilder(t *testing.T) {
+	si, err := NewStationInformation().Output(builtAt, 60).
+		AddStation(Station{StationID: "1", Name: "One", Latitutde: 43.6, Longitude: -79.4, Capacity: f.NewNonNegativeInt(10)}).
+		Build()
+	require.NoError(t, err)
+
+	reported := f.NewTimestamp(builtAt, f.UnixSeconds)
+	ss, err := NewStationStatus().Output(builtAt, 10).Stations(si).
+		AddStation(StationState{StationID: "1", NumBikesAvailable: 4, NumDocksAvailable: 6, IsInstalled: true, IsRenting: true, LastReported: reported}).
+		Build()
+	require.NoError(t, err)
+	assert.Len(t, ss.Data.Stations, 1)
+
+	_, err = NewStationStatus().Stations(si).AddStation(
+		StationState{StationID: "1", NumBikesAvailable: 8, NumDocksAvailable: 6, IsReturning: true},
+		StationState{StationID: "2", LastReported: reported},
+	).Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["last_updated"], ErrRequired)
+	assert.ErrorIs(t, errs["stations[0].last_reported"], ErrRequired)
+	assert.ErrorIs(t, errs["stations[0].is_installed"], ErrInconsistent)
+	assert.ErrorIs(t, errs["stations[0].num_bikes_available"], ErrInconsistent)
+	assert.ErrorIs(t, errs["stations[1].station_id"], ErrUnknownID)
+	assert.Len(t, errs, 5)
+}
+
+// TestFreeBikeStatusBuilder ...
+func TestFreeBikeStatusBuilder(t *testing.T) {
+	_, err := NewFreeBikeStatus().Output(builtAt, 10).
+		AddBike(Bike{BikeID: "a", Latitude: 43.6, Longitude: -79.4}).
+		Build()
+	require.NoError(t, err)
+
+	_, err = NewFreeBikeStatus().Output(builtAt, 10).
+		AddBike(Bike{BikeID: "a"}, Bike{BikeID: "a", Latitude: -91}, Bike{}).
+		Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["bikes[0].lat"], ErrRequired)
+	assert.ErrorIs(t, errs["bikes[1].bike_id"], ErrDuplicateID)
+	assert.ErrorIs(t, errs["bikes[1].lat"], f.ErrLatitude)
+	assert.ErrorIs(t, errs["bikes[2].bike_id"], ErrRequired)
+	assert.ErrorIs(t, errs["bikes[2].lat"], ErrRequired)
+	assert.Len(t, errs, 5)
+}
+
+// TestSystemRegionsBuilder ...
+func TestSystemRegionsBuilder(t *testing.T) {
+	_, err := NewSystemRegions().Output(builtAt, 60).AddRegion("a", "A").AddRegion("a", "").AddRegion("", "C").Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["regions[1].region_id"], ErrDuplicateID)
+	assert.ErrorIs(t, errs["regions[1].name"], ErrRequired)
+	assert.ErrorIs(t, errs["regions[2].region_id"], ErrRequired)
+	assert.Len(t, errs, 3)
+}
+
+// TestSystemAlertsBuilder ...
+func TestSystemAlertsBuilder(t *testing.T) {
+	si, err := NewStationInformation().Output(builtAt, 60).AddStation(Station{StationID: "1", Name: "One", Latitutde: 43.6, Longitude: -79.4}).Build()
+	require.NoError(t, err)
+	sr, err := NewSystemRegions().Output(builtAt, 60).AddRegion("north", "North").Build()
+	require.NoError(t, err)
+
+	start := f.NewTimestamp(builtAt, f.UnixSeconds)
+	end := f.NewTimestamp(builtAt.Add(time.Hour), f.UnixSeconds)
+	b := NewSystemAlerts().Output(builtAt, 60).Stations(si).Regions(sr)
+
+	_, err = b.AddAlert(Alert{
+		AlertID:    "closure",
+		Type:       "STATION_CLOSURE",
+		Summary:    "Closed",
+		Times:      []AlertTime{{Start: start, End: &end}, {Start: end}},
+		StationIds: []f.ID{"1"},
+		RegionIds:  []f.ID{"north"},
+	}).Build()
+	require.NoError(t, err)
+
+	_, err = b.AddAlert(
+		Alert{AlertID: "closure", Type: "ALIENS", Times: []AlertTime{{Start: end, End: &start}}, StationIds: []f.ID{"2"}},
+		Alert{AlertID: "other", Times: []AlertTime{{}}, RegionIds: []f.ID{"south"}},
+	).Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["alerts[1].alert_id"], ErrDuplicateID)
+	assert.ErrorIs(t, errs["alerts[1].type"], f.ErrUnknownAlertType)
+	assert.ErrorIs(t, errs["alerts[1].summary"], ErrRequired)
+	assert.ErrorIs(t, errs["alerts[1].times[0].end"], ErrInconsistent)
+	assert.ErrorIs(t, errs["alerts[1].station_ids[0]"], ErrUnknownID)
+	assert.ErrorIs(t, errs["alerts[2].type"], ErrRequired)
+	assert.ErrorIs(t, errs["alerts[2].summary"], ErrRequired)
+	assert.ErrorIs(t, errs["alerts[2].times[0].start"], ErrRequired)
+	assert.ErrorIs(t, errs["alerts[2].region_ids[0]"], ErrUnknownID)
+	assert.Len(t, errs, 9)
+}
+
+// TestVersionsBuilder ...
+func TestVersionsBuilder(t *testing.T) {
+	vs, err := NewVersions().Output(builtAt, 60).
+		AddVersion("1.1", "https://example.com/v1/gbfs.json").
+		AddVersion("2.0", "https://example.com/gbfs.json").
+		Build()
+	require.NoError(t, err)
+	require.Len(t, vs.Data.Versions, 2)
+	assert.Equal(t, "https://example.com/v1/gbfs.json", vs.Data.Versions[0].URL.String())
+
+	_, err = NewVersions().Output(builtAt, 60).
+		AddVersion("2.0", "https://example.com/gbfs.json").
+		AddVersion("2.0", "gbfs.json").
+		AddVersion("", "https://example.com/gbfs.json").
+		Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["versions[1].version"], ErrDuplicateID)
+	assert.Error(t, errs["versions[1].url"])
+	assert.ErrorIs(t, errs["versions[2].version"], ErrRequired)
+	assert.Len(t, errs, 3)
+}
+
+// TestSystemHoursBuilder ...
+func TestSystemHoursBuilder(t *testing.T) {
+	open, err := f.NewTime(6, 0, 0)
+	require.NoError(t, err)
+	closed, err := f.NewTime(25, 0, 0)
+	require.NoError(t, err)
+
+	_, err = NewSystemHours().Output(builtAt, 60).
+		AddRentalHours(RentalHours{UserTypes: []f.UserType{"member"}, Days: []f.DayOfWeek{"mon", "tue"}, StartTime: open, EndTime: closed}).
+		Build()
+	require.NoError(t, err)
+
+	_, err = NewSystemHours().Output(builtAt, 60).
+		AddRentalHours(RentalHours{UserTypes: []f.UserType{"visitor"}, Days: []f.DayOfWeek{"someday"}}, RentalHours{}).
+		Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["rental_hours[0].user_types[0]"], f.ErrUnknownUserType)
+	assert.ErrorIs(t, errs["rental_hours[0].days[0]"], f.ErrUnknownDayOfWeek)
+	assert.ErrorIs(t, errs["rental_hours[1].user_types"], ErrRequired)
+	assert.ErrorIs(t, errs["rental_hours[1].days"], ErrRequired)
+	assert.Len(t, errs, 4)
+}
+
+// TestSystemCalendarBuilder ...
+func TestSystemCalendarBuilder(t *testing.T) {
+	_, err := NewSystemCalendar().Output(builtAt, 60).
+		AddCalendar(Calendar{StartDay: 1, StartMonth: 4, EndDay: 30, EndMonth: 11, EndYear: 2021}).
+		Build()
+	require.NoError(t, err)
+
+	_, err = NewSystemCalendar().Output(builtAt, 60).AddCalendar(Calendar{StartMonth: 13, EndDay: 1, EndMonth: 1}).Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["calendars[0].start_day"], f.ErrInvalidDay)
+	assert.ErrorIs(t, errs["calendars[0].start_month"], f.ErrInvalidMonth)
+	assert.Len(t, errs, 2)
+}
+
+// TestSystemPricingPlansBuilder ...
+func TestSystemPricingPlansBuilder(t *testing.T) {
+	var plan PricingPlan
+	require.NoError(t, json.Unmarshal([]byte(`{"plan_id":"day","name":"Day Pass","currency":"CAD","price":7,"is_taxable":true,"description":"24 hours"}`), &plan))
+
+	sp, err := NewSystemPricingPlans().Output(builtAt, 60).AddPlan(plan).Build()
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, sp.Data.Plans[0].Price.Float64())
+
+	_, err = NewSystemPricingPlans().Output(builtAt, 60).AddPlan(plan, PricingPlan{PlanID: "day"}).Build()
+	errs := fieldErrors(t, err)
+	assert.ErrorIs(t, errs["plans[1].plan_id"], ErrDuplicateID)
+	for _, field := range []string{"name", "currency", "price", "description"} {
+		assert.ErrorIs(t, errs["plans[1]."+field], ErrRequired, field)
+	}
+	assert.Len(t, errs, 5)
+}
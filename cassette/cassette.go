@@ -0,0 +1,125 @@
+// Package cassette records HTTP interactions to a file and replays them, so
+// code using gbfs.NewClient can be tested offline against real feeds
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+// base64Encoding marks a body that is not valid UTF-8
+const base64Encoding = "base64"
+
+// Cassette is an ordered list of recorded interactions, it is safe for
+// concurrent use
+type Cassette struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request ...
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// Response ...
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"-"`
+}
+
+// response is the encoded form of Response, text bodies are kept readable
+type response struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (r Response) MarshalJSON() ([]byte, error) {
+	out := response{StatusCode: r.StatusCode, Header: r.Header, Body: string(r.Body)}
+	if !utf8.Valid(r.Body) {
+		out.Body, out.BodyEncoding = base64.StdEncoding.EncodeToString(r.Body), base64Encoding
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var in response
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	r.StatusCode, r.Header, r.Body = in.StatusCode, in.Header, []byte(in.Body)
+	if in.BodyEncoding == base64Encoding {
+		body, err := base64.StdEncoding.DecodeString(in.Body)
+		if err != nil {
+			return err
+		}
+		r.Body = body
+	}
+	return nil
+}
+
+// New returns an empty Cassette
+func New() *Cassette {
+	return new(Cassette)
+}
+
+// Load reads the Cassette saved at name
+func Load(name string) (*Cassette, error) {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var in struct {
+		Interactions []Interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, err
+	}
+	return &Cassette{interactions: in.Interactions}, nil
+}
+
+// Save writes the Cassette to name
+func (c *Cassette) Save(name string) error {
+	c.mu.Lock()
+	raw, err := json.MarshalIndent(struct {
+		Interactions []Interaction `json:"interactions"`
+	}{c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, raw, 0o644)
+}
+
+// Interactions returns a copy of the recorded interactions
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Add appends an interaction
+func (c *Cassette) Add(i Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, i)
+}
@@ -0,0 +1,77 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// DefaultRedactedHeaders are not recorded by a Recorder
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Recorder is a http.RoundTripper recording every interaction to a Cassette
+type Recorder struct {
+	cassette  *Cassette
+	transport http.RoundTripper
+	// Redact lists the request and response headers not recorded
+	Redact []string
+}
+
+// NewRecorder returns a Recorder adding interactions to c, requests are sent
+// with rt or http.DefaultTransport when nil
+func NewRecorder(c *Cassette, rt http.RoundTripper) *Recorder {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &Recorder{
+		cassette:  c,
+		transport: rt,
+		Redact:    DefaultRedactedHeaders,
+	}
+}
+
+// Cassette returns the Cassette interactions are recorded to
+func (r *Recorder) Cassette() *Cassette {
+	return r.cassette
+}
+
+// RoundTrip satisfies the http.RoundTripper interface
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.cassette.Add(Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redact(req.Header),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     r.redact(res.Header),
+			Body:       body,
+		},
+	})
+	return res, nil
+}
+
+// redact returns a copy of h without the redacted headers
+func (r *Recorder) redact(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	h = h.Clone()
+	for _, k := range r.Redact {
+		h.Del(k)
+	}
+	return h
+}
@@ -0,0 +1,112 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// ErrNoInteraction is returned when a request was not recorded
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// MatchMode enum decides which recorded interactions match a request
+type MatchMode uint8
+
+const (
+	_ MatchMode = iota
+	// MatchExact matches the method and the full URL
+	MatchExact
+	// MatchIgnoreQuery matches the method and the URL without its query
+	// string, e.g. cache busting parameters
+	MatchIgnoreQuery
+)
+
+// Replayer is a http.RoundTripper serving the interactions of a Cassette.
+// Interactions matching a request are served in the order recorded, the last
+// one is repeated once they are exhausted
+type Replayer struct {
+	cassette *Cassette
+	mode     MatchMode
+
+	mu     sync.Mutex
+	served map[string]int // interactions served per key
+}
+
+// NewReplayer returns a Replayer serving the interactions of c
+func NewReplayer(c *Cassette, mode MatchMode) *Replayer {
+	if mode == 0 {
+		mode = MatchExact
+	}
+	return &Replayer{
+		cassette: c,
+		mode:     mode,
+		served:   make(map[string]int),
+	}
+}
+
+// RoundTrip satisfies the http.RoundTripper interface
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := r.key(req.Method, req.URL)
+	var matches []Interaction
+	for _, i := range r.cassette.Interactions() {
+		u, err := url.Parse(i.Request.URL)
+		if err != nil {
+			continue
+		}
+		if r.key(i.Request.Method, u) == key {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+	}
+
+	r.mu.Lock()
+	n := r.served[key]
+	r.served[key]++
+	r.mu.Unlock()
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+
+	rec := matches[n].Response
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(rec.StatusCode) + " " + http.StatusText(rec.StatusCode),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// key identifies the interactions matching a request
+func (r *Replayer) key(method string, u *url.URL) string {
+	if r.mode == MatchIgnoreQuery {
+		v := *u
+		v.RawQuery, v.ForceQuery = "", false
+		u = &v
+	}
+	v := *u
+	v.Fragment, v.RawFragment = "", ""
+	return method + " " + v.String()
+}
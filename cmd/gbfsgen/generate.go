@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// header fields of every feed, generated as an embedded gbfs.Output
+var header = []string{"last_updated", "ttl", "version"}
+
+// named are the fields types of properties by name, and of the items of
+// array properties
+var named = map[string]string{
+	"lat":            "f.Latitude",
+	"lon":            "f.Longitude",
+	"language":       "f.Language",
+	"timezone":       "f.Timezone",
+	"phone_number":   "f.PhoneNumber",
+	"currency":       "f.Currency",
+	"price":          "f.Price",
+	"last_updated":   "f.Timestamp",
+	"last_reported":  "f.Timestamp",
+	"start":          "f.Timestamp",
+	"end":            "f.Timestamp",
+	"start_time":     "f.Time",
+	"end_time":       "f.Time",
+	"start_day":      "f.Day",
+	"end_day":        "f.Day",
+	"start_month":    "f.Month",
+	"end_month":      "f.Month",
+	"start_year":     "f.Year",
+	"end_year":       "f.Year",
+	"days":           "f.DayOfWeek",
+	"user_types":     "f.UserType",
+	"rental_methods": "f.RentalMethod",
+	"type":           "f.AlertType",
+}
+
+// pointers are the types generated as pointers for optional properties: the
+// fields types encoded from a struct so omitempty applies, numbers and
+// booleans so an explicit 0 or false is kept
+var pointers = map[string]bool{
+	"f.Currency":         true,
+	"f.Date":             true,
+	"f.Email":            true,
+	"f.Language":         true,
+	"f.Price":            true,
+	"f.Time":             true,
+	"f.Timestamp":        true,
+	"f.Timezone":         true,
+	"f.URI":              true,
+	"f.URL":              true,
+	"f.NonNegativeInt":   true,
+	"f.NonNegativeFloat": true,
+	"f.Boolean":          true,
+	"int":                true,
+	"float64":            true,
+	"bool":               true,
+}
+
+// initialisms of the words of property names
+var initialisms = map[string]string{
+	"gbfs": "GBFS",
+	"id":   "ID",
+	"ids":  "IDs",
+	"ios":  "IOS",
+	"ttl":  "TTL",
+	"uri":  "URI",
+	"uris": "URIs",
+	"url":  "URL",
+}
+
+// packageName returns the name of the package of version, e.g. v20 for 2.0
+func packageName(version string) string {
+	return "v" + strings.ReplaceAll(version, ".", "")
+}
+
+// generator of the types of the feeds of a version
+type generator struct {
+	version string
+	names   map[string]bool
+	feeds   map[string]bool
+	decls   []string
+}
+
+// generate returns the formatted source of the package of the feeds with a
+// schema in fsys, named <feed>.json
+func generate(fsys fs.FS, version string) ([]byte, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	g := &generator{version: version, names: make(map[string]bool), feeds: make(map[string]bool)}
+	for _, file := range files {
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		s := new(schema)
+		if err := json.Unmarshal(raw, s); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		g.feed(strings.TrimSuffix(path.Base(file), ".json"), s)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by gbfsgen from the GBFS v%s JSON Schemas. DO NOT EDIT.\n\n", version)
+	fmt.Fprintf(&b, "// Package %s contains the feed types of GBFS v%s\n", packageName(version), version)
+	b.WriteString("//\n// The types of the gbfs package are hand-written, these are generated from\n// the schemas of the version and follow them field for field\n")
+	fmt.Fprintf(&b, "package %s\n\n", packageName(version))
+	b.WriteString("import (\n\t\"github.com/marz619/gbfs-go\"\n\tf \"github.com/marz619/gbfs-go/fields\"\n)\n")
+	for _, d := range g.decls {
+		b.WriteString("\n" + d)
+	}
+	return format.Source([]byte(b.String()))
+}
+
+// feed declares the type of feed, named after it
+func (g *generator) feed(feed string, s *schema) {
+	name := goName(feed)
+	g.names[name] = true
+	g.feeds[name] = true
+	at := g.reserve()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s\n//\n", name, s.ID)
+	comment(&b, s.Description, 0)
+	fmt.Fprintf(&b, "type %s ", name)
+
+	output := true
+	for _, h := range header {
+		output = output && s.property(h) != nil
+	}
+	b.WriteString(g.structOf(name, s, output, 0))
+	g.decls[at] = b.String() + "\n"
+}
+
+// reserve returns the index of a declaration, reserved so types are declared
+// before the types of their fields
+func (g *generator) reserve() int {
+	g.decls = append(g.decls, "")
+	return len(g.decls) - 1
+}
+
+// structOf returns the struct type of the object s declared at depth, output
+// embeds gbfs.Output in place of the header fields
+func (g *generator) structOf(owner string, s *schema, output bool, depth int) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	if output {
+		b.WriteString("gbfs.Output\n")
+	}
+	for _, p := range s.Properties {
+		if output && isHeader(p.name) {
+			continue
+		}
+		required := s.requires(p.name)
+		tag := p.name
+		if !required {
+			tag += ",omitempty"
+		}
+		comment(&b, p.schema.Description, depth+1)
+		fmt.Fprintf(&b, "%s %s `json:\"%s\"`\n", goName(p.name), g.typeOf(owner, p.name, p.schema, required, depth+1), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// typeOf returns the type of the property name of owner declared at depth
+func (g *generator) typeOf(owner, name string, s *schema, required bool, depth int) string {
+	var t string
+	switch {
+	case s.is("array") && s.Items != nil:
+		return "[]" + g.elem(owner, name, s, depth)
+	case s.is("object") && len(s.Properties) == 0 && len(s.PatternProperties) == 1:
+		return "map[string]" + g.typeOf(owner, name, s.PatternProperties[0].schema, true, depth)
+	case s.is("object"):
+		t = g.structOf(owner, s, false, depth)
+	default:
+		t = scalar(name, s)
+		if !pointers[t] {
+			return t
+		}
+	}
+	if !required {
+		t = "*" + t
+	}
+	return t
+}
+
+// elem returns the type of the items of the array property name of owner,
+// objects are declared as a type named after an item, prefixed with owner
+// when it is not a feed or the name is taken
+func (g *generator) elem(owner, name string, arr *schema, depth int) string {
+	items := arr.Items
+	if !items.is("object") || len(items.Properties) == 0 {
+		return g.typeOf(owner, name, items, true, depth)
+	}
+
+	t := goName(singular(name))
+	if !g.feeds[owner] || g.names[t] {
+		t = owner + t
+	}
+	g.names[t] = true
+	at := g.reserve()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is an entry of the %s of %s\n", t, name, owner)
+	if arr.Description != "" {
+		b.WriteString("//\n")
+		comment(&b, arr.Description, 0)
+	}
+	fmt.Fprintf(&b, "type %s %s\n", t, g.structOf(t, items, false, 0))
+	g.decls[at] = b.String()
+	return t
+}
+
+// scalar returns the type of the property name with a scalar schema s
+func scalar(name string, s *schema) string {
+	if t, ok := named[name]; ok {
+		return t
+	}
+
+	switch {
+	case s.is("string"):
+		switch {
+		case name == "id", strings.HasSuffix(name, "_id"), strings.HasSuffix(name, "_ids"):
+			return "f.ID"
+		case s.Format == "uri" && strings.Contains(name, "url"):
+			return "f.URL"
+		case s.Format == "uri":
+			return "f.URI"
+		case s.Format == "email":
+			return "f.Email"
+		case s.Format == "date":
+			return "f.Date"
+		}
+		return "string"
+	case s.is("boolean"):
+		return "bool"
+	case strings.HasPrefix(name, "is_") && bounded(s, 0, 1):
+		// 1.x booleans are 0 or 1
+		return "f.Boolean"
+	case s.is("integer"):
+		if s.Minimum != nil && *s.Minimum >= 0 {
+			return "f.NonNegativeInt"
+		}
+		return "int"
+	case s.is("number"):
+		if s.Minimum != nil && *s.Minimum >= 0 {
+			return "f.NonNegativeFloat"
+		}
+		return "float64"
+	}
+	return "any"
+}
+
+// bounded reports whether the minimum and maximum of s are min and max
+func bounded(s *schema, min, max float64) bool {
+	return s.Minimum != nil && *s.Minimum == min && s.Maximum != nil && *s.Maximum == max
+}
+
+func isHeader(name string) bool {
+	for _, h := range header {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+// goName returns the exported Go name of a snake case name, e.g. StationID for
+// station_id
+func goName(name string) string {
+	var b strings.Builder
+	for _, w := range strings.Split(name, "_") {
+		if i, ok := initialisms[w]; ok {
+			b.WriteString(i)
+			continue
+		}
+		if w != "" {
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
+
+// singular returns the singular of the plural name, e.g. rental_hour for
+// rental_hours
+func singular(name string) string {
+	if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// comment writes text as a comment indented at depth, wrapped at 80 columns
+// with tabs of 4
+func comment(b *strings.Builder, text string, depth int) {
+	if text == "" {
+		return
+	}
+	line := "//"
+	for _, w := range strings.Fields(text) {
+		if 4*depth+len(line)+1+len(w) > 80 && line != "//" {
+			b.WriteString(line + "\n")
+			line = "//"
+		}
+		line += " " + w
+	}
+	b.WriteString(line + "\n")
+}
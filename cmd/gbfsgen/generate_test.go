@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerated checks the generated packages are up to date with the schemas
+func TestGenerated(t *testing.T) {
+	entries, err := os.ReadDir("../../schema")
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		version := strings.TrimPrefix(e.Name(), "v")
+		src, err := generate(os.DirFS(filepath.Join("../../schema", e.Name())), version)
+		require.NoError(t, err)
+
+		actual, err := os.ReadFile(filepath.Join("../../spec", packageName(version), "feeds.go"))
+		require.NoError(t, err)
+		assert.Equal(t, string(src), string(actual), "run go generate ./schema")
+	}
+}
+
+// TestGenerate ...
+func TestGenerate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"vehicle_status.json": {Data: []byte(`{
+			"$id": "https://example.com/gbfs.md#vehicle_statusjson",
+			"description": "Vehicles.",
+			"properties": {
+				"last_updated": {"type": "integer"},
+				"ttl": {"type": "integer"},
+				"version": {"type": "string"},
+				"data": {"type": "object", "properties": {
+					"vehicles": {"type": "array", "description": "Every vehicle.", "items": {"type": "object", "properties": {
+						"vehicle_id": {"type": "string"},
+						"is_reserved": {"type": "number", "minimum": 0, "maximum": 1},
+						"range_meters": {"type": "number", "minimum": 0},
+						"home_url": {"type": "string", "format": "uri"},
+						"zones": {"type": "array", "items": {"type": "object", "properties": {"rank": {"type": "integer"}}}}
+					}, "required": ["vehicle_id", "is_reserved"]}}
+				}, "required": ["vehicles"]}
+			}
+		}`)},
+	}
+
+	src, err := generate(fsys, "9.1")
+	require.NoError(t, err)
+	for _, expected := range []string{
+		"// Code generated by gbfsgen from the GBFS v9.1 JSON Schemas. DO NOT EDIT.",
+		"package v91",
+		"// VehicleStatus https://example.com/gbfs.md#vehicle_statusjson\n//\n// Vehicles.\ntype VehicleStatus struct {\n\tgbfs.Output\n",
+		"\t\tVehicles []Vehicle `json:\"vehicles\"`\n",
+		"// Vehicle is an entry of the vehicles of VehicleStatus\n//\n// Every vehicle.\ntype Vehicle struct {",
+		"\tVehicleID   f.ID                `json:\"vehicle_id\"`\n",
+		"\tIsReserved  f.Boolean           `json:\"is_reserved\"`\n",
+		"\tRangeMeters *f.NonNegativeFloat `json:\"range_meters,omitempty\"`\n",
+		"\tHomeURL     *f.URL              `json:\"home_url,omitempty\"`\n",
+		"\tZones       []VehicleZone       `json:\"zones,omitempty\"`\n",
+		"\tRank *int `json:\"rank,omitempty\"`\n",
+	} {
+		assert.Contains(t, string(src), expected)
+	}
+	assert.NotContains(t, string(src), "LastUpdated")
+}
+
+// TestGoName ...
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"gbfs_versions":      "GBFSVersions",
+		"station_id":         "StationID",
+		"station_ids":        "StationIDs",
+		"rental_uris":        "RentalURIs",
+		"ios":                "IOS",
+		"num_docks_disabled": "NumDocksDisabled",
+	} {
+		assert.Equal(t, expected, goName(name))
+	}
+	assert.Equal(t, "rental_hour", singular("rental_hours"))
+	assert.Equal(t, "address", singular("address"))
+}
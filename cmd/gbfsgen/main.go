@@ -0,0 +1,62 @@
+// Command gbfsgen generates the Go types of the GBFS feeds from their JSON
+// Schemas
+//
+// Every directory v<version> of the schemas directory, e.g. schema/v2.0,
+// produces a package of the output directory named after the version, e.g.
+// spec/v20, with a type per feed using the types of the fields package.
+// Adding a spec version is adding its schemas and running go generate on the
+// schema package
+//
+// The types of the gbfs package in output.go stay hand-written: they span
+// versions and carry methods and names the schemas do not define, the
+// generated types follow the schemas of a single version field for field
+//
+//	gbfsgen -schemas schema -out spec
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	schemas := flag.String("schemas", "schema", "directory of the v<version> schema directories")
+	out := flag.String("out", "spec", "directory of the generated packages")
+	flag.Parse()
+
+	if err := run(*schemas, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "gbfsgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the package of every version of schemas in out
+func run(schemas, out string) error {
+	entries, err := os.ReadDir(schemas)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "v") {
+			continue
+		}
+		version := strings.TrimPrefix(e.Name(), "v")
+		src, err := generate(os.DirFS(filepath.Join(schemas, e.Name())), version)
+		if err != nil {
+			return fmt.Errorf("v%s: %w", version, err)
+		}
+
+		dir := filepath.Join(out, packageName(version))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "feeds.go"), src, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// schema is the part of a JSON Schema describing the Go type of a value
+type schema struct {
+	ID                string            `json:"$id"`
+	Description       string            `json:"description"`
+	Type              types             `json:"type"`
+	Properties        properties        `json:"properties"`
+	PatternProperties properties        `json:"patternProperties"`
+	Required          []string          `json:"required"`
+	Items             *schema           `json:"items"`
+	Enum              []json.RawMessage `json:"enum"`
+	Format            string            `json:"format"`
+	Minimum           *float64          `json:"minimum"`
+	Maximum           *float64          `json:"maximum"`
+}
+
+// is reports whether t is one of the types of s
+func (s *schema) is(t string) bool {
+	for _, st := range s.Type {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
+
+// requires reports whether the property name of s is required
+func (s *schema) requires(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// property returns the schema of the property name of s, nil when missing
+func (s *schema) property(name string) *schema {
+	for _, p := range s.Properties {
+		if p.name == name {
+			return p.schema
+		}
+	}
+	return nil
+}
+
+// types of the type keyword, a string or an array of strings
+type types []string
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (t *types) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = types{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+type property struct {
+	name   string
+	schema *schema
+}
+
+// properties of an object in the order of the schema, which is the order of
+// the generated fields
+type properties []property
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (p *properties) UnmarshalJSON(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return errors.New("properties must be an object")
+	}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		s := new(schema)
+		if err := d.Decode(s); err != nil {
+			return err
+		}
+		*p = append(*p, property{name: t.(string), schema: s})
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+	"github.com/marz619/gbfs-go/server"
+)
+
+// Fetcher returns a gbfs.Fetcher converting the documents retrieved by fe,
+// e.g. for a gbfs.Client or a proxy. Feeds are named after the document of
+// their URL and 3.0 documents are read in the first of Options.Languages
+func (c *Converter) Fetcher(fe gbfs.Fetcher) gbfs.Fetcher {
+	return gbfs.FetcherFunc(func(ctx context.Context, rawURL string) ([]byte, error) {
+		doc, err := fe.Fetch(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		return c.Document(feedOf(rawURL), "", doc)
+	})
+}
+
+// Provider returns a server.Provider converting the documents of feed
+// provided by p in each language
+func (c *Converter) Provider(feed string, p server.Provider) server.Provider {
+	return func(ctx context.Context, lang f.Language) (any, error) {
+		doc, err := p(ctx, lang)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		out, err := c.Document(feed, lang.String(), raw)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(out), nil
+	}
+}
+
+// feedOf returns the name of the feed at rawURL
+func feedOf(rawURL string) string {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		p = u.Path
+	}
+	return strings.TrimSuffix(path.Base(p), ".json")
+}
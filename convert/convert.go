@@ -0,0 +1,568 @@
+// Package convert translates GBFS documents between versions 1.x, 2.x and 3.0
+//
+// Documents are converted as generic JSON so fields unknown to this module are
+// kept. Feeds and fields are renamed, timestamps re-encoded and the
+// translated strings of 3.0 split into per-language documents and back.
+// Conversions losing data are reported as Warnings
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultLanguage of documents without a language
+const DefaultLanguage = "en"
+
+var (
+	// ErrUnsupportedVersion is returned for versions other than Versions
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	// ErrNoEquivalent is returned for feeds that do not exist in the target
+	// version, e.g. system_calendar in 3.0
+	ErrNoEquivalent = errors.New("no equivalent feed")
+	// ErrVersionMismatch is returned when the documents of a feed are of
+	// different versions
+	ErrVersionMismatch = errors.New("documents of different versions")
+	// ErrNoDocument is returned when there is no document to convert
+	ErrNoDocument = errors.New("no document")
+)
+
+// Warning reports a lossy conversion of a field
+type Warning struct {
+	Feed     string `json:"feed"`
+	Language string `json:"language,omitempty"`
+	// Field is the path of the field, e.g. data.stations[].rental_uris
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// Count of the values concerned
+	Count int `json:"count"`
+}
+
+func (w Warning) String() string {
+	feed := w.Feed
+	if w.Language != "" {
+		feed += "[" + w.Language + "]"
+	}
+	return fmt.Sprintf("%s %s: %s (%d)", feed, w.Field, w.Message, w.Count)
+}
+
+// Documents of a feed by language, documents that are not per language, e.g.
+// gbfs.json or any 3.0 document, are keyed by ""
+type Documents map[string][]byte
+
+// Result of a conversion
+type Result struct {
+	// Feed is the name of the feed in Version
+	Feed      string
+	Version   string
+	Documents Documents
+	Warnings  []Warning
+}
+
+// Options configure a Converter
+type Options struct {
+	// Languages of the 2.x gbfs.json converted from 3.0 and of documents
+	// without a language, DefaultLanguage when empty
+	Languages []string
+	// OnWarning is called with the Warnings of every conversion
+	OnWarning func(Warning)
+}
+
+// Converter converts documents to a version
+type Converter struct {
+	to   version
+	opts Options
+}
+
+// New returns a Converter of documents to version to, one of Versions
+func New(to string, opts Options) (*Converter, error) {
+	v, err := parseVersion(to)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{to: v, opts: opts}, nil
+}
+
+// Version returns the version documents are converted to
+func (c *Converter) Version() string {
+	return c.to.String()
+}
+
+// Convert returns the documents of feed converted, 2.x documents are merged
+// into a single 3.0 document and 3.0 documents split per language
+func (c *Converter) Convert(feed string, docs Documents) (*Result, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoDocument, feed)
+	}
+
+	name := v2Name(feed)
+	if sp, ok := feeds[name]; ok && !sp.has(c.to) {
+		return nil, fmt.Errorf("%w: %s in %s", ErrNoEquivalent, feed, c.to)
+	}
+
+	objs := make(map[string]object, len(docs))
+	var from version
+	for _, lang := range sortedKeys(docs) {
+		o, err := decode(docs[lang])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", feed, err)
+		}
+		v, err := versionOf(o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", feed, err)
+		}
+		if len(objs) > 0 && v != from {
+			return nil, fmt.Errorf("%w: %s is %s and %s", ErrVersionMismatch, feed, from, v)
+		}
+		objs[lang], from = o, v
+	}
+
+	r := &report{feed: feedName(feed, c.to)}
+	var out map[string]object
+	switch {
+	case name == "gbfs":
+		out = make(map[string]object, len(objs))
+		for lang, o := range objs {
+			out[lang] = c.discovery(o, from, r)
+		}
+	case from.major >= 3 && c.to.major < 3:
+		out = make(map[string]object)
+		for _, lang := range sortedKeys(objs) {
+			for l, o := range c.split(name, objs[lang], r) {
+				out[l] = o
+			}
+		}
+	case from.major < 3 && c.to.major >= 3:
+		out = map[string]object{"": c.merge(name, objs, r)}
+	default:
+		out = objs
+	}
+
+	res := &Result{Feed: r.feed, Version: c.to.String(), Documents: make(Documents, len(out))}
+	for _, lang := range sortedKeys(out) {
+		o := out[lang]
+		r.lang = lang
+		c.fields(name, o, from, r)
+		c.timestamps(o, r)
+		o["version"] = c.to.String()
+
+		raw, err := json.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", feed, err)
+		}
+		res.Documents[lang] = raw
+	}
+
+	res.Warnings = r.warnings
+	if c.opts.OnWarning != nil {
+		for _, w := range r.warnings {
+			c.opts.OnWarning(w)
+		}
+	}
+	return res, nil
+}
+
+// Document returns doc of feed in lang converted, the document of lang is
+// picked from a 3.0 document, or the first of Options.Languages when lang is
+// empty. Warnings are reported to Options.OnWarning
+func (c *Converter) Document(feed, lang string, doc []byte) ([]byte, error) {
+	res, err := c.Convert(feed, Documents{lang: doc})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range []string{lang, "", c.languages()[0]} {
+		if d, ok := res.Documents[l]; ok {
+			return d, nil
+		}
+	}
+	return res.Documents[sortedKeys(res.Documents)[0]], nil
+}
+
+// languages returns Options.Languages or DefaultLanguage
+func (c *Converter) languages() []string {
+	if len(c.opts.Languages) == 0 {
+		return []string{DefaultLanguage}
+	}
+	return c.opts.Languages
+}
+
+// discovery converts the feeds of gbfs.json, listed per language before 3.0
+func (c *Converter) discovery(o object, from version, r *report) object {
+	data, _ := o["data"].(object)
+	if data == nil {
+		return o
+	}
+
+	switch {
+	case from.major < 3 && c.to.major >= 3:
+		langs := sortedKeys(data)
+		if len(langs) == 0 {
+			return o
+		}
+		if len(langs) > 1 {
+			r.warn("data", fmt.Sprintf("feeds of %v dropped, 3.0 lists feeds once", langs[1:]), len(langs)-1)
+		}
+		feeds, _ := data[langs[0]].(object)
+		r.lang = langs[0]
+		o["data"] = object{"feeds": c.feedList(feeds["feeds"], r)}
+		r.lang = ""
+	case from.major >= 3 && c.to.major < 3:
+		next := make(object)
+		for _, lang := range c.languages() {
+			r.lang = lang
+			next[lang] = object{"feeds": c.feedList(data["feeds"], r)}
+		}
+		r.lang = ""
+		o["data"] = next
+	default:
+		for _, lang := range sortedKeys(data) {
+			if feeds, ok := data[lang].(object); ok {
+				r.lang = lang
+				feeds["feeds"] = c.feedList(feeds["feeds"], r)
+			}
+		}
+		r.lang = ""
+	}
+	return o
+}
+
+// feedList returns a copy of the feeds of gbfs.json named in the target
+// version, without the feeds it does not have
+func (c *Converter) feedList(v any, r *report) []any {
+	list, _ := v.([]any)
+	out := make([]any, 0, len(list))
+	for _, item := range list {
+		fd, ok := item.(object)
+		if !ok {
+			continue
+		}
+		name, _ := fd["name"].(string)
+		if sp, ok := feeds[v2Name(name)]; ok && !sp.has(c.to) {
+			r.warn("data.feeds[].name", fmt.Sprintf("%s dropped, not in %s", name, c.to), 1)
+			continue
+		}
+
+		cp := make(object, len(fd))
+		for k, v := range fd {
+			cp[k] = v
+		}
+		cp["name"] = feedName(name, c.to)
+		out = append(out, cp)
+	}
+	return out
+}
+
+// split returns the documents of each language of a 3.0 document
+func (c *Converter) split(name string, o object, r *report) map[string]object {
+	paths := localized[name]
+
+	seen := make(map[string]bool)
+	var langs []string
+	addLang := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			langs = append(langs, l)
+		}
+	}
+	if name == "system_information" {
+		if data, ok := o["data"].(object); ok {
+			ls, _ := data["languages"].([]any)
+			for _, l := range ls {
+				s, _ := l.(string)
+				addLang(s)
+			}
+		}
+	}
+	for _, p := range paths {
+		each(o, p, false, func(o object, key, _ string) {
+			for _, t := range translations(o[key]) {
+				addLang(t.Language)
+			}
+		})
+	}
+	if len(langs) == 0 {
+		return map[string]object{"": o}
+	}
+
+	out := make(map[string]object, len(langs))
+	for _, lang := range langs {
+		cp := clone(o)
+		r.lang = lang
+		for _, p := range paths {
+			missing := 0
+			var fallback string
+			each(cp, p, false, func(o object, key, _ string) {
+				ts := translations(o[key])
+				if len(ts) == 0 {
+					return
+				}
+				for _, t := range ts {
+					if t.Language == lang {
+						o[key] = t.Text
+						return
+					}
+				}
+				missing++
+				o[key], fallback = ts[0].Text, ts[0].Language
+			})
+			if missing > 0 {
+				r.warn(p, fmt.Sprintf("no %s translation, %s used", lang, fallback), missing)
+			}
+		}
+		if data, ok := cp["data"].(object); ok && name == "system_information" {
+			delete(data, "languages")
+			data["language"] = lang
+		}
+		out[lang] = cp
+	}
+	r.lang = ""
+	return out
+}
+
+// merge returns the 3.0 document of the per language documents objs
+func (c *Converter) merge(name string, objs map[string]object, r *report) object {
+	byLang := make(map[string]object, len(objs))
+	for key, o := range objs {
+		lang := key
+		if lang == "" {
+			lang = c.languages()[0]
+			if data, ok := o["data"].(object); ok && name == "system_information" {
+				if l, ok := data["language"].(string); ok && l != "" {
+					lang = l
+				}
+			}
+		}
+		byLang[lang] = o
+	}
+	langs := sortedKeys(byLang)
+	base := byLang[langs[0]]
+
+	paths := localized[name]
+	if len(paths) == 0 && len(langs) > 1 {
+		raw, _ := json.Marshal(base)
+		for _, lang := range langs[1:] {
+			if other, _ := json.Marshal(byLang[lang]); !bytes.Equal(raw, other) {
+				r.lang = lang
+				r.warn("data", fmt.Sprintf("document differs from %s, dropped", langs[0]), 1)
+			}
+		}
+		r.lang = ""
+	}
+
+	for _, p := range paths {
+		values := make(map[string]map[string]any, len(langs))
+		for _, lang := range langs {
+			values[lang] = make(map[string]any)
+			each(byLang[lang], p, false, func(o object, key, at string) {
+				values[lang][at] = o[key]
+			})
+		}
+
+		missing := make(map[string]int)
+		each(base, p, false, func(o object, key, at string) {
+			ts := make([]any, 0, len(langs))
+			for _, lang := range langs {
+				v, ok := values[lang][at]
+				if !ok || v == nil {
+					missing[lang]++
+					continue
+				}
+				ts = append(ts, object{"text": v, "language": lang})
+			}
+			o[key] = ts
+		})
+		for _, lang := range sortedKeys(missing) {
+			r.lang = lang
+			r.warn(p, "no translation", missing[lang])
+		}
+		r.lang = ""
+	}
+
+	if data, ok := base["data"].(object); ok && name == "system_information" {
+		delete(data, "language")
+		ls := make([]any, 0, len(langs))
+		for _, l := range langs {
+			ls = append(ls, l)
+		}
+		data["languages"] = ls
+	}
+	return base
+}
+
+// fields renames the fields of o, drops the fields missing from the target
+// version and fills the fields 2.x requires of 1.x documents
+func (c *Converter) fields(name string, o object, from version, r *report) {
+	switch {
+	case from.major < 3 && c.to.major >= 3:
+		for _, rn := range renames[name] {
+			renameField(o, rn.v2Parent+"."+rn.v2, rn.v3)
+		}
+	case from.major >= 3 && c.to.major < 3:
+		for _, rn := range renames[name] {
+			renameField(o, rn.v3Parent+"."+rn.v3, rn.v2)
+		}
+	}
+
+	if c.to.less(from) {
+		for _, fd := range added[name] {
+			if !c.to.less(fd.since) {
+				continue
+			}
+			p := fd.parent + "." + fd.key
+			n := 0
+			each(o, p, false, func(o object, key, _ string) {
+				delete(o, key)
+				n++
+			})
+			if n > 0 {
+				r.warn(p, fmt.Sprintf("dropped, added in %s", fd.since), n)
+			}
+		}
+	}
+
+	if from.major < 2 && c.to.major >= 2 && name == "station_status" {
+		for _, key := range []string{"is_installed", "is_renting", "is_returning"} {
+			p := "data.stations[]." + key
+			n := 0
+			each(o, p, true, func(o object, key, _ string) {
+				if _, ok := o[key]; !ok {
+					o[key] = true
+					n++
+				}
+			})
+			if n > 0 {
+				r.warn(p, "missing, defaulted to true", n)
+			}
+		}
+
+		n := 0
+		each(o, "data.stations[].last_reported", true, func(s object, key, _ string) {
+			if _, ok := s[key]; !ok && o["last_updated"] != nil {
+				s[key] = o["last_updated"]
+				n++
+			}
+		})
+		if n > 0 {
+			r.warn("data.stations[].last_reported", "missing, defaulted to last_updated", n)
+		}
+	}
+}
+
+// renameField renames the field at path to key
+func renameField(o object, path, key string) {
+	each(o, path, false, func(o object, old, _ string) {
+		o[key] = o[old]
+		delete(o, old)
+	})
+}
+
+// timestamps encodes the timestamps of o as RFC3339 strings in 3.0 or Unix
+// seconds before
+func (c *Converter) timestamps(o object, r *report) {
+	enc := f.UnixSeconds
+	if c.to.major >= 3 {
+		enc = f.RFC3339
+	}
+
+	for _, p := range timestamps {
+		truncated := 0
+		each(o, p, false, func(o object, key, _ string) {
+			if o[key] == nil {
+				return
+			}
+			raw, err := json.Marshal(o[key])
+			if err != nil {
+				return
+			}
+			var ts f.Timestamp
+			if err := json.Unmarshal(raw, &ts); err != nil || ts.IsZero() {
+				return
+			}
+			if enc == f.UnixSeconds && ts.Nanosecond() != 0 {
+				truncated++
+			}
+			if raw, err = json.Marshal(ts.WithEncoding(enc)); err == nil {
+				o[key] = json.RawMessage(raw)
+			}
+		})
+		if truncated > 0 {
+			r.warn(p, "truncated to seconds", truncated)
+		}
+	}
+}
+
+// versionOf returns the version of document o, 1.0 documents may not have one
+func versionOf(o object) (version, error) {
+	v, _ := o["version"].(string)
+	if v == "" {
+		return version{1, 0}, nil
+	}
+	return parseVersion(v)
+}
+
+// translation of a 3.0 localized string
+type translation struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+}
+
+// translations returns the translations of a localized string v
+func translations(v any) []translation {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	ts := make([]translation, 0, len(list))
+	for _, item := range list {
+		o, ok := item.(object)
+		if !ok {
+			continue
+		}
+		text, _ := o["text"].(string)
+		lang, _ := o["language"].(string)
+		ts = append(ts, translation{text, lang})
+	}
+	return ts
+}
+
+// clone returns a deep copy of o
+func clone(o object) object {
+	raw, _ := json.Marshal(o)
+	cp, _ := decode(raw)
+	return cp
+}
+
+// report collects the Warnings of a conversion
+type report struct {
+	feed     string
+	lang     string
+	warnings []Warning
+}
+
+// warn reports n values of the field at path converted with loss
+func (r *report) warn(path, msg string, n int) {
+	for i := range r.warnings {
+		w := &r.warnings[i]
+		if w.Language == r.lang && w.Field == path && w.Message == msg {
+			w.Count += n
+			return
+		}
+	}
+	r.warnings = append(r.warnings, Warning{Feed: r.feed, Language: r.lang, Field: path, Message: msg, Count: n})
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,284 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+	"github.com/marz619/gbfs-go/gbfstest"
+	"github.com/marz619/gbfs-go/server"
+)
+
+func converter(t *testing.T, to string, opts Options) *Converter {
+	t.Helper()
+
+	c, err := New(to, opts)
+	require.NoError(t, err)
+	return c
+}
+
+// convert returns the documents of feed converted to version to
+func convert(t *testing.T, to, feed string, docs map[string]string) *Result {
+	t.Helper()
+
+	in := make(Documents, len(docs))
+	for lang, doc := range docs {
+		in[lang] = []byte(doc)
+	}
+	res, err := converter(t, to, Options{Languages: []string{"en", "fr"}}).Convert(feed, in)
+	require.NoError(t, err)
+	return res
+}
+
+func warnings(res *Result) []string {
+	out := make([]string, 0, len(res.Warnings))
+	for _, w := range res.Warnings {
+		out = append(out, w.String())
+	}
+	return out
+}
+
+// TestConvertVehicleStatus ...
+func TestConvertVehicleStatus(t *testing.T) {
+	v2 := `{"last_updated":1609866247,"ttl":10,"version":"2.3","data":{"bikes":[
+		{"bike_id":"a","lat":43.6,"lon":-79.4,"is_reserved":false,"is_disabled":false,"last_reported":1609866200}
+	]}}`
+	v3 := `{"last_updated":"2021-01-05T17:04:07Z","ttl":10,"version":"3.0","data":{"vehicles":[
+		{"vehicle_id":"a","lat":43.6,"lon":-79.4,"is_reserved":false,"is_disabled":false,"last_reported":"2021-01-05T17:03:20Z"}
+	]}}`
+
+	res := convert(t, "3.0", "free_bike_status", map[string]string{"en": v2})
+	assert.Equal(t, "vehicle_status", res.Feed)
+	require.Contains(t, res.Documents, "")
+	assert.JSONEq(t, v3, string(res.Documents[""]))
+	assert.Empty(t, res.Warnings)
+
+	res = convert(t, "2.3", "vehicle_status", map[string]string{"": v3})
+	assert.Equal(t, "free_bike_status", res.Feed)
+	assert.JSONEq(t, v2, string(res.Documents[""]))
+
+	// 1.1 has no vehicle types or last_reported
+	res = convert(t, "1.1", "vehicle_status", map[string]string{"": v3})
+	assert.JSONEq(t, `{"last_updated":1609866247,"ttl":10,"version":"1.1","data":{"bikes":[
+		{"bike_id":"a","lat":43.6,"lon":-79.4,"is_reserved":false,"is_disabled":false}
+	]}}`, string(res.Documents[""]))
+	assert.Equal(t, []string{"free_bike_status data.bikes[].last_reported: dropped, added in 2.1 (1)"}, warnings(res))
+}
+
+// TestConvertLocalized ...
+func TestConvertLocalized(t *testing.T) {
+	v3 := `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"stations":[
+		{"station_id":"1","name":[{"text":"Union","language":"en"},{"text":"Gare Union","language":"fr"}],"lat":43.6,"lon":-79.4},
+		{"station_id":"2","name":[{"text":"King","language":"en"}],"lat":43.7,"lon":-79.3}
+	]}}`
+
+	res := convert(t, "2.3", "station_information", map[string]string{"": v3})
+	require.Len(t, res.Documents, 2)
+	assert.JSONEq(t, `{"last_updated":1609866247,"ttl":60,"version":"2.3","data":{"stations":[
+		{"station_id":"1","name":"Union","lat":43.6,"lon":-79.4},
+		{"station_id":"2","name":"King","lat":43.7,"lon":-79.3}
+	]}}`, string(res.Documents["en"]))
+	assert.JSONEq(t, `{"last_updated":1609866247,"ttl":60,"version":"2.3","data":{"stations":[
+		{"station_id":"1","name":"Gare Union","lat":43.6,"lon":-79.4},
+		{"station_id":"2","name":"King","lat":43.7,"lon":-79.3}
+	]}}`, string(res.Documents["fr"]))
+	assert.Equal(t, []string{"station_information[fr] data.stations[].name: no fr translation, en used (1)"}, warnings(res))
+
+	// and back
+	back := make(map[string]string, len(res.Documents))
+	for lang, doc := range res.Documents {
+		back[lang] = string(doc)
+	}
+	res = convert(t, "3.0", "station_information", back)
+	require.Len(t, res.Documents, 1)
+	assert.JSONEq(t, `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"stations":[
+		{"station_id":"1","name":[{"text":"Union","language":"en"},{"text":"Gare Union","language":"fr"}],"lat":43.6,"lon":-79.4},
+		{"station_id":"2","name":[{"text":"King","language":"en"},{"text":"King","language":"fr"}],"lat":43.7,"lon":-79.3}
+	]}}`, string(res.Documents[""]))
+}
+
+// TestConvertSystemInformation ...
+func TestConvertSystemInformation(t *testing.T) {
+	res := convert(t, "3.0", "system_information", map[string]string{
+		"": `{"last_updated":1609866247,"ttl":3600,"version":"2.2","data":{"system_id":"city","language":"fr","name":"Vélo","timezone":"America/Toronto","feed_contact_email":"a@b.c"}}`,
+	})
+	assert.JSONEq(t, `{"last_updated":"2021-01-05T17:04:07Z","ttl":3600,"version":"3.0","data":{
+		"system_id":"city","languages":["fr"],"name":[{"text":"Vélo","language":"fr"}],"timezone":"America/Toronto","feed_contact_email":"a@b.c"
+	}}`, string(res.Documents[""]))
+
+	res = convert(t, "2.0", "system_information", map[string]string{"": string(res.Documents[""])})
+	assert.JSONEq(t, `{"last_updated":1609866247,"ttl":3600,"version":"2.0","data":{
+		"system_id":"city","language":"fr","name":"Vélo","timezone":"America/Toronto"
+	}}`, string(res.Documents["fr"]))
+	assert.Equal(t, []string{"system_information[fr] data.feed_contact_email: dropped, added in 2.1 (1)"}, warnings(res))
+}
+
+// TestConvertDefaults ...
+func TestConvertDefaults(t *testing.T) {
+	res := convert(t, "2.0", "station_status", map[string]string{"en": `{"last_updated":1609866247,"ttl":10,"data":{"stations":[
+		{"station_id":"1","num_bikes_available":1,"num_docks_available":2,"is_installed":1,"is_renting":1,"is_returning":1,"last_reported":1609866000},
+		{"station_id":"2","num_bikes_available":3,"num_docks_available":4}
+	]}}`})
+	assert.JSONEq(t, `{"last_updated":1609866247,"ttl":10,"version":"2.0","data":{"stations":[
+		{"station_id":"1","num_bikes_available":1,"num_docks_available":2,"is_installed":1,"is_renting":1,"is_returning":1,"last_reported":1609866000},
+		{"station_id":"2","num_bikes_available":3,"num_docks_available":4,"is_installed":true,"is_renting":true,"is_returning":true,"last_reported":1609866247}
+	]}}`, string(res.Documents["en"]))
+	assert.Equal(t, []string{
+		"station_status[en] data.stations[].is_installed: missing, defaulted to true (1)",
+		"station_status[en] data.stations[].is_renting: missing, defaulted to true (1)",
+		"station_status[en] data.stations[].is_returning: missing, defaulted to true (1)",
+		"station_status[en] data.stations[].last_reported: missing, defaulted to last_updated (1)",
+	}, warnings(res))
+}
+
+// TestConvertDiscovery ...
+func TestConvertDiscovery(t *testing.T) {
+	v2 := `{"last_updated":1609866247,"ttl":60,"version":"2.3","data":{"en":{"feeds":[
+		{"name":"system_information","url":"https://example.com/en/system_information.json"},
+		{"name":"free_bike_status","url":"https://example.com/en/free_bike_status.json"},
+		{"name":"system_calendar","url":"https://example.com/en/system_calendar.json"}
+	]}}}`
+
+	res := convert(t, "3.0", "gbfs", map[string]string{"": v2})
+	v3 := `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"feeds":[
+		{"name":"system_information","url":"https://example.com/en/system_information.json"},
+		{"name":"vehicle_status","url":"https://example.com/en/free_bike_status.json"}
+	]}}`
+	assert.JSONEq(t, v3, string(res.Documents[""]))
+	assert.Equal(t, []string{"gbfs[en] data.feeds[].name: system_calendar dropped, not in 3.0 (1)"}, warnings(res))
+
+	res = convert(t, "2.3", "gbfs", map[string]string{"": v3})
+	var g gbfs.GBFS
+	require.NoError(t, json.Unmarshal(res.Documents[""], &g))
+	require.Len(t, g.Languages(), 2)
+	for _, l := range g.Languages() {
+		assert.Equal(t, []string{"system_information", "free_bike_status"}, g.Feeds(l).Names())
+	}
+}
+
+// TestConvertErrors ...
+func TestConvertErrors(t *testing.T) {
+	_, err := New("4.0", Options{})
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	c := converter(t, "3.0", Options{})
+	_, err = c.Convert("system_calendar", Documents{"": []byte(`{"version":"2.3"}`)})
+	assert.ErrorIs(t, err, ErrNoEquivalent)
+
+	_, err = c.Convert("system_regions", nil)
+	assert.ErrorIs(t, err, ErrNoDocument)
+
+	_, err = c.Convert("system_regions", Documents{"en": []byte(`{"version":"2.3"}`), "fr": []byte(`{"version":"2.2"}`)})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	_, err = c.Convert("system_regions", Documents{"": []byte(`{"version":"9.9"}`)})
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+// TestFetcher ...
+func TestFetcher(t *testing.T) {
+	upstream := gbfstest.NewServer(gbfstest.NewSystem(3, 2))
+	defer upstream.Close()
+
+	var warned []Warning
+	v3 := converter(t, "3.0", Options{})
+	v2 := converter(t, "2.3", Options{OnWarning: func(w Warning) { warned = append(warned, w) }})
+	fe := v2.Fetcher(v3.Fetcher(gbfs.NewHTTPFetcher(upstream.Client())))
+
+	// 2.0 documents through 3.0 and back
+	g, err := gbfs.NewFetcherClient(upstream.RootURL(), fe).GBFS()
+	require.NoError(t, err)
+	assert.Equal(t, "2.3", g.Version)
+	en, err := g.MatchLanguage()
+	require.NoError(t, err)
+
+	s, err := g.FetchAll(context.Background(), en, gbfs.FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Err())
+	assert.Equal(t, en, s.SystemInformation.Data.Language)
+	assert.Len(t, s.FreeBikeStatus.Data.Bikes, 2)
+	assert.Equal(t, f.ID("station-1"), s.StationStatus.Data.Stations[0].StationID)
+	assert.NotEmpty(t, s.StationInformation.Data.Stations[0].Name)
+	assert.Empty(t, warned)
+}
+
+// TestProvider ...
+func TestProvider(t *testing.T) {
+	now := time.Unix(1609866247, 0)
+	s, err := server.New("http://example.com/gbfs", server.Options{
+		Version:   "3.0",
+		Languages: []language.Tag{language.English},
+		Now:       func() time.Time { return now },
+	})
+	require.NoError(t, err)
+
+	var sr gbfs.SystemRegions
+	sr.Data.Regions = []gbfs.Region{{RegionID: "north", Name: "North"}}
+	s.Handle("system_regions", -1, converter(t, "3.0", Options{}).Provider("system_regions", server.Static(&sr)))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gbfs/en/system_regions.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"regions":[
+		{"region_id":"north","name":[{"text":"North","language":"en"}]}
+	]}}`, string(body))
+}
+
+// TestServerConvert ...
+func TestServerConvert(t *testing.T) {
+	now := time.Unix(1609866247, 0)
+	s, err := server.New("http://example.com/gbfs", server.Options{
+		Version:   "3.0",
+		Languages: []language.Tag{language.English},
+		Now:       func() time.Time { return now },
+		Convert:   converter(t, "3.0", Options{}),
+	})
+	require.NoError(t, err)
+
+	var sr gbfs.SystemRegions
+	sr.Data.Regions = []gbfs.Region{{RegionID: "north", Name: "North"}}
+	s.Handle("system_regions", -1, server.Static(&sr))
+	s.Handle("free_bike_status", -1, server.Static(&gbfs.FreeBikeStatus{}))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gbfs/en/system_regions.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"regions":[
+		{"region_id":"north","name":[{"text":"North","language":"en"}]}
+	]}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gbfs/gbfs.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"feeds":[
+		{"name":"system_regions","url":"http://example.com/gbfs/en/system_regions.json"},
+		{"name":"vehicle_status","url":"http://example.com/gbfs/en/free_bike_status.json"}
+	]}}`, rec.Body.String())
+
+	s, err = server.New("http://example.com/gbfs", server.Options{Convert: converter(t, "3.0", Options{})})
+	require.NoError(t, err)
+	s.Handle("system_regions", -1, server.Static(&sr))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gbfs/en/system_regions.json", nil))
+	assert.Contains(t, rec.Body.String(), `"version":"3.0"`)
+
+	_, err = server.New("http://example.com/gbfs", server.Options{
+		Version: "2.0",
+		Convert: converter(t, "3.0", Options{}),
+	})
+	assert.True(t, errors.Is(err, server.ErrVersionMismatch))
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// object is a decoded JSON object, numbers are json.Number
+type object = map[string]any
+
+// decode returns the JSON object doc
+func decode(doc []byte) (object, error) {
+	d := json.NewDecoder(bytes.NewReader(doc))
+	d.UseNumber()
+
+	var o object
+	if err := d.Decode(&o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// each calls fn with the object holding every key at path and the concrete
+// path of the value, e.g. data.stations[].name calls fn with each station,
+// "name" and data.stations[2].name. Keys missing from an object are skipped
+// unless all is set
+func each(v any, path string, all bool, fn func(o object, key, at string)) {
+	walk(v, strings.Split(path, "."), "", all, fn)
+}
+
+func walk(v any, segs []string, at string, all bool, fn func(o object, key, at string)) {
+	o, ok := v.(object)
+	if !ok {
+		return
+	}
+
+	seg := segs[0]
+	key := strings.TrimSuffix(seg, "[]")
+	if at != "" {
+		at += "."
+	}
+	at += key
+
+	child, ok := o[key]
+	if len(segs) == 1 {
+		if ok || all {
+			fn(o, key, at)
+		}
+		return
+	}
+	if key == seg {
+		walk(child, segs[1:], at, all, fn)
+		return
+	}
+
+	items, _ := child.([]any)
+	for i, item := range items {
+		walk(item, segs[1:], at+"["+strconv.Itoa(i)+"]", all, fn)
+	}
+}
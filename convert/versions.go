@@ -0,0 +1,166 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Versions of the specification documents can be converted between
+var Versions = []string{"1.0", "1.1", "2.0", "2.1", "2.2", "2.3", "3.0"}
+
+// version of the specification
+type version struct {
+	major, minor int
+}
+
+// parseVersion returns the supported version v
+func parseVersion(v string) (version, error) {
+	for _, supported := range Versions {
+		if v != supported {
+			continue
+		}
+		major, minor, _ := strings.Cut(v, ".")
+		var out version
+		out.major, _ = strconv.Atoi(major)
+		out.minor, _ = strconv.Atoi(minor)
+		return out, nil
+	}
+	return version{}, fmt.Errorf("%w: %q", ErrUnsupportedVersion, v)
+}
+
+// less reports whether v is older than o
+func (v version) less(o version) bool {
+	return v.major < o.major || v.major == o.major && v.minor < o.minor
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// span of versions a feed exists in, until is zero while it still exists
+type span struct {
+	since, until version
+}
+
+// has reports whether the span includes v
+func (s span) has(v version) bool {
+	return !v.less(s.since) && (s.until == version{} || v.less(s.until))
+}
+
+// feeds by their v2 name, free_bike_status is named vehicle_status from 3.0
+var feeds = map[string]span{
+	"gbfs":                 {since: version{1, 0}},
+	"gbfs_versions":        {since: version{1, 1}},
+	"system_information":   {since: version{1, 0}},
+	"vehicle_types":        {since: version{2, 1}},
+	"station_information":  {since: version{1, 0}},
+	"station_status":       {since: version{1, 0}},
+	"free_bike_status":     {since: version{1, 0}},
+	"system_hours":         {since: version{1, 0}, until: version{3, 0}},
+	"system_calendar":      {since: version{1, 0}, until: version{3, 0}},
+	"system_regions":       {since: version{1, 0}},
+	"system_pricing_plans": {since: version{1, 0}},
+	"system_alerts":        {since: version{1, 0}},
+	"geofencing_zones":     {since: version{2, 1}},
+	"manifest":             {since: version{3, 0}},
+}
+
+// v3Names of the feeds renamed in 3.0
+var v3Names = map[string]string{
+	"free_bike_status": "vehicle_status",
+}
+
+// feedName returns the name of the feed in v
+func feedName(feed string, v version) string {
+	feed = v2Name(feed)
+	if name, ok := v3Names[feed]; ok && v.major >= 3 {
+		return name
+	}
+	return feed
+}
+
+// v2Name returns the v2 name of feed
+func v2Name(feed string) string {
+	for v2, v3 := range v3Names {
+		if feed == v3 {
+			return v2
+		}
+	}
+	return feed
+}
+
+// rename of a field in 3.0, parents are paths of the object holding it
+type rename struct {
+	v2Parent, v3Parent string
+	v2, v3             string
+}
+
+// renames of the fields of each feed in 3.0, inner fields first
+var renames = map[string][]rename{
+	"free_bike_status": {
+		{"data.bikes[]", "data.vehicles[]", "bike_id", "vehicle_id"},
+		{"data", "data", "bikes", "vehicles"},
+	},
+	"station_status": {
+		{"data.stations[]", "data.stations[]", "num_bikes_available", "num_vehicles_available"},
+		{"data.stations[]", "data.stations[]", "num_bikes_disabled", "num_vehicles_disabled"},
+	},
+}
+
+// field added to a feed in a version, dropped when converting to older ones
+type field struct {
+	since  version
+	parent string
+	key    string
+}
+
+// added fields of each feed by v2 name and path, a parent with the v3 name
+// of an object is only reached in 3.0
+var added = map[string][]field{
+	"system_information": {
+		{version{1, 1}, "data", "email"},
+		{version{2, 1}, "data", "feed_contact_email"},
+	},
+	"station_information": {
+		{version{1, 1}, "data.stations[]", "rental_uris"},
+		{version{2, 1}, "data.stations[]", "is_virtual_station"},
+		{version{2, 1}, "data.stations[]", "station_area"},
+		{version{2, 1}, "data.stations[]", "is_charging_station"},
+		{version{2, 1}, "data.stations[]", "vehicle_capacity"},
+		{version{2, 1}, "data.stations[]", "vehicle_type_capacity"},
+	},
+	"station_status": {
+		{version{2, 1}, "data.stations[]", "vehicle_types_available"},
+		{version{2, 1}, "data.stations[]", "vehicle_docks_available"},
+	},
+	"free_bike_status": {
+		{version{1, 1}, "data.bikes[]", "rental_uris"},
+		{version{2, 1}, "data.bikes[]", "vehicle_type_id"},
+		{version{2, 1}, "data.bikes[]", "last_reported"},
+		{version{2, 1}, "data.bikes[]", "current_range_meters"},
+		{version{2, 1}, "data.bikes[]", "station_id"},
+		{version{2, 1}, "data.bikes[]", "pricing_plan_id"},
+	},
+}
+
+// localized fields of each feed by v2 name, translated strings in 3.0
+var localized = map[string][]string{
+	"system_information":   {"data.name", "data.short_name", "data.operator"},
+	"station_information":  {"data.stations[].name", "data.stations[].short_name"},
+	"system_regions":       {"data.regions[].name"},
+	"system_alerts":        {"data.alerts[].summary", "data.alerts[].description", "data.alerts[].url"},
+	"system_pricing_plans": {"data.plans[].name", "data.plans[].description"},
+	"vehicle_types":        {"data.vehicle_types[].name"},
+}
+
+// timestamps of every feed, RFC3339 strings in 3.0 and Unix seconds before
+var timestamps = []string{
+	"last_updated",
+	"data.stations[].last_reported",
+	"data.bikes[].last_reported",
+	"data.vehicles[].last_reported",
+	"data.alerts[].last_updated",
+	"data.alerts[].times[].start",
+	"data.alerts[].times[].end",
+}
@@ -0,0 +1,139 @@
+package gbfs
+
+import (
+	"time"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// EventType enum
+type EventType uint8
+
+const (
+	_ EventType = iota
+	BikesTaken
+	BikesReturned
+	StationOffline
+	StationBackOnline
+	DocksDisabled
+	StationAdded
+	StationRemoved
+)
+
+var eventTypeNames = map[EventType]string{
+	BikesTaken:        "BikesTaken",
+	BikesReturned:     "BikesReturned",
+	StationOffline:    "StationOffline",
+	StationBackOnline: "StationBackOnline",
+	DocksDisabled:     "DocksDisabled",
+	StationAdded:      "StationAdded",
+	StationRemoved:    "StationRemoved",
+}
+
+func (e EventType) String() string {
+	if s, ok := eventTypeNames[e]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+// Event is a change observed between two consecutive StationStatus documents
+type Event struct {
+	Type      EventType
+	StationID f.ID
+	// Time is the last_reported of the station, or the last_updated of the
+	// document when the station did not report one
+	Time time.Time
+	// Count is the number of bikes or docks involved, 0 for other events
+	Count int
+}
+
+// DiffStationStatus returns the events that happened between prev and next,
+// stations are reported in the order of next followed by removed stations in
+// the order of prev
+//
+// Bikes are counted as available plus disabled so a bike being disabled is
+// not mistaken for a rental
+func DiffStationStatus(prev, next StationStatus) []Event {
+	before := make(map[f.ID]StationState, len(prev.Data.Stations))
+	for _, s := range prev.Data.Stations {
+		before[s.StationID] = s
+	}
+
+	var events []Event
+	seen := make(map[f.ID]struct{}, len(next.Data.Stations))
+	for _, s := range next.Data.Stations {
+		seen[s.StationID] = struct{}{}
+		at := reportedAt(s, next.Output)
+
+		p, ok := before[s.StationID]
+		if !ok {
+			events = append(events, Event{Type: StationAdded, StationID: s.StationID, Time: at})
+			continue
+		}
+
+		switch wasOnline, isOnline := online(p), online(s); {
+		case wasOnline && !isOnline:
+			events = append(events, Event{Type: StationOffline, StationID: s.StationID, Time: at})
+		case !wasOnline && isOnline:
+			events = append(events, Event{Type: StationBackOnline, StationID: s.StationID, Time: at})
+		}
+
+		switch d := bikes(s) - bikes(p); {
+		case d < 0:
+			events = append(events, Event{Type: BikesTaken, StationID: s.StationID, Time: at, Count: -d})
+		case d > 0:
+			events = append(events, Event{Type: BikesReturned, StationID: s.StationID, Time: at, Count: d})
+		}
+
+		if d := int(s.NumDocsDisabled.Value()) - int(p.NumDocsDisabled.Value()); d > 0 {
+			events = append(events, Event{Type: DocksDisabled, StationID: s.StationID, Time: at, Count: d})
+		}
+	}
+
+	for _, s := range prev.Data.Stations {
+		if _, ok := seen[s.StationID]; !ok {
+			events = append(events, Event{Type: StationRemoved, StationID: s.StationID, Time: next.LastUpdated.Time})
+		}
+	}
+
+	return events
+}
+
+func online(s StationState) bool {
+	return s.IsInstalled && s.IsRenting
+}
+
+func bikes(s StationState) int {
+	return int(s.NumBikesAvailable) + int(s.NumBikesDisabled.Value())
+}
+
+func reportedAt(s StationState, o Output) time.Time {
+	if !s.LastReported.IsZero() {
+		return s.LastReported.Time
+	}
+	return o.LastUpdated.Time
+}
+
+// StationStatusDiffer diffs each StationStatus it is given against the
+// previous one, it can be fed saved snapshots or live documents
+type StationStatusDiffer struct {
+	prev *StationStatus
+}
+
+// Next returns the events since the previous StationStatus, the first
+// StationStatus is the baseline and yields no events. Documents that are not
+// newer than the previous one are ignored
+func (d *StationStatusDiffer) Next(ss StationStatus) []Event {
+	if d.prev == nil {
+		d.prev = &ss
+		return nil
+	}
+	if !ss.LastUpdated.After(d.prev.LastUpdated.Time) {
+		return nil
+	}
+
+	events := DiffStationStatus(*d.prev, ss)
+	d.prev = &ss
+	return events
+}
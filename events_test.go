@@ -0,0 +1,79 @@
+package gbfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+func statusAt(unix int64, stations ...StationState) StationStatus {
+	var ss StationStatus
+	ss.LastUpdated = f.NewTimestamp(time.Unix(unix, 0), f.UnixSeconds)
+	ss.Data.Stations = stations
+	return ss
+}
+
+func state(id f.ID, available, disabled, docksDisabled int, renting bool, reported int64) StationState {
+	s := StationState{
+		StationID:         id,
+		NumBikesAvailable: f.NonNegativeInt(available),
+		NumBikesDisabled:  f.NewNonNegativeInt(disabled),
+		NumDocsDisabled:   f.NewNonNegativeInt(docksDisabled),
+		IsInstalled:       true,
+		IsRenting:         renting,
+		IsReturning:       true,
+	}
+	if reported > 0 {
+		s.LastReported = f.NewTimestamp(time.Unix(reported, 0), f.UnixSeconds)
+	}
+	return s
+}
+
+// TestDiffStationStatus ...
+func TestDiffStationStatus(t *testing.T) {
+	prev := statusAt(100,
+		state("a", 5, 0, 0, true, 90),
+		state("b", 3, 0, 0, true, 90),
+		state("c", 1, 0, 0, false, 90),
+		state("d", 2, 0, 0, true, 90),
+		state("e", 4, 0, 0, true, 90),
+	)
+	next := statusAt(200,
+		state("a", 2, 0, 0, true, 150),  // 3 taken
+		state("b", 4, 0, 2, true, 160),  // 1 returned, 2 docks disabled
+		state("c", 1, 0, 0, true, 170),  // back online
+		state("d", 1, 1, 0, false, 180), // offline, bike disabled not taken
+		state("f", 0, 0, 0, true, 0),    // added, never reported
+	)
+
+	exp := []Event{
+		{Type: BikesTaken, StationID: "a", Time: time.Unix(150, 0).UTC(), Count: 3},
+		{Type: BikesReturned, StationID: "b", Time: time.Unix(160, 0).UTC(), Count: 1},
+		{Type: DocksDisabled, StationID: "b", Time: time.Unix(160, 0).UTC(), Count: 2},
+		{Type: StationBackOnline, StationID: "c", Time: time.Unix(170, 0).UTC()},
+		{Type: StationOffline, StationID: "d", Time: time.Unix(180, 0).UTC()},
+		{Type: StationAdded, StationID: "f", Time: time.Unix(200, 0).UTC()},
+		{Type: StationRemoved, StationID: "e", Time: time.Unix(200, 0).UTC()},
+	}
+	assert.Equal(t, exp, DiffStationStatus(prev, next))
+}
+
+// TestStationStatusDiffer ...
+func TestStationStatusDiffer(t *testing.T) {
+	var d StationStatusDiffer
+
+	// baseline
+	assert.Empty(t, d.Next(statusAt(100, state("a", 5, 0, 0, true, 90))))
+
+	// stale documents are ignored
+	assert.Empty(t, d.Next(statusAt(100, state("a", 0, 0, 0, true, 90))))
+
+	events := d.Next(statusAt(200, state("a", 4, 0, 0, true, 150)))
+	assert.Equal(t, []Event{{Type: BikesTaken, StationID: "a", Time: time.Unix(150, 0).UTC(), Count: 1}}, events)
+
+	events = d.Next(statusAt(300, state("a", 6, 0, 0, true, 250)))
+	assert.Equal(t, []Event{{Type: BikesReturned, StationID: "a", Time: time.Unix(250, 0).UTC(), Count: 2}}, events)
+}
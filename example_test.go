@@ -0,0 +1,29 @@
+package gbfs_test
+
+import (
+	"fmt"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// Stream station events as the station_status feed refreshes
+func ExampleAutoRefreshClient_Subscribe() {
+	c := gbfs.NewAutoRefreshClient("https://tor.publicbikesystem.net/ube/gbfs/v1/", nil)
+
+	s := c.Subscribe("station_status", gbfs.WithBuffer(4))
+	defer s.Unsubscribe()
+
+	c.Resume()
+	defer c.Pause()
+
+	var d gbfs.StationStatusDiffer
+	for u := range s.Updates() {
+		if u.Err != nil {
+			fmt.Println("refresh failed:", u.Err)
+			continue
+		}
+		for _, e := range d.Next(*u.Document.(*gbfs.StationStatus)) {
+			fmt.Println(e.Time, e.Type, e.StationID, e.Count)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultFetchConcurrency is the number of feeds FetchAll requests at once
+const DefaultFetchConcurrency = 4
+
+// FetchOptions configure FetchAll
+type FetchOptions struct {
+	// Concurrency limits simultaneous requests, DefaultFetchConcurrency when
+	// not positive
+	Concurrency int
+	// Feeds restricts the feeds fetched by name, every advertised feed is
+	// fetched when empty
+	Feeds []string
+}
+
+// System holds the feeds of a system fetched by FetchAll, feeds that were not
+// advertised or failed are nil
+type System struct {
+	Language           f.Language
+	GBFS               *GBFS
+	Versions           *Versions
+	SystemInformation  *SystemInformation
+	StationInformation *StationInformation
+	StationStatus      *StationStatus
+	FreeBikeStatus     *FreeBikeStatus
+	SystemHours        *SystemHours
+	SystemCalendar     *SystemCalendar
+	SystemRegions      *SystemRegions
+	SystemPricingPlans *SystemPricingPlans
+	SystemAlerts       *SystemAlerts
+	// Other holds the raw documents of feeds without a type
+	Other map[string]json.RawMessage
+	// Errors holds the error of each feed that could not be fetched
+	Errors map[string]error
+}
+
+// set stores a decoded document
+func (s *System) set(name string, doc any) {
+	switch d := doc.(type) {
+	case *GBFS:
+		s.GBFS = d
+	case *Versions:
+		s.Versions = d
+	case *SystemInformation:
+		s.SystemInformation = d
+	case *StationInformation:
+		s.StationInformation = d
+	case *StationStatus:
+		s.StationStatus = d
+	case *FreeBikeStatus:
+		s.FreeBikeStatus = d
+	case *SystemHours:
+		s.SystemHours = d
+	case *SystemCalendar:
+		s.SystemCalendar = d
+	case *SystemRegions:
+		s.SystemRegions = d
+	case *SystemPricingPlans:
+		s.SystemPricingPlans = d
+	case *SystemAlerts:
+		s.SystemAlerts = d
+	case *json.RawMessage:
+		s.Other[name] = *d
+	}
+}
+
+// Err returns an error summarizing the feeds that failed, nil when every feed
+// was fetched
+func (s System) Err() error {
+	if len(s.Errors) == 0 {
+		return nil
+	}
+	return FetchErrors(s.Errors)
+}
+
+// FetchErrors maps feed names to the error encountered fetching them
+type FetchErrors map[string]error
+
+func (e FetchErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e[name]))
+	}
+	return "fetch failed for " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the feed errors
+func (e FetchErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// FetchAll fetches the feeds advertised for a language concurrently. Failing
+// feeds are reported in System.Errors while the others are still returned, an
+// error is returned only when the language has no feeds
+func (g GBFS) FetchAll(ctx context.Context, l f.Language, opts FetchOptions) (*System, error) {
+	feeds := g.IterFeeds(l)
+	if len(feeds) == 0 {
+		return nil, ErrNoFeed
+	}
+
+	if len(opts.Feeds) > 0 {
+		want := make(map[string]bool, len(opts.Feeds))
+		for _, name := range opts.Feeds {
+			want[name] = true
+		}
+		selected := make([]Feed, 0, len(opts.Feeds))
+		for _, fd := range feeds {
+			if want[fd.Name] {
+				selected = append(selected, fd)
+			}
+		}
+		feeds = selected
+	}
+
+	n := opts.Concurrency
+	if n <= 0 {
+		n = DefaultFetchConcurrency
+	}
+
+	s := &System{
+		Language: l,
+		Other:    make(map[string]json.RawMessage),
+		Errors:   make(map[string]error),
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, n)
+	)
+	for _, fd := range feeds {
+		wg.Add(1)
+		go func(fd Feed) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				s.Errors[fd.Name] = ctx.Err()
+				mu.Unlock()
+				return
+			}
+
+			doc, ok := newDocument(fd.Name)
+			if !ok {
+				doc = new(json.RawMessage)
+			}
+			err := g.c.get(ctx, fd.url(), doc)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.Errors[fd.Name] = err
+				return
+			}
+			s.set(fd.Name, doc)
+		}(fd)
+	}
+	wg.Wait()
+
+	return s, nil
+}
+
+// fetch decodes the named feed for a language into dst
+func (g GBFS) fetch(ctx context.Context, l f.Language, name string, dst any) error {
+	u := g.Feeds(l).URL(name)
+	if u.URL == nil {
+		return ErrNoFeed
+	}
+	return g.c.get(ctx, u.String(), dst)
+}
+
+// Versions ...
+func (g GBFS) Versions(l f.Language) (v Versions, err error) {
+	err = g.fetch(context.Background(), l, "gbfs_versions", &v)
+	return
+}
+
+// SystemInformation ...
+func (g GBFS) SystemInformation(l f.Language) (s SystemInformation, err error) {
+	err = g.fetch(context.Background(), l, "system_information", &s)
+	return
+}
+
+// StationInformation ...
+func (g GBFS) StationInformation(l f.Language) (s StationInformation, err error) {
+	err = g.fetch(context.Background(), l, "station_information", &s)
+	return
+}
+
+// StationStatus ...
+func (g GBFS) StationStatus(l f.Language) (s StationStatus, err error) {
+	err = g.fetch(context.Background(), l, "station_status", &s)
+	return
+}
+
+// FreeBikeStatus ...
+func (g GBFS) FreeBikeStatus(l f.Language) (s FreeBikeStatus, err error) {
+	err = g.fetch(context.Background(), l, "free_bike_status", &s)
+	return
+}
+
+// SystemHours ...
+func (g GBFS) SystemHours(l f.Language) (s SystemHours, err error) {
+	err = g.fetch(context.Background(), l, "system_hours", &s)
+	return
+}
+
+// SystemCalendar ...
+func (g GBFS) SystemCalendar(l f.Language) (s SystemCalendar, err error) {
+	err = g.fetch(context.Background(), l, "system_calendar", &s)
+	return
+}
+
+// SystemRegions ...
+func (g GBFS) SystemRegions(l f.Language) (s SystemRegions, err error) {
+	err = g.fetch(context.Background(), l, "system_regions", &s)
+	return
+}
+
+// SystemPricingPlans ...
+func (g GBFS) SystemPricingPlans(l f.Language) (s SystemPricingPlans, err error) {
+	err = g.fetch(context.Background(), l, "system_pricing_plans", &s)
+	return
+}
+
+// SystemAlerts ...
+func (g GBFS) SystemAlerts(l f.Language) (s SystemAlerts, err error) {
+	err = g.fetch(context.Background(), l, "system_alerts", &s)
+	return
+}
@@ -0,0 +1,180 @@
+package gbfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchAll ...
+func TestFetchAll(t *testing.T) {
+	srv, hits := fixtureServer(t)
+
+	g, err := NewClient(srv.URL+"/gbfs/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+
+	t.Run("en", func(t *testing.T) {
+		atomic.StoreInt32(hits, 0)
+
+		s, err := g.FetchAll(bgCtx, mustLanguage(t, "en"), FetchOptions{Concurrency: 2})
+		require.NoError(t, err)
+		require.NoError(t, s.Err())
+
+		assert.Equal(t, int32(9), atomic.LoadInt32(hits))
+		assert.NotNil(t, s.SystemInformation)
+		assert.NotNil(t, s.StationInformation)
+		assert.NotNil(t, s.StationStatus)
+		assert.NotNil(t, s.FreeBikeStatus)
+		assert.NotNil(t, s.SystemHours)
+		assert.NotNil(t, s.SystemCalendar)
+		assert.NotNil(t, s.SystemRegions)
+		assert.NotNil(t, s.SystemPricingPlans)
+		assert.NotNil(t, s.SystemAlerts)
+		assert.Nil(t, s.Versions)
+		assert.Empty(t, s.Other)
+	})
+
+	t.Run("selected feeds", func(t *testing.T) {
+		s, err := g.FetchAll(bgCtx, mustLanguage(t, "fr"), FetchOptions{Feeds: []string{"station_status", "free_bike_status"}})
+		require.NoError(t, err)
+		require.NoError(t, s.Err())
+
+		assert.NotNil(t, s.StationStatus)
+		assert.Nil(t, s.StationInformation)
+		assert.Nil(t, s.FreeBikeStatus)
+	})
+
+	t.Run("no feeds", func(t *testing.T) {
+		_, err := g.FetchAll(bgCtx, mustLanguage(t, "de"), FetchOptions{})
+		assert.ErrorIs(t, err, ErrNoFeed)
+	})
+}
+
+// TestFetchAllDiscovery checks a discovery document advertising itself is
+// kept
+func TestFetchAllDiscovery(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{"en":{"feeds":[
+			{"name":"gbfs","url":"` + srv.URL + `/gbfs.json"}
+		]}}}`))
+	}))
+	defer srv.Close()
+
+	g, err := NewClient(srv.URL+"/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+
+	s, err := g.FetchAll(bgCtx, mustLanguage(t, "en"), FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Err())
+	require.NotNil(t, s.GBFS)
+	assert.Equal(t, g.Languages(), s.GBFS.Languages())
+	assert.Empty(t, s.Other)
+}
+
+// TestFetchAllPartial ...
+func TestFetchAllPartial(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		if name == "station_status.json" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		raw, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(string(raw), "https://example.com", srv.URL)))
+	}))
+	defer srv.Close()
+
+	g, err := NewClient(srv.URL+"/gbfs/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+
+	s, err := g.FetchAll(bgCtx, mustLanguage(t, "fr"), FetchOptions{})
+	require.NoError(t, err)
+
+	assert.NotNil(t, s.SystemInformation)
+	assert.NotNil(t, s.StationInformation)
+	assert.Nil(t, s.StationStatus)
+
+	require.Len(t, s.Errors, 1)
+	assert.Contains(t, s.Errors["station_status"].Error(), "HTTP<503>")
+
+	var fe FetchErrors
+	require.True(t, errors.As(s.Err(), &fe))
+	assert.Contains(t, fe.Error(), "station_status: HTTP<503>")
+}
+
+// TestFetchAllCancelled ...
+func TestFetchAllCancelled(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	g, err := NewClient(srv.URL+"/gbfs/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s, err := g.FetchAll(ctx, mustLanguage(t, "en"), FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, s.Errors, 9)
+	for _, err := range s.Errors {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+// TestGetDeduplicates ...
+func TestGetDeduplicates(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		_, _ = w.Write([]byte(`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, srv.Client()).(*clientImpl)
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GBFS()
+			errs <- err
+		}()
+	}
+
+	// give every caller a chance to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// completed requests are not cached
+	_, err := c.GBFS()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,89 @@
+package gbfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fetcher retrieves the raw document at a URL
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// FetcherFunc adapts a function to the Fetcher interface
+type FetcherFunc func(ctx context.Context, url string) ([]byte, error)
+
+// Fetch satisfies the Fetcher interface
+func (fn FetcherFunc) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return fn(ctx, url)
+}
+
+// NewFetcherClient returns a Client retrieving documents with fe
+func NewFetcherClient(rootURL string, fe Fetcher, opts ...ClientOption) Client {
+	o := newClientOptions(opts)
+	return &clientImpl{
+		rootURL: rootURL,
+		fetcher: fe,
+		flight:  flight{timeout: o.fetchTimeout},
+	}
+}
+
+// NewHTTPFetcher returns a Fetcher retrieving documents over HTTP with c, or
+// http.DefaultClient when c is nil
+func NewHTTPFetcher(c *http.Client) Fetcher {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return httpFetcher{c}
+}
+
+// rootFetcher returns the Fetcher for rootURL, file:// URLs are read from disk
+func rootFetcher(rootURL string, c *http.Client) Fetcher {
+	if strings.HasPrefix(rootURL, "file://") {
+		if u, err := url.Parse(rootURL); err == nil {
+			p := filepath.FromSlash(u.Path)
+			return &fsFetcher{
+				fsys:    os.DirFS(filepath.Dir(p)),
+				root:    filepath.Base(p),
+				rootURL: rootURL,
+			}
+		}
+	}
+	return httpFetcher{c}
+}
+
+// httpFetcher retrieves documents over HTTP
+type httpFetcher struct {
+	*http.Client
+}
+
+// Fetch satisfies the Fetcher interface
+func (h httpFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// check status code
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP<%d>: %s", res.StatusCode, string(content))
+	}
+	return content, nil
+}
@@ -0,0 +1,25 @@
+package fields
+
+import "encoding/json"
+
+// Boolean is a boolean of GBFS v1.x documents, which may be encoded as 0 or 1.
+// It is marshaled as true or false
+type Boolean bool
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "0":
+		*b = false
+		return nil
+	case "1":
+		*b = true
+		return nil
+	}
+	return json.Unmarshal(data, (*bool)(b))
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (b Boolean) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bool(b))
+}
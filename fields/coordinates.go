@@ -1,6 +1,9 @@
 package fields
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+)
 
 // Latitude ...
 type Latitude float64
@@ -23,6 +26,14 @@ func (l *Latitude) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (l Latitude) MarshalJSON() ([]byte, error) {
+	if l < -90.0 || l > 90.0 {
+		return nil, ErrLatitude
+	}
+	return json.Marshal(float64(l))
+}
+
 // Longitude ...
 type Longitude float64
 
@@ -43,3 +54,11 @@ func (l *Longitude) UnmarshalJSON(data []byte) error {
 	*l = Longitude(f)
 	return nil
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (l Longitude) MarshalJSON() ([]byte, error) {
+	if l < -180.0 || l > 180.0 {
+		return nil, ErrLongitude
+	}
+	return json.Marshal(float64(l))
+}
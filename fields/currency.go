@@ -9,11 +9,28 @@ type Currency struct {
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (c *Currency) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	s, err := unmarshalToString(data)
 	if err != nil {
 		return err
 	}
 
-	(*(*c).Unit), err = currency.ParseISO(s)
-	return err
+	u, err := currency.ParseISO(s)
+	if err != nil {
+		return err
+	}
+
+	(*c).Unit = &u
+	return nil
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (c Currency) MarshalJSON() ([]byte, error) {
+	if c.Unit == nil {
+		return null, nil
+	}
+	return marshalString(c.Unit.String())
 }
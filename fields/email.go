@@ -9,6 +9,10 @@ type Email struct {
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (e *Email) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	s, err := unmarshalToString(data)
 	if err != nil {
 		return err
@@ -18,3 +22,16 @@ func (e *Email) UnmarshalJSON(data []byte) error {
 	(*e).Address, err = mail.ParseAddress(s)
 	return err
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (e Email) MarshalJSON() ([]byte, error) {
+	if e.Address == nil {
+		return null, nil
+	}
+
+	// bare address unless a display name was provided
+	if e.Name == "" {
+		return marshalString(e.Address.Address)
+	}
+	return marshalString(e.Address.String())
+}
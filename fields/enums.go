@@ -18,6 +18,14 @@ const (
 // ErrUnknownAlertType ...
 var ErrUnknownAlertType = errors.New("unknown alert type")
 
+func (a AlertType) valid() bool {
+	switch a {
+	case atSystemClosure, atStationClosure, atStationMove, atOther:
+		return true
+	}
+	return false
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (a *AlertType) UnmarshalJSON(data []byte) error {
 	s, err := unmarshalToString(data)
@@ -26,16 +34,22 @@ func (a *AlertType) UnmarshalJSON(data []byte) error {
 	}
 
 	at := AlertType(s)
-	switch at {
-	default:
+	if !at.valid() {
 		return ErrUnknownAlertType
-	case atSystemClosure, atStationClosure, atStationMove, atOther:
 	}
 
 	*a = at
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (a AlertType) MarshalJSON() ([]byte, error) {
+	if !a.valid() {
+		return nil, ErrUnknownAlertType
+	}
+	return marshalString(string(a))
+}
+
 func (a AlertType) String() string {
 	return string(a)
 }
@@ -72,6 +86,11 @@ func (d DayOfWeek) Weekday() time.Weekday {
 // ErrUnknownDayOfWeek ...
 var ErrUnknownDayOfWeek = errors.New("unknown day")
 
+func (d DayOfWeek) valid() bool {
+	_, ok := dowWeekday[d]
+	return ok
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (d *DayOfWeek) UnmarshalJSON(data []byte) error {
 	s, err := unmarshalToString(data)
@@ -80,16 +99,22 @@ func (d *DayOfWeek) UnmarshalJSON(data []byte) error {
 	}
 
 	dow := DayOfWeek(s)
-	switch dow {
-	default:
+	if !dow.valid() {
 		return ErrUnknownDayOfWeek
-	case mon, tue, wed, thu, fri, sat, sun:
 	}
 
 	*d = dow
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (d DayOfWeek) MarshalJSON() ([]byte, error) {
+	if !d.valid() {
+		return nil, ErrUnknownDayOfWeek
+	}
+	return marshalString(string(d))
+}
+
 // Mobile tags
 type Mobile string
 
@@ -102,6 +127,14 @@ const (
 // ErrUnknownMobile ...
 var ErrUnknownMobile = errors.New("unknown mobile")
 
+func (m Mobile) valid() bool {
+	switch m {
+	case Android, IOS:
+		return true
+	}
+	return false
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (m *Mobile) UnmarshalJSON(data []byte) error {
 	s, err := unmarshalToString(data)
@@ -110,16 +143,22 @@ func (m *Mobile) UnmarshalJSON(data []byte) error {
 	}
 
 	mob := Mobile(s)
-	switch mob {
-	default:
+	if !mob.valid() {
 		return ErrUnknownMobile
-	case Android, IOS:
 	}
 
 	*m = mob
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (m Mobile) MarshalJSON() ([]byte, error) {
+	if !m.valid() {
+		return nil, ErrUnknownMobile
+	}
+	return marshalString(string(m))
+}
+
 // RentalMethod ...
 type RentalMethod string
 
@@ -138,6 +177,14 @@ const (
 // ErrUnknownRentalMethod ...
 var ErrUnknownRentalMethod = errors.New("unknown rental method")
 
+func (r RentalMethod) valid() bool {
+	switch r {
+	case RMKey, RMCreditcard, RMPaypass, RMApplepay, RMAndroidpay, RMTransitcard, RMAccountnumber, RMPhone:
+		return true
+	}
+	return false
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (r *RentalMethod) UnmarshalJSON(data []byte) error {
 	s, err := unmarshalToString(data)
@@ -146,16 +193,22 @@ func (r *RentalMethod) UnmarshalJSON(data []byte) error {
 	}
 
 	rm := RentalMethod(s)
-	switch rm {
-	default:
+	if !rm.valid() {
 		return ErrUnknownRentalMethod
-	case RMKey, RMCreditcard, RMPaypass, RMApplepay, RMAndroidpay, RMTransitcard, RMAccountnumber, RMPhone:
 	}
 
 	*r = rm
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (r RentalMethod) MarshalJSON() ([]byte, error) {
+	if !r.valid() {
+		return nil, ErrUnknownRentalMethod
+	}
+	return marshalString(string(r))
+}
+
 // UserType ...
 type UserType string
 
@@ -168,6 +221,14 @@ const (
 // ErrUnknownUserType ...
 var ErrUnknownUserType = errors.New("unknown user type")
 
+func (u UserType) valid() bool {
+	switch u {
+	case member, nonmember:
+		return true
+	}
+	return false
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (u *UserType) UnmarshalJSON(data []byte) error {
 	s, err := unmarshalToString(data)
@@ -176,12 +237,18 @@ func (u *UserType) UnmarshalJSON(data []byte) error {
 	}
 
 	ut := UserType(s)
-	switch ut {
-	default:
+	if !ut.valid() {
 		return ErrUnknownUserType
-	case member, nonmember:
 	}
 
 	*u = ut
 	return nil
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (u UserType) MarshalJSON() ([]byte, error) {
+	if !u.valid() {
+		return nil, ErrUnknownUserType
+	}
+	return marshalString(string(u))
+}
@@ -26,3 +26,11 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 	(*id) = ID(raw)
 	return nil
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (id ID) MarshalJSON() ([]byte, error) {
+	if containsSpaces(string(id)) {
+		return nil, ErrIDSpaces
+	}
+	return marshalString(string(id))
+}
@@ -9,6 +9,10 @@ type Language struct {
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (l *Language) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	s, err := unmarshalToString(data)
 	if err != nil {
 		return err
@@ -17,3 +21,8 @@ func (l *Language) UnmarshalJSON(data []byte) error {
 	(*l).Tag, err = language.Parse(s)
 	return err
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (l Language) MarshalJSON() ([]byte, error) {
+	return marshalString(l.Tag.String())
+}
@@ -1,6 +1,7 @@
 package fields
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 )
@@ -11,6 +12,20 @@ type NonNegativeInt int
 // ErrNonNegativeInt error
 var ErrNonNegativeInt = errors.New("NonNegativeInt must have value >= 0")
 
+// NewNonNegativeInt returns a pointer to n, to set optional fields
+func NewNonNegativeInt(n int) *NonNegativeInt {
+	i := NonNegativeInt(n)
+	return &i
+}
+
+// Value returns n, 0 when n is nil
+func (n *NonNegativeInt) Value() NonNegativeInt {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (n *NonNegativeInt) UnmarshalJSON(data []byte) error {
 	i, err := unmarshalToInt(data)
@@ -26,6 +41,14 @@ func (n *NonNegativeInt) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (n NonNegativeInt) MarshalJSON() ([]byte, error) {
+	if n < 0 {
+		return nil, ErrNonNegativeInt
+	}
+	return json.Marshal(int(n))
+}
+
 func (n NonNegativeInt) String() string {
 	return strconv.Itoa(int(n))
 }
@@ -51,6 +74,14 @@ func (n *NonNegativeFloat) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (n NonNegativeFloat) MarshalJSON() ([]byte, error) {
+	if n < 0.0 {
+		return nil, ErrNonNegativeFloat
+	}
+	return json.Marshal(float64(n))
+}
+
 func (n NonNegativeFloat) String() string {
 	// special case 0
 	if n == 0 {
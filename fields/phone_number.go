@@ -2,3 +2,8 @@ package fields
 
 // PhoneNumber ...
 type PhoneNumber string
+
+// MarshalJSON satisfies json.Marshaler interface
+func (p PhoneNumber) MarshalJSON() ([]byte, error) {
+	return marshalString(string(p))
+}
@@ -0,0 +1,180 @@
+package fields
+
+import "math"
+
+// EarthRadius is the mean radius of the earth in meters
+const EarthRadius = 6371008.8
+
+// Point is a WGS 84 coordinate
+type Point struct {
+	Lat Latitude  `json:"lat"`
+	Lon Longitude `json:"lon"`
+}
+
+// NewPoint returns the Point at lat, lon
+func NewPoint(lat, lon float64) (Point, error) {
+	switch {
+	case lat < -90.0 || lat > 90.0:
+		return Point{}, ErrLatitude
+	case lon < -180.0 || lon > 180.0:
+		return Point{}, ErrLongitude
+	}
+	return Point{Latitude(lat), Longitude(lon)}, nil
+}
+
+// Valid reports whether the latitude and longitude are in range
+func (p Point) Valid() bool {
+	_, err := NewPoint(float64(p.Lat), float64(p.Lon))
+	return err == nil
+}
+
+// radians returns the latitude and longitude in radians
+func (p Point) radians() (lat, lon float64) {
+	return toRadians(float64(p.Lat)), toRadians(float64(p.Lon))
+}
+
+// Distance returns the great-circle distance in meters between p and q using
+// the haversine formula
+func (p Point) Distance(q Point) float64 {
+	lat1, lon1 := p.radians()
+	lat2, lon2 := q.radians()
+
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((lon2 - lon1) / 2)
+	a := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Bearing returns the initial bearing in degrees [0, 360) to follow from p to
+// reach q along a great circle
+func (p Point) Bearing(q Point) float64 {
+	lat1, lon1 := p.radians()
+	lat2, lon2 := q.radians()
+
+	y := math.Sin(lon2-lon1) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(lon2-lon1)
+
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns the Point reached by travelling distance meters from p
+// along a great circle with the initial bearing in degrees
+func (p Point) Destination(bearing, distance float64) Point {
+	lat1, lon1 := p.radians()
+	brng := toRadians(bearing)
+	d := distance / EarthRadius
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+
+	return Point{Latitude(toDegrees(lat2)), Longitude(normalizeLongitude(toDegrees(lon2)))}
+}
+
+// BBox is a bounding box defined by its south-west (Min) and north-east (Max)
+// corners, boxes crossing the antimeridian are not supported
+type BBox struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// NewBBox returns the smallest BBox containing all points, with no points the
+// BBox is empty
+func NewBBox(points ...Point) BBox {
+	b := BBox{Min: Point{90, 180}, Max: Point{-90, -180}}
+	for _, p := range points {
+		b = b.Extend(p)
+	}
+	return b
+}
+
+// BBoxAround returns the BBox containing every Point within radius meters of
+// p
+func BBoxAround(p Point, radius float64) BBox {
+	return NewBBox(p).Buffer(radius)
+}
+
+// IsEmpty reports whether the BBox contains no points
+func (b BBox) IsEmpty() bool {
+	return b.Min.Lat > b.Max.Lat || b.Min.Lon > b.Max.Lon
+}
+
+// Contains reports whether p lies within the BBox, edges included
+func (b BBox) Contains(p Point) bool {
+	return p.Lat >= b.Min.Lat && p.Lat <= b.Max.Lat && p.Lon >= b.Min.Lon && p.Lon <= b.Max.Lon
+}
+
+// Extend returns the smallest BBox containing both b and p
+func (b BBox) Extend(p Point) BBox {
+	if p.Lat < b.Min.Lat {
+		b.Min.Lat = p.Lat
+	}
+	if p.Lat > b.Max.Lat {
+		b.Max.Lat = p.Lat
+	}
+	if p.Lon < b.Min.Lon {
+		b.Min.Lon = p.Lon
+	}
+	if p.Lon > b.Max.Lon {
+		b.Max.Lon = p.Lon
+	}
+	return b
+}
+
+// Union returns the smallest BBox containing both b and o
+func (b BBox) Union(o BBox) BBox {
+	if o.IsEmpty() {
+		return b
+	}
+	return b.Extend(o.Min).Extend(o.Max)
+}
+
+// Buffer returns the BBox grown by meters in every direction, latitudes are
+// clamped at the poles and longitudes at the antimeridian
+func (b BBox) Buffer(meters float64) BBox {
+	if b.IsEmpty() {
+		return b
+	}
+
+	dLat := toDegrees(meters / EarthRadius)
+
+	// widen longitudes by the amount needed at the latitude closest to a pole
+	maxLat := math.Max(math.Abs(float64(b.Min.Lat)), math.Abs(float64(b.Max.Lat))) + dLat
+	dLon := 180.0
+	if maxLat < 90 {
+		dLon = math.Min(180, dLat/math.Cos(toRadians(maxLat)))
+	}
+
+	return BBox{
+		Min: Point{
+			Latitude(math.Max(-90, float64(b.Min.Lat)-dLat)),
+			Longitude(math.Max(-180, float64(b.Min.Lon)-dLon)),
+		},
+		Max: Point{
+			Latitude(math.Min(90, float64(b.Max.Lat)+dLat)),
+			Longitude(math.Min(180, float64(b.Max.Lon)+dLon)),
+		},
+	}
+}
+
+// Center returns the midpoint of the BBox corners
+func (b BBox) Center() Point {
+	return Point{(b.Min.Lat + b.Max.Lat) / 2, (b.Min.Lon + b.Max.Lon) / 2}
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// normalizeLongitude wraps a longitude into [-180, 180]
+func normalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
@@ -0,0 +1,84 @@
+package fields
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPointDistance ...
+func TestPointDistance(t *testing.T) {
+	toronto := Point{43.6532, -79.3832}
+	montreal := Point{45.5017, -73.5673}
+
+	// ~504km
+	assert.InDelta(t, 504_000, toronto.Distance(montreal), 2_000)
+	assert.InDelta(t, toronto.Distance(montreal), montreal.Distance(toronto), 1e-6)
+	assert.Zero(t, toronto.Distance(toronto))
+
+	// one degree of latitude on the meridian
+	assert.InDelta(t, 111_195, Point{0, 0}.Distance(Point{1, 0}), 1)
+}
+
+// TestPointBearing ...
+func TestPointBearing(t *testing.T) {
+	origin := Point{0, 0}
+
+	for _, tc := range []struct {
+		name string
+		to   Point
+		exp  float64
+	}{
+		{"north", Point{1, 0}, 0},
+		{"east", Point{0, 1}, 90},
+		{"south", Point{-1, 0}, 180},
+		{"west", Point{0, -1}, 270},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.exp, origin.Bearing(tc.to), 1e-9)
+		})
+	}
+}
+
+// TestPointDestination ...
+func TestPointDestination(t *testing.T) {
+	start := Point{43.6532, -79.3832}
+
+	for _, bearing := range []float64{0, 45, 90, 180, 270, 359} {
+		dst := start.Destination(bearing, 1_000)
+		assert.InDelta(t, 1_000, start.Distance(dst), 1e-6)
+		// angular difference, 359.9999 is as close to 0 as 0.0001
+		assert.InDelta(t, 0, math.Mod(start.Bearing(dst)-bearing+540, 360)-180, 1e-3)
+	}
+
+	// wraps the antimeridian
+	dst := Point{0, 179.9}.Destination(90, 50_000)
+	assert.True(t, dst.Valid())
+	assert.Less(t, float64(dst.Lon), -179.0)
+}
+
+// TestBBox ...
+func TestBBox(t *testing.T) {
+	empty := NewBBox()
+	assert.True(t, empty.IsEmpty())
+	assert.False(t, empty.Contains(Point{0, 0}))
+
+	b := NewBBox(Point{43.6, -79.4}, Point{43.7, -79.3})
+	require.False(t, b.IsEmpty())
+	assert.True(t, b.Contains(Point{43.65, -79.35}))
+	assert.True(t, b.Contains(b.Min))
+	assert.False(t, b.Contains(Point{43.8, -79.35}))
+
+	b = b.Extend(Point{43.8, -79.35})
+	assert.True(t, b.Contains(Point{43.8, -79.35}))
+
+	// every point within the radius is contained by the box around the center
+	center := Point{43.6532, -79.3832}
+	around := BBoxAround(center, 1_000)
+	for _, bearing := range []float64{0, 45, 90, 135, 180, 225, 270, 315} {
+		assert.True(t, around.Contains(center.Destination(bearing, 999)), "bearing %v", bearing)
+	}
+	assert.False(t, around.Contains(center.Destination(0, 1_100)))
+}
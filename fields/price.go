@@ -1,14 +1,22 @@
 package fields
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 )
 
 // Price is represented as float64 or string
+//
+// Number is a named field rather than embedded: with GOEXPERIMENT=jsonv2,
+// json.Number has MarshalJSONTo and UnmarshalJSONFrom methods which, once
+// promoted, take precedence over the methods of Price
 type Price struct {
-	json.Number
+	Number json.Number
+	// quoted records whether the price was encoded as a string
+	quoted bool
 }
 
 // ErrInvalidPriceType returned when the .(type) of unmarshaled data is not a string
@@ -17,13 +25,38 @@ var ErrInvalidPriceType = errors.New("price must be string or float")
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (p *Price) UnmarshalJSON(data []byte) error {
-	err := json.Unmarshal(data, &(p.Number))
-	if err != nil {
-		return fmt.Errorf("%w %w", err, ErrInvalidPriceType)
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return &priceError{err}
+	}
+	switch v := v.(type) {
+	case json.Number:
+		p.Number, p.quoted = v, false
+	case string:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return &priceError{err}
+		}
+		p.Number, p.quoted = json.Number(v), true
+	default:
+		return &priceError{fmt.Errorf("unexpected %T", v)}
 	}
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (p Price) MarshalJSON() ([]byte, error) {
+	if p.Number == "" {
+		return null, nil
+	}
+	if p.quoted {
+		return marshalString(p.Number.String())
+	}
+	return []byte(p.Number), nil
+}
+
 // Float64 returns the float64 value of this Price
 func (p Price) Float64() float64 {
 	f64, _ := p.Number.Float64()
@@ -34,3 +67,22 @@ func (p Price) Float64() float64 {
 func (p Price) String() string {
 	return p.Number.String()
 }
+
+// priceError wraps the error decoding a Price, it matches ErrInvalidPriceType
+type priceError struct {
+	err error
+}
+
+func (e *priceError) Error() string {
+	return ErrInvalidPriceType.Error() + ": " + e.err.Error()
+}
+
+// Is reports whether target is ErrInvalidPriceType
+func (e *priceError) Is(target error) bool {
+	return target == ErrInvalidPriceType
+}
+
+// Unwrap returns the decoding error
+func (e *priceError) Unwrap() error {
+	return e.err
+}
@@ -2,6 +2,7 @@ package fields
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"strconv"
 	"testing"
@@ -47,7 +48,10 @@ func TestPriceUnmarshalJSON(t *testing.T) {
 			var p Price
 
 			if tc.err != nil {
-				assert.ErrorIs(t, json.Unmarshal(tc.raw, &p), tc.err)
+				err := json.Unmarshal(tc.raw, &p)
+				assert.ErrorIs(t, err, tc.err)
+				// the cause remains wrapped
+				assert.NotNil(t, errors.Unwrap(err))
 			} else {
 				assert.NoError(t, json.Unmarshal(tc.raw, &p))
 				assert.Equal(t, tc.expS, p.String())
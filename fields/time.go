@@ -1,8 +1,11 @@
 package fields
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +19,10 @@ const dateFmt = "2006-01-02"
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (d *Date) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	s, err := unmarshalToString(data)
 	if err != nil {
 		return err
@@ -25,6 +32,14 @@ func (d *Date) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return null, nil
+	}
+	return marshalString(d.Format(dateFmt))
+}
+
 func (d *Date) String() string {
 	return d.Format(dateFmt)
 }
@@ -50,6 +65,14 @@ func (d *Day) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (d Day) MarshalJSON() ([]byte, error) {
+	if d < 1 || d > 31 {
+		return nil, ErrInvalidDay
+	}
+	return json.Marshal(int(d))
+}
+
 // Month ...
 type Month NonNegativeInt
 
@@ -71,6 +94,14 @@ func (m *Month) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (m Month) MarshalJSON() ([]byte, error) {
+	if m < 1 || m > 12 {
+		return nil, ErrInvalidMonth
+	}
+	return json.Marshal(int(m))
+}
+
 // Year ...
 type Year NonNegativeInt
 
@@ -92,51 +123,110 @@ func (y *Year) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// Time ...
+// MarshalJSON satisfies json.Marshaler interface
+func (y Year) MarshalJSON() ([]byte, error) {
+	if y < 0 || y > 9999 {
+		return nil, ErrInvalidYear
+	}
+	return json.Marshal(int(y))
+}
+
+// Time is a time of day expressed as an offset from local midnight, hours may
+// exceed 23 for service that extends past midnight (e.g. "25:30:00")
 type Time struct {
-	time.Time
+	d time.Duration
 }
 
-// time format HH:mm:ss
-const timeFmt = "15:04:05"
+// ErrInvalidTime ...
+var ErrInvalidTime = errors.New("Time must be formatted as HH:MM:SS with HH in range [0, 47]")
+
+// maxTimeHours is the exclusive upper bound of the hours component of a Time
+const maxTimeHours = 48
+
+// NewTime returns the Time for a number of hours, minutes and seconds after
+// midnight
+func NewTime(hours, minutes, seconds int) (Time, error) {
+	if hours < 0 || hours >= maxTimeHours || minutes < 0 || minutes > 59 || seconds < 0 || seconds > 59 {
+		return Time{}, ErrInvalidTime
+	}
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	return Time{d}, nil
+}
+
+// ParseTime parses a HH:MM:SS formatted time of day
+func ParseTime(s string) (Time, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Time{}, ErrInvalidTime
+	}
+
+	var hms [3]int
+	for i, p := range parts {
+		if len(p) != 2 {
+			return Time{}, ErrInvalidTime
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Time{}, ErrInvalidTime
+		}
+		hms[i] = n
+	}
+
+	return NewTime(hms[0], hms[1], hms[2])
+}
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (t *Time) UnmarshalJSON(data []byte) error {
-	s, err := unmarshalToString(data)
-	if err != nil {
-		return err
+	if isNull(data) {
+		return nil
 	}
 
-	(*t).Time, err = time.Parse(timeFmt, s)
+	s, err := unmarshalToString(data)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	(*t), err = ParseTime(s)
+	return err
 }
 
-func (t *Time) String() string {
-	return t.Format(timeFmt)
+// MarshalJSON satisfies json.Marshaler interface
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.d < 0 || t.d >= maxTimeHours*time.Hour {
+		return nil, ErrInvalidTime
+	}
+	return marshalString(t.String())
 }
 
-// Timestamp ...
-type Timestamp struct {
-	time.Time
+// Duration returns the time elapsed since local midnight
+func (t Time) Duration() time.Duration {
+	return t.d
 }
 
-// UnmarshalJSON satisifies json.Unmarshaler interface
-func (t *Timestamp) UnmarshalJSON(data []byte) error {
-	i, err := unmarshalToInt(data)
-	if err != nil {
-		return err
-	}
+// Clock returns the hours, minutes and seconds of this Time, hours may exceed
+// 23
+func (t Time) Clock() (hours, minutes, seconds int) {
+	s := int(t.d / time.Second)
+	return s / 3600, s % 3600 / 60, s % 60
+}
 
-	(*t).Time = time.Unix(int64(i), 0).UTC()
-	return nil
+// On materializes this Time in loc on the calendar day of date (as observed in
+// the location of date), a Time past midnight falls on the following day
+//
+// The wall clock is used, so on days with a daylight saving transition the
+// result is not necessarily midnight plus Duration()
+func (t Time) On(date time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = date.Location()
+	}
+	y, m, d := date.Date()
+	h, min, s := t.Clock()
+	return time.Date(y, m, d, h, min, s, 0, loc)
 }
 
-func (t *Timestamp) String() string {
-	return strconv.FormatInt(t.Unix(), 10)
+func (t Time) String() string {
+	h, m, s := t.Clock()
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
 }
 
 // Timezone ...
@@ -146,6 +236,10 @@ type Timezone struct {
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (t *Timezone) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	zone, err := unmarshalToString(data)
 	if err != nil {
 		return err
@@ -154,3 +248,11 @@ func (t *Timezone) UnmarshalJSON(data []byte) error {
 	(*t).Location, err = time.LoadLocation(zone)
 	return err
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (t Timezone) MarshalJSON() ([]byte, error) {
+	if t.Location == nil {
+		return null, nil
+	}
+	return marshalString(t.Location.String())
+}
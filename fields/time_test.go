@@ -0,0 +1,71 @@
+package fields
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeUnmarshalJSON ...
+func TestTimeUnmarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+		exp  time.Duration
+		err  error
+	}{
+		{"midnight", []byte(`"00:00:00"`), 0, nil},
+		{"afternoon", []byte(`"13:45:30"`), 13*time.Hour + 45*time.Minute + 30*time.Second, nil},
+		{"end_of_day", []byte(`"23:59:59"`), 24*time.Hour - time.Second, nil},
+		{"after_midnight", []byte(`"25:30:00"`), 25*time.Hour + 30*time.Minute, nil},
+		{"error_hours", []byte(`"48:00:00"`), 0, ErrInvalidTime},
+		{"error_minutes", []byte(`"12:60:00"`), 0, ErrInvalidTime},
+		{"error_format", []byte(`"12:00"`), 0, ErrInvalidTime},
+		{"error_digits", []byte(`"1:00:00"`), 0, ErrInvalidTime},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var tm Time
+
+			if tc.err != nil {
+				assert.ErrorIs(t, json.Unmarshal(tc.raw, &tm), tc.err)
+				return
+			}
+
+			require.NoError(t, json.Unmarshal(tc.raw, &tm))
+			assert.Equal(t, tc.exp, tm.Duration())
+
+			// round trip
+			b, err := json.Marshal(tm)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tc.raw), string(b))
+		})
+	}
+}
+
+// TestTimeOn ...
+func TestTimeOn(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	date := time.Date(2021, time.March, 13, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name string
+		tm   string
+		exp  time.Time
+	}{
+		{"same_day", "18:00:00", time.Date(2021, time.March, 13, 18, 0, 0, 0, toronto)},
+		{"after_midnight", "25:30:00", time.Date(2021, time.March, 14, 1, 30, 0, 0, toronto)},
+		// 2021-03-14 02:00 EST clocks move forward to 03:00 EDT
+		{"dst_wall_clock", "27:00:00", time.Date(2021, time.March, 14, 3, 0, 0, 0, toronto)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tm, err := ParseTime(tc.tm)
+			require.NoError(t, err)
+			assert.True(t, tc.exp.Equal(tm.On(date, toronto)), "%s != %s", tc.exp, tm.On(date, toronto))
+		})
+	}
+}
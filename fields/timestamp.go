@@ -0,0 +1,182 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Timestamp is a point in time, GBFS v1.x and v2.x use Unix seconds while v3.0
+// uses RFC3339 strings. Some operators emit millisecond epochs or quote their
+// epochs, all of these forms are accepted and the original form is remembered
+// so re-encoding is faithful
+type Timestamp struct {
+	time.Time
+	enc TimestampEncoding
+}
+
+// TimestampEncoding enum describes the JSON form of a Timestamp
+type TimestampEncoding uint8
+
+// TimestampEncoding constants
+const (
+	UnixSeconds TimestampEncoding = iota
+	UnixMilliseconds
+	UnixSecondsString
+	UnixMillisecondsString
+	RFC3339
+)
+
+// DefaultMillisecondThreshold is the smallest absolute epoch considered to be
+// in milliseconds, as seconds it is in the year 5138 and as milliseconds it is
+// in 1973
+const DefaultMillisecondThreshold int64 = 1e11
+
+// millisecondEpoch holds the func(int64) bool set with SetMillisecondEpoch
+var millisecondEpoch atomic.Value
+
+// defaultMillisecondEpoch compares epochs to DefaultMillisecondThreshold
+func defaultMillisecondEpoch(epoch int64) bool {
+	return epoch >= DefaultMillisecondThreshold || epoch <= -DefaultMillisecondThreshold
+}
+
+// IsMillisecondEpoch reports whether a numeric epoch is expressed in
+// milliseconds, using the heuristic set with SetMillisecondEpoch
+func IsMillisecondEpoch(epoch int64) bool {
+	if fn, ok := millisecondEpoch.Load().(func(int64) bool); ok {
+		return fn(epoch)
+	}
+	return defaultMillisecondEpoch(epoch)
+}
+
+// SetMillisecondEpoch replaces the heuristic of IsMillisecondEpoch for feeds
+// the default does not fit, nil restores the default. It is safe to call
+// while timestamps are decoded, prefer ParseTimestamp to decode a single feed
+// differently
+func SetMillisecondEpoch(fn func(epoch int64) bool) {
+	if fn == nil {
+		fn = defaultMillisecondEpoch
+	}
+	millisecondEpoch.Store(fn)
+}
+
+// ErrInvalidTimestamp ...
+var ErrInvalidTimestamp = errors.New("Timestamp must be a Unix epoch or an RFC3339 string")
+
+// NewTimestamp returns a Timestamp for t which will be encoded as enc
+func NewTimestamp(t time.Time, enc TimestampEncoding) Timestamp {
+	if enc != RFC3339 {
+		t = t.UTC()
+	}
+	return Timestamp{Time: t, enc: enc}
+}
+
+// ParseTimestamp decodes the JSON value data as UnmarshalJSON does, numeric
+// epochs are in milliseconds when isMillisecond reports so
+func ParseTimestamp(data []byte, isMillisecond func(epoch int64) bool) (Timestamp, error) {
+	var t Timestamp
+	err := t.decode(data, isMillisecond)
+	return t, err
+}
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	return t.decode(data, IsMillisecondEpoch)
+}
+
+func (t *Timestamp) decode(data []byte, isMillisecond func(int64) bool) error {
+	if isNull(data) {
+		return nil
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		s, err := unmarshalToString(data)
+		if err != nil {
+			return err
+		}
+		return t.parseString(s, isMillisecond)
+	}
+
+	epoch, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	t.setEpoch(epoch, isMillisecond(epoch), UnixSeconds, UnixMilliseconds)
+	return nil
+}
+
+// parseString handles quoted epochs and RFC3339 values
+func (t *Timestamp) parseString(s string, isMillisecond func(int64) bool) error {
+	if epoch, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t.setEpoch(epoch, isMillisecond(epoch), UnixSecondsString, UnixMillisecondsString)
+		return nil
+	}
+
+	tm, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	(*t).Time, (*t).enc = tm, RFC3339
+	return nil
+}
+
+// setEpoch sets the time from a numeric epoch using the seconds or
+// milliseconds encoding
+func (t *Timestamp) setEpoch(epoch int64, milli bool, s, ms TimestampEncoding) {
+	if milli {
+		(*t).Time, (*t).enc = time.UnixMilli(epoch).UTC(), ms
+		return
+	}
+	(*t).Time, (*t).enc = time.Unix(epoch, 0).UTC(), s
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return null, nil
+	}
+
+	switch t.enc {
+	case UnixMilliseconds:
+		return json.Marshal(t.UnixMilli())
+	case UnixSecondsString:
+		return marshalString(strconv.FormatInt(t.Unix(), 10))
+	case UnixMillisecondsString:
+		return marshalString(strconv.FormatInt(t.UnixMilli(), 10))
+	case RFC3339:
+		return marshalString(t.Format(time.RFC3339Nano))
+	}
+	return json.Marshal(t.Unix())
+}
+
+// Encoding returns the JSON form this Timestamp was decoded from and will be
+// encoded as
+func (t Timestamp) Encoding() TimestampEncoding {
+	return t.enc
+}
+
+// WithEncoding returns a copy of this Timestamp that will be encoded as enc
+func (t Timestamp) WithEncoding(enc TimestampEncoding) Timestamp {
+	return NewTimestamp(t.Time, enc)
+}
+
+// InZone returns the time in the system timezone, a zero Timezone yields UTC
+func (t Timestamp) InZone(tz Timezone) time.Time {
+	if tz.Location == nil {
+		return t.UTC()
+	}
+	return t.In(tz.Location)
+}
+
+// FormatInZone returns the time formatted with layout in the system timezone
+func (t Timestamp) FormatInZone(tz Timezone, layout string) string {
+	return t.InZone(tz).Format(layout)
+}
+
+func (t *Timestamp) String() string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -0,0 +1,94 @@
+package fields
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimestampUnmarshalJSON ...
+func TestTimestampUnmarshalJSON(t *testing.T) {
+	exp := time.Date(2021, time.January, 5, 17, 4, 7, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+		enc  TimestampEncoding
+		err  error
+	}{
+		{"seconds", []byte(`1609866247`), UnixSeconds, nil},
+		{"milliseconds", []byte(`1609866247000`), UnixMilliseconds, nil},
+		{"seconds_string", []byte(`"1609866247"`), UnixSecondsString, nil},
+		{"milliseconds_string", []byte(`"1609866247000"`), UnixMillisecondsString, nil},
+		{"rfc3339_utc", []byte(`"2021-01-05T17:04:07Z"`), RFC3339, nil},
+		{"rfc3339_offset", []byte(`"2021-01-05T12:04:07-05:00"`), RFC3339, nil},
+		{"error_float", []byte(`1609866247.5`), 0, ErrInvalidTimestamp},
+		{"error_string", []byte(`"yesterday"`), 0, ErrInvalidTimestamp},
+		{"error_bool", []byte(`true`), 0, ErrInvalidTimestamp},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var ts Timestamp
+
+			if tc.err != nil {
+				assert.ErrorIs(t, json.Unmarshal(tc.raw, &ts), tc.err)
+				return
+			}
+
+			require.NoError(t, json.Unmarshal(tc.raw, &ts))
+			assert.True(t, exp.Equal(ts.Time), "%s != %s", exp, ts.Time)
+			assert.Equal(t, tc.enc, ts.Encoding())
+
+			// faithful re-encoding
+			b, err := json.Marshal(ts)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tc.raw), string(b))
+		})
+	}
+}
+
+// TestTimestampHeuristic ...
+func TestTimestampHeuristic(t *testing.T) {
+	defer SetMillisecondEpoch(nil)
+
+	// per call
+	ts, err := ParseTimestamp([]byte(`"1000"`), func(int64) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, UnixMillisecondsString, ts.Encoding())
+	assert.Equal(t, int64(1), ts.Unix())
+	assert.False(t, IsMillisecondEpoch(1000))
+
+	// everything is in milliseconds, concurrently with decoding
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ts Timestamp
+			assert.NoError(t, json.Unmarshal([]byte(`1609866247`), &ts))
+		}()
+	}
+	SetMillisecondEpoch(func(int64) bool { return true })
+	wg.Wait()
+
+	require.NoError(t, json.Unmarshal([]byte(`1000`), &ts))
+	assert.Equal(t, UnixMilliseconds, ts.Encoding())
+	assert.Equal(t, int64(1), ts.Unix())
+
+	SetMillisecondEpoch(nil)
+	assert.False(t, IsMillisecondEpoch(1000))
+	assert.True(t, IsMillisecondEpoch(1609866247000))
+}
+
+// TestTimestampInZone ...
+func TestTimestampInZone(t *testing.T) {
+	var tz Timezone
+	require.NoError(t, json.Unmarshal([]byte(`"America/Toronto"`), &tz))
+
+	ts := NewTimestamp(time.Unix(1609866247, 0), UnixSeconds)
+	assert.Equal(t, "2021-01-05T12:04:07-05:00", ts.FormatInZone(tz, time.RFC3339))
+	assert.Equal(t, "2021-01-05T17:04:07Z", ts.FormatInZone(Timezone{}, time.RFC3339))
+}
@@ -5,10 +5,37 @@ import "net/url"
 // URI ...
 type URI struct {
 	*url.URL
+	// raw is the value as it was decoded, url.URL.String() does not preserve
+	// app deep links like "com.example.android://"
+	raw string
 }
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (u *URI) UnmarshalJSON(data []byte) (err error) {
-	(*u).URL, err = unmarshalToURL(data)
+	if isNull(data) {
+		return nil
+	}
+
+	(*u).raw, err = unmarshalToString(data)
+	if err != nil {
+		return err
+	}
+
+	(*u).URL, err = url.Parse(u.raw)
 	return err
 }
+
+// MarshalJSON satisfies json.Marshaler interface
+func (u URI) MarshalJSON() ([]byte, error) {
+	if u.URL == nil {
+		return null, nil
+	}
+
+	// prefer the decoded value as long as the URL has not been modified since
+	if u.raw != "" {
+		if p, err := url.Parse(u.raw); err == nil && *p == *u.URL {
+			return marshalString(u.raw)
+		}
+	}
+	return marshalString(u.URL.String())
+}
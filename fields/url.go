@@ -15,19 +15,43 @@ var ErrURLScheme = errors.New("URL Scheme must be 'http' OR 'https'")
 
 // UnmarshalJSON satisifies json.Unmarshaler interface
 func (u *URL) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
 	url, err := unmarshalToURL(data)
 	if err != nil {
 		return err
 	}
 
 	// check the URL scheme
-	switch url.Scheme {
+	if err = checkURLScheme(url); err != nil {
+		return err
+	}
+
+	(*u).URL = url
+	return nil
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (u URL) MarshalJSON() ([]byte, error) {
+	if u.URL == nil {
+		return null, nil
+	}
+
+	if err := checkURLScheme(u.URL); err != nil {
+		return nil, err
+	}
+	return marshalString(u.URL.String())
+}
+
+// checkURLScheme validates the scheme of a URL field
+func checkURLScheme(u *url.URL) error {
+	switch u.Scheme {
 	default:
 		return ErrURLScheme
 	case "http", "https":
 		// valid
 	}
-
-	(*u).URL = url
 	return nil
 }
@@ -1,11 +1,20 @@
 package fields
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/url"
 	"unicode"
 )
 
+// null is the JSON literal null
+var null = []byte("null")
+
+// isNull determines if the raw JSON value is the literal null
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), null)
+}
+
 // containsRuneFunc uses a provided predicate func to determine if some rune(s)
 // are contained in the string
 func containsRuneFunc(s string, f func(rune) bool) bool {
@@ -74,3 +83,8 @@ func unmarshalToNonNegativeInt(data []byte) (n NonNegativeInt, err error) {
 	err = json.Unmarshal(data, &n)
 	return
 }
+
+// marshalString is a convenience method to marshal a string into a JSON string
+func marshalString(s string) ([]byte, error) {
+	return json.Marshal(s)
+}
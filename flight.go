@@ -0,0 +1,90 @@
+package gbfs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultFetchTimeout bounds a request shared by concurrent callers, which is
+// not cancelled by the caller that started it, see WithFetchTimeout
+const DefaultFetchTimeout = 30 * time.Second
+
+// call is an in-flight or completed flight.do call
+type call struct {
+	done    chan struct{} // closed once body and err are set
+	body    []byte
+	err     error
+	waiters int                // callers waiting for the call
+	cancel  context.CancelFunc // cancels the call once nobody waits
+}
+
+// flight deduplicates concurrent requests for the same key, callers arriving
+// while a request is in-flight wait for and share its result
+type flight struct {
+	timeout time.Duration // of a call, DefaultFetchTimeout when zero
+	mu      sync.Mutex
+	calls   map[string]*call
+}
+
+// do calls fn unless a call for key is in-flight, fn runs with a context
+// detached from the callers and is cancelled once every caller's ctx is done
+func (f *flight) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]*call)
+	}
+	c, ok := f.calls[key]
+	if !ok {
+		timeout := f.timeout
+		if timeout <= 0 {
+			timeout = DefaultFetchTimeout
+		}
+		fctx, cancel := context.WithTimeout(detached{ctx}, timeout)
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		f.calls[key] = c
+
+		go func() {
+			defer cancel()
+			c.body, c.err = fn(fctx)
+
+			f.mu.Lock()
+			f.forget(key, c)
+			f.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	f.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.body, c.err
+	case <-ctx.Done():
+		f.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			f.forget(key, c)
+		}
+		f.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes c so later callers start a new call, f.mu must be held
+func (f *flight) forget(key string, c *call) {
+	if f.calls[key] == c {
+		delete(f.calls, key)
+	}
+}
+
+// detached is a context with the values of its parent which is never done
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+func (d detached) Value(key any) any         { return d.parent.Value(key) }
@@ -0,0 +1,105 @@
+package gbfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFlightDetached checks the shared call outlives the caller that started
+// it while others wait
+func TestFlightDetached(t *testing.T) {
+	var fl flight
+	release := make(chan struct{})
+	started := make(chan context.Context, 1)
+	fn := func(ctx context.Context) ([]byte, error) {
+		started <- ctx
+		select {
+		case <-release:
+			return []byte("body"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := fl.do(first, "key", fn)
+		firstErr <- err
+	}()
+	fctx := <-started
+
+	second := make(chan []byte, 1)
+	go func() {
+		body, err := fl.do(context.Background(), "key", fn)
+		assert.NoError(t, err)
+		second <- body
+	}()
+
+	// wait for the second caller to join
+	require.Eventually(t, func() bool {
+		fl.mu.Lock()
+		defer fl.mu.Unlock()
+		return fl.calls["key"] != nil && fl.calls["key"].waiters == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.NoError(t, fctx.Err())
+
+	close(release)
+	assert.Equal(t, []byte("body"), <-second)
+}
+
+// TestFlightAbandoned checks the shared call is cancelled once every caller
+// gave up
+func TestFlightAbandoned(t *testing.T) {
+	var fl flight
+	started := make(chan context.Context, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := fl.do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+			started <- ctx
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		done <- err
+	}()
+	fctx := <-started
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	select {
+	case <-fctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("abandoned call not cancelled")
+	}
+
+	// later callers start a new call
+	body, err := fl.do(context.Background(), "key", func(context.Context) ([]byte, error) {
+		return []byte("new"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), body)
+}
+
+// TestFetchTimeout checks the shared call is bounded by WithFetchTimeout
+func TestFetchTimeout(t *testing.T) {
+	hang := FetcherFunc(func(ctx context.Context, _ string) ([]byte, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 40*time.Millisecond)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	c := NewFetcherClient("https://example.com/gbfs.json", hang, WithFetchTimeout(50*time.Millisecond))
+	_, err := c.GBFS()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
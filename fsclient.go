@@ -0,0 +1,77 @@
+package gbfs
+
+import (
+	"context"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// NewFSClient returns a Client reading feeds from fsys, root is the path of
+// gbfs.json. Feed URLs are resolved to the longest trailing part of their path
+// found next to root, e.g. https://example.com/gbfs/en/station_status.json is
+// read from en/station_status.json or station_status.json
+func NewFSClient(fsys fs.FS, root string, opts ...ClientOption) Client {
+	return NewFetcherClient(root, NewFSFetcher(fsys, root), opts...)
+}
+
+// NewFSFetcher returns a Fetcher reading documents from fsys, root is the path
+// of gbfs.json and is also the URL it is fetched with, see NewFSClient
+func NewFSFetcher(fsys fs.FS, root string) Fetcher {
+	return &fsFetcher{fsys: fsys, root: root, rootURL: root}
+}
+
+// fsFetcher reads documents from a fs.FS
+type fsFetcher struct {
+	fsys    fs.FS
+	root    string // path of the discovery document in fsys
+	rootURL string // URL the discovery document is requested with
+}
+
+// Fetch satisfies the Fetcher interface
+func (f *fsFetcher) Fetch(ctx context.Context, u string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if u == f.rootURL {
+		return fs.ReadFile(f.fsys, f.root)
+	}
+
+	name, err := f.resolve(u)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(f.fsys, name)
+}
+
+// resolve returns the path in fsys of the document at u
+func (f *fsFetcher) resolve(u string) (string, error) {
+	p := u
+	if parsed, err := url.Parse(u); err == nil && parsed.Scheme != "" {
+		p = parsed.Path
+	}
+
+	dir := path.Dir(f.root)
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	for i := range segs {
+		for _, name := range candidates(path.Join(append([]string{dir}, segs[i:]...)...)) {
+			if !fs.ValidPath(name) {
+				continue
+			}
+			if st, err := fs.Stat(f.fsys, name); err == nil && !st.IsDir() {
+				return name, nil
+			}
+		}
+	}
+	return "", &fs.PathError{Op: "open", Path: u, Err: fs.ErrNotExist}
+}
+
+// candidates returns name and, when it has no extension, name with a .json
+// extension
+func candidates(name string) []string {
+	if path.Ext(name) == "" {
+		return []string{name, name + ".json"}
+	}
+	return []string{name}
+}
@@ -0,0 +1,86 @@
+package gbfs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFSClient ...
+func TestFSClient(t *testing.T) {
+	abs, err := filepath.Abs(filepath.Join("testdata", "gbfs.json"))
+	require.NoError(t, err)
+
+	for name, c := range map[string]Client{
+		"fs":   NewFSClient(os.DirFS("testdata"), "gbfs.json"),
+		"file": NewClient("file://"+filepath.ToSlash(abs), nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			g, err := c.GBFS()
+			require.NoError(t, err)
+
+			si, err := g.SystemInformation(mustLanguage(t, "en"))
+			require.NoError(t, err)
+			assert.NotEmpty(t, si.Data.SystemID)
+
+			s, err := g.FetchAll(bgCtx, mustLanguage(t, "en"), FetchOptions{})
+			require.NoError(t, err)
+			require.NoError(t, s.Err())
+			assert.NotNil(t, s.StationStatus)
+
+			// feeds not advertised are reported as such
+			_, err = g.Versions(mustLanguage(t, "en"))
+			assert.ErrorIs(t, err, ErrNoFeed)
+		})
+	}
+}
+
+// TestFSClientResolve ...
+func TestFSClientResolve(t *testing.T) {
+	doc := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	info := `{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{"system_id":"%s"}}`
+
+	fsys := fstest.MapFS{
+		"archive/gbfs.json": doc(`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{
+			"en":{"feeds":[
+				{"name":"system_information","url":"https://example.com/gbfs/en/system_information.json"},
+				{"name":"station_status","url":"https://example.com/gbfs/en/station_status"},
+				{"name":"free_bike_status","url":"https://example.com/gbfs/en/free_bike_status.json"}
+			]},
+			"fr":{"feeds":[
+				{"name":"system_information","url":"https://example.com/gbfs/fr/system_information.json"}
+			]}
+		}}`),
+		"archive/en/system_information.json": doc(fmt.Sprintf(info, "en")),
+		"archive/fr/system_information.json": doc(fmt.Sprintf(info, "fr")),
+		"archive/station_status.json":        doc(`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{"stations":[]}}`),
+		// outside of the archive directory
+		"free_bike_status.json": doc(`{}`),
+	}
+
+	g, err := NewFSClient(fsys, "archive/gbfs.json").GBFS()
+	require.NoError(t, err)
+
+	// the longest matching path wins
+	for _, lang := range []string{"en", "fr"} {
+		si, err := g.SystemInformation(mustLanguage(t, lang))
+		require.NoError(t, err)
+		assert.Equal(t, lang, string(si.Data.SystemID))
+	}
+
+	// extensionless URLs fall back to .json
+	_, err = g.StationStatus(mustLanguage(t, "en"))
+	assert.NoError(t, err)
+
+	_, err = g.FreeBikeStatus(mustLanguage(t, "en"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = NewFSClient(fsys, "missing/gbfs.json").GBFS()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
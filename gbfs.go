@@ -1,15 +1,12 @@
 package gbfs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"sync"
 	"time"
-
-	f "github.com/marz619/gbfs-go/fields"
 )
 
 const DefaultRefreshDuration = 10 * time.Second
@@ -26,8 +23,18 @@ type AutoRefreshClient interface {
 	Client
 	Pause() RefreshState
 	Resume() RefreshState
-	//
-	next(any, int)
+	// Stop stops refreshing and waits for in-flight fetches and SubscribeFunc
+	// callbacks to complete or ctx to be done, in which case fetches are
+	// cancelled. Updates are no longer waited for by subscribers using the
+	// Block policy. All subscriptions are closed and the client can no longer
+	// be resumed
+	Stop(ctx context.Context) error
+	// Close is Stop without a deadline
+	Close() error
+	// Subscribe returns a Subscription receiving every refresh of the feed
+	Subscribe(feed string, opts ...SubscribeOption) *Subscription
+	// SubscribeFunc calls fn with every refresh of the feed
+	SubscribeFunc(feed string, fn func(Update), opts ...SubscribeOption) *Subscription
 }
 
 // RefreshState enum
@@ -39,43 +46,77 @@ const (
 	Refreshing
 	Errored
 	Noop
+	Closed
 )
 
+type clientOptions struct {
+	fetchTimeout time.Duration
+}
+
+// ClientOption configures a Client
+type ClientOption func(*clientOptions)
+
+// WithFetchTimeout bounds the requests of the Client, shared by concurrent
+// callers, to d instead of DefaultFetchTimeout
+func WithFetchTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.fetchTimeout = d
+	}
+}
+
+// newClientOptions returns opts applied
+func newClientOptions(opts []ClientOption) clientOptions {
+	o := clientOptions{fetchTimeout: DefaultFetchTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // New Client with default http.Client
-func New(rootURL string) Client {
-	return NewClient(rootURL, nil)
+func New(rootURL string, opts ...ClientOption) Client {
+	return NewClient(rootURL, nil, opts...)
 }
 
 // NewClient returns a Client
-func NewClient(rootURL string, c *http.Client) Client {
+func NewClient(rootURL string, c *http.Client, opts ...ClientOption) Client {
 	if c == nil {
 		c = http.DefaultClient
 	}
+	o := newClientOptions(opts)
 	return &clientImpl{
 		Client:      c,
 		rootURL:     rootURL,
 		autoRefresh: false,
+		fetcher:     rootFetcher(rootURL, c),
+		flight:      flight{timeout: o.fetchTimeout},
 	}
 }
 
 // NewAutoRefreshClient returns a Client that will self update based on the
 // returned TTL
-func NewAutoRefreshClient(rootURL string, c *http.Client) AutoRefreshClient {
+func NewAutoRefreshClient(rootURL string, c *http.Client, opts ...ClientOption) AutoRefreshClient {
 	if c == nil {
 		c = http.DefaultClient
 	}
+	o := newClientOptions(opts)
+	ctx, cancel := context.WithCancel(context.Background())
 	return &clientImpl{
 		Client:      c,
 		rootURL:     rootURL,
 		autoRefresh: true,
+		fetcher:     rootFetcher(rootURL, c),
+		flight:      flight{timeout: o.fetchTimeout},
+		ctx:         ctx,
+		cancel:      cancel,
 		state:       Paused,
-		ts:          make(map[any]time.Ticker),
+		feeds:       make(map[feedKey]*refresher),
 	}
 }
 
 // client interface
 type client interface {
-	get(string, any) error
+	get(context.Context, string, any) error
 	set(client)
 }
 
@@ -90,34 +131,39 @@ type clientImpl struct {
 	*http.Client
 	rootURL     string
 	autoRefresh bool
+	fetcher     Fetcher // retrieves documents
+	flight      flight  // deduplicates in-flight requests
+	// cancelled once stopped
+	ctx    context.Context
+	cancel context.CancelFunc
 	// protected by mutex
 	m     sync.Mutex
-	state RefreshState        // global state
-	ts    map[any]time.Ticker // per object ticker
+	state RefreshState           // global state
+	feeds map[feedKey]*refresher // per feed refresher
+	wg    sync.WaitGroup         // running refreshers and deliveries
+	fns   sync.WaitGroup         // running SubscribeFunc callbacks
+	// protected by discovery mutex
+	dm    sync.Mutex
+	disc  *GBFS     // discovery document used to resolve feeds
+	discT time.Time // expiry of disc
 }
 
 func (c *clientImpl) set(_ client) {} // noop to satisfy interface
 
-// get retrieves
-func (c *clientImpl) get(url string, dst any) error {
+// get retrieves the document at url and decodes it into dst, identical
+// concurrent requests share a single round trip
+func (c *clientImpl) get(ctx context.Context, url string, dst any) error {
 	defer setC(c, dst)
 
-	res, err := c.Get(url)
+	body, err := c.flight.do(ctx, url, func(ctx context.Context) ([]byte, error) {
+		return c.fetcher.Fetch(ctx, url)
+	})
 	if err != nil {
 		return err
 	}
 
-	// check status code
-	if res.StatusCode != http.StatusOK {
-		content, err := io.ReadAll(res.Body)
-		if err != nil {
-			return err
-		}
-		return fmt.Errorf("HTTP<%d>: %s", res.StatusCode, string(content))
-	}
-
 	// try to unmarshal as json
-	err = json.NewDecoder(res.Body).Decode(dst)
+	err = json.Unmarshal(body, dst)
 	if err != nil {
 		return err
 	}
@@ -132,62 +178,116 @@ var ErrNoRootURL = errors.New("no rootURL url")
 
 // GBFS satisfies the Client interface
 func (c *clientImpl) GBFS() (g GBFS, err error) {
+	return c.gbfs(context.Background())
+}
+
+func (c *clientImpl) gbfs(ctx context.Context) (g GBFS, err error) {
 	if c.rootURL == "" {
 		err = ErrNoRootURL
 		return
 	}
 	// get the Discover doc
-	err = c.get(c.rootURL, &g)
+	err = c.get(ctx, c.rootURL, &g)
 	return
 }
 
 func (c *clientImpl) Pause() RefreshState {
-	if c.state == Paused {
-		return Noop
-	}
 	c.m.Lock()
 	defer c.m.Unlock()
 	return c.pause()
 }
 
 func (c *clientImpl) pause() RefreshState {
-	if c.state == Paused {
+	if c.state == Paused || c.state == Closed {
 		return Noop
 	}
-	// stop all the tickers
-	for _, t := range c.ts {
-		t.Stop()
+	// cancel all the refreshers
+	for _, r := range c.feeds {
+		r.stop(true)
 	}
 	c.state = Paused
 	return c.state
 }
 
 func (c *clientImpl) Resume() RefreshState {
-	if c.state == Refreshing {
-		return Noop
-	}
 	c.m.Lock()
 	defer c.m.Unlock()
 	return c.resume()
 }
 
 func (c *clientImpl) resume() RefreshState {
-	if c.state == Refreshing {
+	if c.state == Refreshing || c.state == Closed {
 		return Noop
 	}
-	for _, t := range c.ts {
-		t.Reset(DefaultRefreshDuration)
+	for _, r := range c.feeds {
+		c.start(r)
 	}
 	c.state = Refreshing
 	return c.state
 }
 
-func (c *clientImpl) next(i any, ttl int) {
-	// based on TTL set the next timer tick for this object
+// Stop satisfies the AutoRefreshClient interface
+func (c *clientImpl) Stop(ctx context.Context) error {
+	c.m.Lock()
+	if c.state == Closed {
+		c.m.Unlock()
+		return nil
+	}
+	c.state = Closed
+
+	// let in-flight fetches complete but schedule no more
+	var subs []*Subscription
+	for _, r := range c.feeds {
+		r.stop(false)
+		for s := range r.subs {
+			subs = append(subs, s)
+		}
+	}
+	c.m.Unlock()
+	defer c.cancel()
+
+	// blocked deliveries give up so in-flight fetches can complete
+	for _, s := range subs {
+		s.stop()
+	}
+
+	err := wait(ctx, &c.wg)
+	if err != nil {
+		c.m.Lock()
+		for _, r := range c.feeds {
+			r.stop(true)
+		}
+		c.m.Unlock()
+		c.cancel()
+	}
+
+	// close every subscription, ending the SubscribeFunc callbacks
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+	if err != nil {
+		return err
+	}
+	return wait(ctx, &c.fns)
+}
+
+// wait waits for wg until ctx is done
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// SystemInformation ...
-func (g GBFS) SystemInformation(l f.Language) (s SystemInformation, err error) {
-	err = g.c.get(g.Feeds(l).URL("system_information").String(), &s)
-	return
+// Close satisfies the AutoRefreshClient interface
+func (c *clientImpl) Close() error {
+	return c.Stop(context.Background())
 }
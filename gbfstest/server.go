@@ -0,0 +1,267 @@
+// Package gbfstest provides an in-process GBFS server for end to end tests,
+// with knobs to inject latency, errors, malformed and stale documents
+package gbfstest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// Discovery is the feed name of gbfs.json
+const Discovery = "gbfs"
+
+// AllFeeds applies a Fault to every feed
+const AllFeeds = "*"
+
+// Version of the documents served
+const Version = gbfs.DefaultVersion
+
+// Fault is injected in the responses of a feed
+type Fault struct {
+	// Latency delays the response
+	Latency time.Duration
+	// StatusCode replaces the response with an error, e.g. 429 or 500
+	StatusCode int
+	// RetryAfter sets the Retry-After header of error responses
+	RetryAfter time.Duration
+	// Malformed truncates the document
+	Malformed bool
+	// Stale moves last_updated back
+	Stale time.Duration
+	// Times limits the number of responses the Fault is injected in, until it
+	// is cleared when zero
+	Times int
+}
+
+// Server serves a System, feeds are at /gbfs/<language>/<feed>.json
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	system System
+	faults map[string]*Fault
+	hits   map[string]int
+}
+
+// NewServer starts a Server serving sys, it should be closed once done
+func NewServer(sys System) *Server {
+	s := &Server{
+		system: sys,
+		faults: make(map[string]*Fault),
+		hits:   make(map[string]int),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// RootURL returns the URL of gbfs.json
+func (s *Server) RootURL() string {
+	return s.URL + "/gbfs/gbfs.json"
+}
+
+// NewClient returns a gbfs.Client of the Server
+func (s *Server) NewClient() gbfs.Client {
+	return gbfs.NewClient(s.RootURL(), s.Client())
+}
+
+// NewAutoRefreshClient returns a gbfs.AutoRefreshClient of the Server
+func (s *Server) NewAutoRefreshClient() gbfs.AutoRefreshClient {
+	return gbfs.NewAutoRefreshClient(s.RootURL(), s.Client())
+}
+
+// Update modifies the System served
+func (s *Server) Update(fn func(*System)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.system)
+}
+
+// SetFault injects fault in the responses of feed, Discovery or AllFeeds
+func (s *Server) SetFault(feed string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[feed] = &fault
+}
+
+// ClearFaults removes every Fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string]*Fault)
+}
+
+// Hits returns the number of requests for feed
+func (s *Server) Hits(feed string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[feed]
+}
+
+// ServeHTTP satisfies the http.Handler interface
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lang, feed, ok := s.route(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.hits[feed]++
+	fault := s.fault(feed)
+	body, err := s.render(lang, feed, fault)
+	s.mu.Unlock()
+
+	if fault.Latency > 0 {
+		t := time.NewTimer(fault.Latency)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		}
+	}
+
+	if fault.StatusCode != 0 && fault.StatusCode != http.StatusOK {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+		}
+		http.Error(w, http.StatusText(fault.StatusCode), fault.StatusCode)
+		return
+	}
+	if err == errNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if fault.Malformed {
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// route returns the language and feed of path
+func (s *Server) route(path string) (string, string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "gbfs" && parts[1] == "gbfs.json":
+		return "", Discovery, true
+	case len(parts) == 3 && parts[0] == "gbfs" && strings.HasSuffix(parts[2], ".json"):
+		return parts[1], strings.TrimSuffix(parts[2], ".json"), true
+	}
+	return "", "", false
+}
+
+// fault returns the Fault injected in this response of feed, s.mu must be held
+func (s *Server) fault(feed string) Fault {
+	name := feed
+	ft, ok := s.faults[name]
+	if !ok {
+		name = AllFeeds
+		if ft, ok = s.faults[name]; !ok {
+			return Fault{}
+		}
+	}
+
+	// only the Fault applied counts a response
+	out := *ft
+	if ft.Times > 0 {
+		if ft.Times--; ft.Times == 0 {
+			delete(s.faults, name)
+		}
+	}
+	return out
+}
+
+var errNotFound = errors.New("not found")
+
+// render encodes the document of feed, s.mu must be held
+func (s *Server) render(lang, feed string, fault Fault) ([]byte, error) {
+	sys := &s.system
+
+	lastUpdated := sys.LastUpdated
+	if lastUpdated.IsZero() {
+		lastUpdated = time.Now()
+	}
+	out := gbfs.Output{
+		LastUpdated: f.NewTimestamp(lastUpdated.Add(-fault.Stale), f.UnixSeconds),
+		TTL:         f.NonNegativeInt(sys.ttl(feed)),
+		Version:     Version,
+	}
+
+	if feed == Discovery {
+		return json.Marshal(s.discovery(out))
+	}
+
+	known := false
+	for _, l := range sys.languages() {
+		known = known || l == lang
+	}
+	doc, ok := sys.feeds()[feed]
+	if !known || !ok {
+		return nil, errNotFound
+	}
+
+	*doc.out = out
+	if feed == "station_status" {
+		// stations without a last_reported report as of last_updated
+		status := sys.Status
+		status.Data.Stations = make([]gbfs.StationState, len(sys.Status.Data.Stations))
+		for i, st := range sys.Status.Data.Stations {
+			if st.LastReported.IsZero() {
+				st.LastReported = out.LastUpdated
+			}
+			status.Data.Stations[i] = st
+		}
+		return json.Marshal(&status)
+	}
+	if feed == "system_information" {
+		defer func(l f.Language) { sys.Information.Data.Language = l }(sys.Information.Data.Language)
+		if err := json.Unmarshal([]byte(strconv.Quote(lang)), &sys.Information.Data.Language); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(doc.v)
+}
+
+// discovery returns gbfs.json as a generic document
+func (s *Server) discovery(out gbfs.Output) any {
+	names := make([]string, 0, 5)
+	for name := range s.system.feeds() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type feed struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	data := make(map[string]map[string][]feed)
+	for _, l := range s.system.languages() {
+		feeds := make([]feed, 0, len(names))
+		for _, name := range names {
+			feeds = append(feeds, feed{name, fmt.Sprintf("%s/gbfs/%s/%s.json", s.URL, l, name)})
+		}
+		data[l] = map[string][]feed{"feeds": feeds}
+	}
+
+	return struct {
+		gbfs.Output
+		Data any `json:"data"`
+	}{out, data}
+}
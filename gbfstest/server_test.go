@@ -0,0 +1,182 @@
+package gbfstest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+func fetchAll(t *testing.T, s *Server) *gbfs.System {
+	t.Helper()
+
+	g, err := s.NewClient().GBFS()
+	require.NoError(t, err)
+	l, err := g.MatchLanguage()
+	require.NoError(t, err)
+	sys, err := g.FetchAll(context.Background(), l, gbfs.FetchOptions{})
+	require.NoError(t, err)
+	return sys
+}
+
+// TestServer ...
+func TestServer(t *testing.T) {
+	s := NewServer(NewSystem(5, 3))
+	defer s.Close()
+
+	sys := fetchAll(t, s)
+	require.NoError(t, sys.Err())
+
+	assert.Equal(t, f.ID("gbfstest"), sys.SystemInformation.Data.SystemID)
+	assert.EqualValues(t, DefaultTTL, sys.StationStatus.TTL)
+	assert.Len(t, sys.FreeBikeStatus.Data.Bikes, 3)
+	assert.NotNil(t, sys.SystemAlerts)
+
+	// stations are consistent with their status
+	require.Len(t, sys.StationInformation.Data.Stations, 5)
+	require.Len(t, sys.StationStatus.Data.Stations, 5)
+	for i, st := range sys.StationInformation.Data.Stations {
+		state := sys.StationStatus.Data.Stations[i]
+		assert.Equal(t, st.StationID, state.StationID)
+		assert.Equal(t, st.Capacity.Value(), state.NumBikesAvailable+state.NumDocksAvailable)
+		assert.InDelta(t, 500, Center.Distance(st.Point()), 1)
+		assert.Equal(t, sys.StationStatus.LastUpdated.Unix(), state.LastReported.Unix())
+	}
+
+	assert.Equal(t, 1, s.Hits(Discovery))
+	assert.Equal(t, 1, s.Hits("station_status"))
+}
+
+// TestServerUpdate ...
+func TestServerUpdate(t *testing.T) {
+	s := NewServer(NewSystem(1, 0))
+	defer s.Close()
+
+	at := time.Unix(1609866247, 0)
+	s.Update(func(sys *System) {
+		sys.Languages = []string{"en", "fr"}
+		sys.LastUpdated = at
+		sys.TTLs = map[string]int{"station_status": 5}
+		sys.Status.Data.Stations[0].IsRenting = false
+	})
+
+	g, err := s.NewClient().GBFS()
+	require.NoError(t, err)
+	assert.Equal(t, at.Unix(), g.LastUpdated.Unix())
+
+	l, err := g.MatchLanguage(language.CanadianFrench)
+	require.NoError(t, err)
+	si, err := g.SystemInformation(l)
+	require.NoError(t, err)
+	assert.Equal(t, "fr", si.Data.Language.String())
+
+	ss, err := g.StationStatus(l)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, ss.TTL)
+	assert.False(t, ss.Data.Stations[0].IsRenting)
+	assert.Equal(t, at.Unix(), ss.Data.Stations[0].LastReported.Unix())
+
+	// a station reporting on its own keeps its last_reported
+	reported := at.Add(-time.Minute)
+	s.Update(func(sys *System) {
+		sys.Status.Data.Stations[0].LastReported = f.NewTimestamp(reported, f.UnixSeconds)
+	})
+	ss, err = g.StationStatus(l)
+	require.NoError(t, err)
+	assert.Equal(t, reported.Unix(), ss.Data.Stations[0].LastReported.Unix())
+
+	res, err := s.Client().Get(s.URL + "/gbfs/de/station_status.json")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+// TestServerFaults ...
+func TestServerFaults(t *testing.T) {
+	s := NewServer(NewSystem(2, 2))
+	defer s.Close()
+
+	t.Run("status", func(t *testing.T) {
+		s.SetFault("station_status", Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: 30 * time.Second, Times: 1})
+
+		res, err := s.Client().Get(s.URL + "/gbfs/en/station_status.json")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "30", res.Header.Get("Retry-After"))
+
+		// the fault was injected once
+		sys := fetchAll(t, s)
+		assert.NoError(t, sys.Err())
+	})
+
+	t.Run("all feeds", func(t *testing.T) {
+		defer s.ClearFaults()
+		s.SetFault(AllFeeds, Fault{StatusCode: http.StatusInternalServerError})
+
+		_, err := s.NewClient().GBFS()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "HTTP<500>")
+	})
+
+	t.Run("override", func(t *testing.T) {
+		defer s.ClearFaults()
+		s.SetFault(AllFeeds, Fault{StatusCode: http.StatusInternalServerError, Times: 1})
+		s.SetFault("station_status", Fault{StatusCode: http.StatusTooManyRequests, Times: 1})
+
+		get := func(feed string) int {
+			res, err := s.Client().Get(s.URL + "/gbfs/en/" + feed + ".json")
+			require.NoError(t, err)
+			res.Body.Close()
+			return res.StatusCode
+		}
+
+		// the feed fault overrides and is the only one counted
+		assert.Equal(t, http.StatusTooManyRequests, get("station_status"))
+		assert.Equal(t, http.StatusInternalServerError, get("station_status"))
+		assert.Equal(t, http.StatusOK, get("station_status"))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		defer s.ClearFaults()
+		s.SetFault("free_bike_status", Fault{Malformed: true})
+
+		sys := fetchAll(t, s)
+		assert.Len(t, sys.Errors, 1)
+		assert.Contains(t, sys.Errors, "free_bike_status")
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		defer s.ClearFaults()
+		s.SetFault("station_status", Fault{Stale: time.Hour})
+
+		sys := fetchAll(t, s)
+		require.NoError(t, sys.Err())
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), sys.StationStatus.LastUpdated.Time, time.Minute)
+		assert.WithinDuration(t, time.Now(), sys.StationInformation.LastUpdated.Time, time.Minute)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		defer s.ClearFaults()
+		s.SetFault("station_status", Fault{Latency: time.Second})
+
+		g, err := s.NewClient().GBFS()
+		require.NoError(t, err)
+		l, err := g.MatchLanguage()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		sys, err := g.FetchAll(ctx, l, gbfs.FetchOptions{Feeds: []string{"station_status", "system_information"}})
+		require.NoError(t, err)
+		assert.ErrorIs(t, sys.Errors["station_status"], context.DeadlineExceeded)
+		assert.NotNil(t, sys.SystemInformation)
+	})
+}
@@ -0,0 +1,126 @@
+package gbfstest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultTTL of the documents served
+const DefaultTTL = 60
+
+// Center of the systems generated by NewSystem
+var Center = f.Point{Lat: 43.6532, Lon: -79.3832}
+
+// System is the content served by a Server, the Output of each document is
+// set when served
+type System struct {
+	// Languages of gbfs.json, en when empty
+	Languages []string
+	// TTL of every document, DefaultTTL when zero
+	TTL int
+	// TTLs overrides the TTL of a feed
+	TTLs map[string]int
+	// LastUpdated of every document, the time of the request when zero. It is
+	// also the last_reported of the stations of Status without one
+	LastUpdated time.Time
+
+	Information gbfs.SystemInformation
+	Stations    gbfs.StationInformation
+	Status      gbfs.StationStatus
+	Bikes       gbfs.FreeBikeStatus
+	Alerts      gbfs.SystemAlerts
+}
+
+// NewSystem returns a System with n stations around Center, each with a
+// matching status, and bikes free floating bikes
+func NewSystem(stations, bikes int) System {
+	var s System
+	s.Information.Data.SystemID = "gbfstest"
+	s.Information.Data.Language = f.Language{Tag: language.English}
+	s.Information.Data.Name = "GBFS Test"
+	s.Information.Data.Timezone = f.Timezone{Location: time.UTC}
+
+	s.Stations.Data.Stations = make([]gbfs.Station, 0, stations)
+	s.Status.Data.Stations = make([]gbfs.StationState, 0, stations)
+	for i := 0; i < stations; i++ {
+		id := f.ID(fmt.Sprintf("station-%d", i+1))
+		p := around(i, stations, 500)
+		capacity := 10 + i%10
+		available := i % (capacity + 1)
+
+		s.Stations.Data.Stations = append(s.Stations.Data.Stations, gbfs.Station{
+			StationID: id,
+			Name:      fmt.Sprintf("Station %d", i+1),
+			Latitutde: p.Lat,
+			Longitude: p.Lon,
+			Capacity:  f.NewNonNegativeInt(capacity),
+		})
+		s.Status.Data.Stations = append(s.Status.Data.Stations, gbfs.StationState{
+			StationID:         id,
+			NumBikesAvailable: f.NonNegativeInt(available),
+			NumDocksAvailable: f.NonNegativeInt(capacity - available),
+			IsInstalled:       true,
+			IsRenting:         true,
+			IsReturning:       true,
+		})
+	}
+
+	s.Bikes.Data.Bikes = make([]gbfs.Bike, 0, bikes)
+	for i := 0; i < bikes; i++ {
+		p := around(i, bikes, 1000)
+		s.Bikes.Data.Bikes = append(s.Bikes.Data.Bikes, gbfs.Bike{
+			BikeID:    f.ID(fmt.Sprintf("bike-%d", i+1)),
+			Latitude:  p.Lat,
+			Longitude: p.Lon,
+		})
+	}
+	s.Alerts.Data.Alerts = []gbfs.Alert{}
+	return s
+}
+
+// around returns the i-th of n points on a circle of radius meters
+func around(i, n int, radius float64) f.Point {
+	return Center.Destination(360*float64(i)/math.Max(float64(n), 1), radius)
+}
+
+// document is a served document and its Output
+type document struct {
+	v   any
+	out *gbfs.Output
+}
+
+// feeds returns the documents by feed name
+func (s *System) feeds() map[string]document {
+	return map[string]document{
+		"system_information":  {&s.Information, &s.Information.Output},
+		"station_information": {&s.Stations, &s.Stations.Output},
+		"station_status":      {&s.Status, &s.Status.Output},
+		"free_bike_status":    {&s.Bikes, &s.Bikes.Output},
+		"system_alerts":       {&s.Alerts, &s.Alerts.Output},
+	}
+}
+
+// ttl returns the TTL of feed
+func (s *System) ttl(feed string) int {
+	if ttl, ok := s.TTLs[feed]; ok {
+		return ttl
+	}
+	if s.TTL == 0 {
+		return DefaultTTL
+	}
+	return s.TTL
+}
+
+// languages returns the languages of gbfs.json
+func (s *System) languages() []string {
+	if len(s.Languages) == 0 {
+		return []string{"en"}
+	}
+	return s.Languages
+}
@@ -0,0 +1,158 @@
+package geojson
+
+import (
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// Stations returns a Point Feature per station, joined with its status when ss
+// is not nil
+func Stations(si gbfs.StationInformation, ss *gbfs.StationStatus) FeatureCollection {
+	status := make(map[f.ID]gbfs.StationState)
+	if ss != nil {
+		for _, s := range ss.Data.Stations {
+			status[s.StationID] = s
+		}
+	}
+
+	features := make([]Feature, 0, len(si.Data.Stations))
+	for _, st := range si.Data.Stations {
+		props := Properties{
+			"station_id": st.StationID,
+			"name":       st.Name,
+		}
+		if st.Capacity != nil {
+			props["capacity"] = *st.Capacity
+		}
+		setNonEmpty(props, "short_name", st.ShortName)
+		setNonEmpty(props, "address", st.Address)
+		setNonEmpty(props, "region_id", string(st.RegionID))
+
+		if s, ok := status[st.StationID]; ok {
+			props["num_bikes_available"] = s.NumBikesAvailable
+			props["num_docks_available"] = s.NumDocksAvailable
+			if s.NumBikesDisabled != nil {
+				props["num_bikes_disabled"] = *s.NumBikesDisabled
+			}
+			if s.NumDocsDisabled != nil {
+				props["num_docks_disabled"] = *s.NumDocsDisabled
+			}
+			props["is_installed"] = s.IsInstalled
+			props["is_renting"] = s.IsRenting
+			props["is_returning"] = s.IsReturning
+			props["last_reported"] = s.LastReported
+		}
+
+		features = append(features, NewFeature(string(st.StationID), Point(st.Point()), props))
+	}
+	return NewFeatureCollection(features...)
+}
+
+// Vehicles returns a Point Feature per free floating vehicle
+func Vehicles(fb gbfs.FreeBikeStatus) FeatureCollection {
+	features := make([]Feature, 0, len(fb.Data.Bikes))
+	for _, b := range fb.Data.Bikes {
+		props := Properties{
+			"bike_id":     b.BikeID,
+			"is_reserved": b.IsReserved,
+			"is_disabled": b.IsDisabled,
+		}
+		setNonEmpty(props, "vehicle_type_id", string(b.VehicleTypeID))
+
+		features = append(features, NewFeature(string(b.BikeID), Point(b.Point()), props))
+	}
+	return NewFeatureCollection(features...)
+}
+
+// Regions returns a Feature per region, regions carry no geometry in the
+// specification so the Polygon is the bounding box of the region's stations,
+// regions without stations have a null geometry
+func Regions(sr gbfs.SystemRegions, si gbfs.StationInformation) FeatureCollection {
+	points := stationsByRegion(si)
+
+	features := make([]Feature, 0, len(sr.Data.Regions))
+	for _, r := range sr.Data.Regions {
+		ps := points[r.RegionID]
+
+		var g *Geometry
+		if len(ps) > 0 {
+			g = Polygon(f.NewBBox(ps...))
+		}
+
+		props := Properties{
+			"region_id":     r.RegionID,
+			"name":          r.Name,
+			"station_count": len(ps),
+		}
+		features = append(features, NewFeature(string(r.RegionID), g, props))
+	}
+	return NewFeatureCollection(features...)
+}
+
+// Alerts returns a Feature per alert whose MultiPoint geometry holds the
+// affected stations, directly or through their region. System wide alerts have
+// a null geometry
+func Alerts(sa gbfs.SystemAlerts, si gbfs.StationInformation) FeatureCollection {
+	stations := make(map[f.ID]f.Point, len(si.Data.Stations))
+	for _, st := range si.Data.Stations {
+		stations[st.StationID] = st.Point()
+	}
+	regions := stationsByRegion(si)
+
+	features := make([]Feature, 0, len(sa.Data.Alerts))
+	for _, a := range sa.Data.Alerts {
+		var ps []f.Point
+		for _, id := range a.StationIds {
+			if p, ok := stations[id]; ok {
+				ps = append(ps, p)
+			}
+		}
+		for _, id := range a.RegionIds {
+			ps = append(ps, regions[id]...)
+		}
+
+		var g *Geometry
+		if len(ps) > 0 {
+			g = MultiPoint(ps...)
+		}
+
+		props := Properties{
+			"alert_id": a.AlertID,
+			"type":     a.Type,
+			"summary":  a.Summary,
+		}
+		setNonEmpty(props, "description", a.Description)
+		if a.URL != nil {
+			props["url"] = a.URL
+		}
+		if len(a.Times) > 0 {
+			props["times"] = a.Times
+		}
+		if len(a.StationIds) > 0 {
+			props["station_ids"] = a.StationIds
+		}
+		if len(a.RegionIds) > 0 {
+			props["region_ids"] = a.RegionIds
+		}
+
+		features = append(features, NewFeature(string(a.AlertID), g, props))
+	}
+	return NewFeatureCollection(features...)
+}
+
+// stationsByRegion groups station locations by region_id
+func stationsByRegion(si gbfs.StationInformation) map[f.ID][]f.Point {
+	m := make(map[f.ID][]f.Point)
+	for _, st := range si.Data.Stations {
+		if st.RegionID != "" {
+			m[st.RegionID] = append(m[st.RegionID], st.Point())
+		}
+	}
+	return m
+}
+
+func setNonEmpty(props Properties, key, value string) {
+	if value != "" {
+		props[key] = value
+	}
+}
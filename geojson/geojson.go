@@ -0,0 +1,89 @@
+// Package geojson encodes GBFS feeds as GeoJSON FeatureCollections
+//
+// https://datatracker.ietf.org/doc/html/rfc7946
+package geojson
+
+import (
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// GeoJSON object types
+const (
+	TypeFeatureCollection = "FeatureCollection"
+	TypeFeature           = "Feature"
+	TypePoint             = "Point"
+	TypeMultiPoint        = "MultiPoint"
+	TypePolygon           = "Polygon"
+)
+
+// Properties of a Feature
+type Properties map[string]any
+
+// FeatureCollection https://datatracker.ietf.org/doc/html/rfc7946#section-3.3
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// NewFeatureCollection returns a FeatureCollection of features
+func NewFeatureCollection(features ...Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: TypeFeatureCollection, Features: features}
+}
+
+// Feature https://datatracker.ietf.org/doc/html/rfc7946#section-3.2
+type Feature struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id,omitempty"`
+	Geometry   *Geometry  `json:"geometry"`
+	Properties Properties `json:"properties"`
+}
+
+// NewFeature returns a Feature, a nil geometry is an unlocated Feature
+func NewFeature(id string, g *Geometry, props Properties) Feature {
+	if props == nil {
+		props = Properties{}
+	}
+	return Feature{Type: TypeFeature, ID: id, Geometry: g, Properties: props}
+}
+
+// Geometry https://datatracker.ietf.org/doc/html/rfc7946#section-3.1
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// Position is a longitude, latitude pair
+type Position [2]float64
+
+// NewPosition returns the Position of p, GeoJSON orders longitude first
+func NewPosition(p f.Point) Position {
+	return Position{float64(p.Lon), float64(p.Lat)}
+}
+
+// Point returns a Point Geometry
+func Point(p f.Point) *Geometry {
+	return &Geometry{Type: TypePoint, Coordinates: NewPosition(p)}
+}
+
+// MultiPoint returns a MultiPoint Geometry
+func MultiPoint(points ...f.Point) *Geometry {
+	ps := make([]Position, 0, len(points))
+	for _, p := range points {
+		ps = append(ps, NewPosition(p))
+	}
+	return &Geometry{Type: TypeMultiPoint, Coordinates: ps}
+}
+
+// Polygon returns the Polygon Geometry of a bounding box, the exterior ring is
+// counterclockwise
+func Polygon(b f.BBox) *Geometry {
+	sw, ne := b.Min, b.Max
+	se := f.Point{Lat: sw.Lat, Lon: ne.Lon}
+	nw := f.Point{Lat: ne.Lat, Lon: sw.Lon}
+
+	ring := []Position{NewPosition(sw), NewPosition(se), NewPosition(ne), NewPosition(nw), NewPosition(sw)}
+	return &Geometry{Type: TypePolygon, Coordinates: [][]Position{ring}}
+}
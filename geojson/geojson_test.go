@@ -0,0 +1,135 @@
+package geojson
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+)
+
+func loadFixture(t *testing.T, name string, dst any) {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, dst))
+}
+
+// roundTrip encodes v and decodes it as generic JSON
+func roundTrip(t *testing.T, v any) map[string]any {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(b, &m))
+	return m
+}
+
+// TestStations ...
+func TestStations(t *testing.T) {
+	var si gbfs.StationInformation
+	var ss gbfs.StationStatus
+	loadFixture(t, "station_information.json", &si)
+	loadFixture(t, "station_status.json", &ss)
+
+	fc := roundTrip(t, Stations(si, &ss))
+	assert.Equal(t, TypeFeatureCollection, fc["type"])
+
+	features := fc["features"].([]any)
+	require.Len(t, features, 3)
+
+	first := features[0].(map[string]any)
+	assert.Equal(t, "7000", first["id"])
+	assert.Equal(t, map[string]any{"type": TypePoint, "coordinates": []any{-79.395954, 43.639832}}, first["geometry"])
+
+	props := first["properties"].(map[string]any)
+	assert.Equal(t, "Fort York Blvd / Capreol Ct", props["name"])
+	assert.Equal(t, float64(35), props["capacity"])
+	assert.Equal(t, float64(12), props["num_bikes_available"])
+	assert.Equal(t, true, props["is_renting"])
+	assert.Equal(t, "4", props["region_id"])
+
+	// without status
+	props = roundTrip(t, Stations(si, nil))["features"].([]any)[0].(map[string]any)["properties"].(map[string]any)
+	assert.NotContains(t, props, "num_bikes_available")
+}
+
+// TestVehicles ...
+func TestVehicles(t *testing.T) {
+	var fb gbfs.FreeBikeStatus
+	loadFixture(t, "free_bike_status.json", &fb)
+
+	features := roundTrip(t, Vehicles(fb))["features"].([]any)
+	require.Len(t, features, 2)
+
+	second := features[1].(map[string]any)
+	assert.Equal(t, "jkl012", second["id"])
+	assert.Equal(t, map[string]any{"type": TypePoint, "coordinates": []any{-79.3807, 43.6565}}, second["geometry"])
+
+	props := second["properties"].(map[string]any)
+	assert.Equal(t, true, props["is_reserved"])
+	assert.Equal(t, false, props["is_disabled"])
+	assert.NotContains(t, props, "vehicle_type_id")
+
+	// no bikes
+	assert.Equal(t, []any{}, roundTrip(t, Vehicles(gbfs.FreeBikeStatus{}))["features"])
+}
+
+// TestRegions ...
+func TestRegions(t *testing.T) {
+	var si gbfs.StationInformation
+	var sr gbfs.SystemRegions
+	loadFixture(t, "station_information.json", &si)
+	loadFixture(t, "system_regions.json", &sr)
+
+	sr.Data.Regions = append(sr.Data.Regions, gbfs.Region{RegionID: "6", Name: "Empty"})
+
+	fc := Regions(sr, si)
+	require.Len(t, fc.Features, 3)
+
+	downtown := fc.Features[0]
+	assert.Equal(t, 2, downtown.Properties["station_count"])
+	require.NotNil(t, downtown.Geometry)
+	assert.Equal(t, TypePolygon, downtown.Geometry.Type)
+
+	ring := downtown.Geometry.Coordinates.([][]Position)[0]
+	assert.Len(t, ring, 5)
+	assert.Equal(t, ring[0], ring[4])
+	assert.Equal(t, Position{-79.395954, 43.639832}, ring[0])
+
+	assert.Nil(t, fc.Features[2].Geometry)
+	assert.Contains(t, string(mustMarshal(t, fc.Features[2])), `"geometry":null`)
+}
+
+// TestAlerts ...
+func TestAlerts(t *testing.T) {
+	var si gbfs.StationInformation
+	var sa gbfs.SystemAlerts
+	loadFixture(t, "station_information.json", &si)
+	loadFixture(t, "system_alerts.json", &sa)
+
+	fc := Alerts(sa, si)
+	require.Len(t, fc.Features, 2)
+
+	// station closure
+	assert.Equal(t, TypeMultiPoint, fc.Features[0].Geometry.Type)
+	assert.Equal(t, []Position{{-79.38355, 43.66496}}, fc.Features[0].Geometry.Coordinates)
+
+	// region wide alert covers the region's station
+	assert.Equal(t, []Position{{-79.399429, 43.667333}}, fc.Features[1].Geometry.Coordinates)
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}
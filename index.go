@@ -0,0 +1,309 @@
+package gbfs
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// Entry is a station or a free floating vehicle held by an Index
+type Entry struct {
+	Point   f.Point
+	Station *Station      // nil for vehicles
+	Status  *StationState // nil for vehicles and stations without status
+	Bike    *Bike         // nil for stations
+	// unit vector of Point
+	v [3]float64
+}
+
+// ID returns the station_id or bike_id of the Entry
+func (e Entry) ID() f.ID {
+	if e.Station != nil {
+		return e.Station.StationID
+	}
+	return e.Bike.BikeID
+}
+
+// IsStation reports whether the Entry is a station
+func (e Entry) IsStation() bool {
+	return e.Station != nil
+}
+
+// Hit is an Entry matched by a query and its distance in meters from the
+// queried Point
+type Hit struct {
+	Entry
+	Distance float64
+}
+
+// Filter reports whether an Entry may be returned by a query
+type Filter func(Entry) bool
+
+// All returns a Filter matching entries matched by every filter
+func All(filters ...Filter) Filter {
+	return func(e Entry) bool {
+		for _, fn := range filters {
+			if fn != nil && !fn(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Stations matches stations only
+func Stations() Filter {
+	return func(e Entry) bool { return e.IsStation() }
+}
+
+// Vehicles matches free floating vehicles only
+func Vehicles() Filter {
+	return func(e Entry) bool { return !e.IsStation() }
+}
+
+// MinBikes matches stations with at least n bikes available, an available
+// vehicle counts as a single bike
+func MinBikes(n int) Filter {
+	return func(e Entry) bool {
+		if e.IsStation() {
+			return e.Status != nil && int(e.Status.NumBikesAvailable) >= n
+		}
+		return n <= 1 && !e.Bike.IsReserved && !e.Bike.IsDisabled
+	}
+}
+
+// MinDocks matches stations with at least n docks available
+func MinDocks(n int) Filter {
+	return func(e Entry) bool {
+		return e.IsStation() && e.Status != nil && int(e.Status.NumDocksAvailable) >= n
+	}
+}
+
+// VehicleType matches vehicles of the vehicle type and stations with at least
+// one vehicle of the vehicle type available
+func VehicleType(id f.ID) Filter {
+	return func(e Entry) bool {
+		if !e.IsStation() {
+			return e.Bike.VehicleTypeID == id
+		}
+		if e.Status == nil {
+			return false
+		}
+		for _, vt := range e.Status.VehicleTypesAvailable {
+			if vt.VehicleTypeID == id && vt.Count > 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsRenting matches installed stations that are renting and vehicles that are
+// neither reserved nor disabled
+func IsRenting() Filter {
+	return func(e Entry) bool {
+		if e.IsStation() {
+			return e.Status != nil && e.Status.IsInstalled && e.Status.IsRenting
+		}
+		return !e.Bike.IsReserved && !e.Bike.IsDisabled
+	}
+}
+
+// Index is a spatial index of stations and free floating vehicles supporting
+// nearest neighbour and radius queries
+//
+// Entries are stored as a k-d tree over their unit vectors on the sphere, the
+// chord between two unit vectors grows monotonically with the great-circle
+// distance so the tree can be searched with euclidean distances
+type Index struct {
+	entries []Entry
+}
+
+// NewIndex returns an Index of the stations in si joined with their status in
+// ss and the vehicles in fb, any of which may be nil
+func NewIndex(si *StationInformation, ss *StationStatus, fb *FreeBikeStatus) *Index {
+	status := make(map[f.ID]*StationState)
+	if ss != nil {
+		for i := range ss.Data.Stations {
+			status[ss.Data.Stations[i].StationID] = &ss.Data.Stations[i]
+		}
+	}
+
+	var entries []Entry
+	if si != nil {
+		for i := range si.Data.Stations {
+			st := &si.Data.Stations[i]
+			entries = append(entries, newEntry(st.Point(), st, status[st.StationID], nil))
+		}
+	}
+	if fb != nil {
+		for i := range fb.Data.Bikes {
+			b := &fb.Data.Bikes[i]
+			entries = append(entries, newEntry(b.Point(), nil, nil, b))
+		}
+	}
+
+	build(entries, 0)
+	return &Index{entries: entries}
+}
+
+func newEntry(p f.Point, st *Station, s *StationState, b *Bike) Entry {
+	return Entry{Point: p, Station: st, Status: s, Bike: b, v: unitVector(p)}
+}
+
+// Len returns the number of entries in the Index
+func (ix *Index) Len() int {
+	return len(ix.entries)
+}
+
+// Nearest returns up to k entries matching the filter closest to p, ordered
+// by distance. A nil filter matches every Entry
+func (ix *Index) Nearest(p f.Point, k int, filter Filter) []Hit {
+	if k <= 0 {
+		return nil
+	}
+
+	q := unitVector(p)
+	h := &hitHeap{}
+	var search func(lo, hi, depth int)
+	search = func(lo, hi, depth int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		e := ix.entries[mid]
+
+		if d := chord2(q, e.v); filter == nil || filter(e) {
+			if h.Len() < k {
+				heap.Push(h, candidate{mid, d})
+			} else if d < (*h)[0].d {
+				(*h)[0] = candidate{mid, d}
+				heap.Fix(h, 0)
+			}
+		}
+
+		// search the side of the splitting plane containing q first
+		axis := depth % 3
+		diff := q[axis] - e.v[axis]
+		near, far := [2]int{lo, mid}, [2]int{mid + 1, hi}
+		if diff > 0 {
+			near, far = far, near
+		}
+		search(near[0], near[1], depth+1)
+		if h.Len() < k || diff*diff < (*h)[0].d {
+			search(far[0], far[1], depth+1)
+		}
+	}
+	search(0, len(ix.entries), 0)
+
+	hits := make([]Hit, h.Len())
+	for i := len(hits) - 1; i >= 0; i-- {
+		c := heap.Pop(h).(candidate)
+		hits[i] = ix.hit(c.i, p)
+	}
+	return hits
+}
+
+// Within returns the entries matching the filter within radius meters of p,
+// ordered by distance. A nil filter matches every Entry
+func (ix *Index) Within(p f.Point, radius float64, filter Filter) []Hit {
+	if radius < 0 {
+		return nil
+	}
+
+	// squared chord length of the radius, anything past half the
+	// circumference is the whole sphere
+	r2 := 4.0
+	if a := radius / (2 * f.EarthRadius); a < math.Pi/2 {
+		r2 = 4 * math.Sin(a) * math.Sin(a)
+	}
+
+	q := unitVector(p)
+	var hits []Hit
+	var search func(lo, hi, depth int)
+	search = func(lo, hi, depth int) {
+		if lo >= hi {
+			return
+		}
+		mid := (lo + hi) / 2
+		e := ix.entries[mid]
+
+		if chord2(q, e.v) <= r2 && (filter == nil || filter(e)) {
+			if hit := ix.hit(mid, p); hit.Distance <= radius {
+				hits = append(hits, hit)
+			}
+		}
+
+		axis := depth % 3
+		diff := q[axis] - e.v[axis]
+		if diff <= 0 || diff*diff <= r2 {
+			search(lo, mid, depth+1)
+		}
+		if diff >= 0 || diff*diff <= r2 {
+			search(mid+1, hi, depth+1)
+		}
+	}
+	search(0, len(ix.entries), 0)
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+	return hits
+}
+
+func (ix *Index) hit(i int, p f.Point) Hit {
+	e := ix.entries[i]
+	return Hit{Entry: e, Distance: p.Distance(e.Point)}
+}
+
+// build arranges entries into an implicit k-d tree, the median of each range
+// splits it on the axis of its depth
+func build(entries []Entry, depth int) {
+	if len(entries) <= 1 {
+		return
+	}
+	axis := depth % 3
+	sort.Slice(entries, func(i, j int) bool { return entries[i].v[axis] < entries[j].v[axis] })
+
+	mid := len(entries) / 2
+	build(entries[:mid], depth+1)
+	build(entries[mid+1:], depth+1)
+}
+
+// unitVector returns the cartesian coordinates of p on the unit sphere
+func unitVector(p f.Point) [3]float64 {
+	lat := float64(p.Lat) * math.Pi / 180
+	lon := float64(p.Lon) * math.Pi / 180
+	return [3]float64{
+		math.Cos(lat) * math.Cos(lon),
+		math.Cos(lat) * math.Sin(lon),
+		math.Sin(lat),
+	}
+}
+
+// chord2 returns the squared euclidean distance between two vectors
+func chord2(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+// candidate is an index into Index.entries and its squared chord to the query
+type candidate struct {
+	i int
+	d float64
+}
+
+// hitHeap is a max-heap of candidates, the furthest candidate is on top
+type hitHeap []candidate
+
+func (h hitHeap) Len() int           { return len(h) }
+func (h hitHeap) Less(i, j int) bool { return h[i].d > h[j].d }
+func (h hitHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *hitHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+
+func (h *hitHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,138 @@
+package gbfs
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// randomSystem returns a system of stations and bikes scattered around
+// Toronto
+func randomSystem(r *rand.Rand, stations, bikes int) (*StationInformation, *StationStatus, *FreeBikeStatus) {
+	si, ss, fb := new(StationInformation), new(StationStatus), new(FreeBikeStatus)
+	for i := 0; i < stations; i++ {
+		id := f.ID(fmt.Sprintf("s%d", i))
+		si.Data.Stations = append(si.Data.Stations, Station{
+			StationID: id,
+			Latitutde: f.Latitude(43.6 + r.Float64()*0.2),
+			Longitude: f.Longitude(-79.5 + r.Float64()*0.2),
+		})
+		ss.Data.Stations = append(ss.Data.Stations, StationState{
+			StationID:         id,
+			NumBikesAvailable: f.NonNegativeInt(r.Intn(10)),
+			NumDocksAvailable: f.NonNegativeInt(r.Intn(10)),
+			IsInstalled:       true,
+			IsRenting:         r.Intn(4) != 0,
+		})
+	}
+	for i := 0; i < bikes; i++ {
+		fb.Data.Bikes = append(fb.Data.Bikes, Bike{
+			BikeID:     f.ID(fmt.Sprintf("b%d", i)),
+			Latitude:   f.Latitude(43.6 + r.Float64()*0.2),
+			Longitude:  f.Longitude(-79.5 + r.Float64()*0.2),
+			IsReserved: r.Intn(5) == 0,
+		})
+	}
+	return si, ss, fb
+}
+
+// bruteForce returns every entry matching the filter sorted by distance
+func bruteForce(ix *Index, p f.Point, filter Filter) []Hit {
+	var hits []Hit
+	for i, e := range ix.entries {
+		if filter == nil || filter(e) {
+			hits = append(hits, ix.hit(i, p))
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+	return hits
+}
+
+func ids(hits []Hit) []f.ID {
+	out := make([]f.ID, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, h.ID())
+	}
+	return out
+}
+
+// TestIndexNearest ...
+func TestIndexNearest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ix := NewIndex(randomSystem(r, 300, 200))
+	require.Equal(t, 500, ix.Len())
+
+	for _, tc := range []struct {
+		name   string
+		k      int
+		filter Filter
+	}{
+		{"all", 5, nil},
+		{"stations_with_bikes", 3, All(Stations(), MinBikes(3), IsRenting())},
+		{"stations_with_docks", 10, MinDocks(5)},
+		{"available_vehicles", 4, All(Vehicles(), IsRenting())},
+		{"more_than_available", 1000, Stations()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				p := f.Point{Lat: f.Latitude(43.6 + r.Float64()*0.2), Lon: f.Longitude(-79.5 + r.Float64()*0.2)}
+
+				exp := bruteForce(ix, p, tc.filter)
+				if len(exp) > tc.k {
+					exp = exp[:tc.k]
+				}
+				assert.Equal(t, ids(exp), ids(ix.Nearest(p, tc.k, tc.filter)))
+			}
+		})
+	}
+}
+
+// TestIndexWithin ...
+func TestIndexWithin(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	ix := NewIndex(randomSystem(r, 300, 200))
+
+	for _, radius := range []float64{0, 250, 1_000, 5_000, 50_000} {
+		t.Run(fmt.Sprintf("%vm", radius), func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				p := f.Point{Lat: f.Latitude(43.6 + r.Float64()*0.2), Lon: f.Longitude(-79.5 + r.Float64()*0.2)}
+
+				var exp []Hit
+				for _, h := range bruteForce(ix, p, MinBikes(1)) {
+					if h.Distance <= radius {
+						exp = append(exp, h)
+					}
+				}
+				assert.Equal(t, ids(exp), ids(ix.Within(p, radius, MinBikes(1))))
+			}
+		})
+	}
+}
+
+// TestIndexJoin ...
+func TestIndexJoin(t *testing.T) {
+	var si StationInformation
+	var ss StationStatus
+	loadFixture(t, "station_information.json", &si)
+	loadFixture(t, "station_status.json", &ss)
+
+	ix := NewIndex(&si, &ss, nil)
+
+	// Fort York Blvd / Capreol Ct
+	hits := ix.Nearest(f.Point{Lat: 43.64, Lon: -79.396}, 1, nil)
+	require.Len(t, hits, 1)
+	assert.Equal(t, f.ID("7000"), hits[0].ID())
+	require.NotNil(t, hits[0].Status)
+	assert.Equal(t, f.NonNegativeInt(12), hits[0].Status.NumBikesAvailable)
+
+	// 7001 is not renting
+	for _, h := range ix.Within(f.Point{Lat: 43.66496, Lon: -79.38355}, 10_000, IsRenting()) {
+		assert.NotEqual(t, f.ID("7001"), h.ID())
+	}
+}
@@ -0,0 +1,136 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// ErrNoSnapshot is returned when a feed has no snapshot at the requested time
+var ErrNoSnapshot = errors.New("no snapshot")
+
+// Archive reads the snapshots of a system directory written by a Mirror
+type Archive struct {
+	fsys     fs.FS
+	manifest *Manifest
+}
+
+// OpenArchive reads the manifest of the system directory fsys
+func OpenArchive(fsys fs.FS) (*Archive, error) {
+	m, err := ReadManifest(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Archive{fsys: fsys, manifest: m}, nil
+}
+
+// Manifest returns a copy of the manifest of the archive
+func (a *Archive) Manifest() *Manifest {
+	return a.manifest.clone()
+}
+
+// Snapshot returns the latest snapshot of feed last updated at or before asOf
+func (a *Archive) Snapshot(feed string, asOf time.Time) (Snapshot, error) {
+	s, ok := a.manifest.At(feed, asOf)
+	if !ok {
+		return s, fmt.Errorf("%w: %s as of %s", ErrNoSnapshot, feed, asOf.Format(time.RFC3339))
+	}
+	return s, nil
+}
+
+// Read returns the document of the latest snapshot of feed last updated at or
+// before asOf
+func (a *Archive) Read(feed string, asOf time.Time) ([]byte, error) {
+	s, err := a.Snapshot(feed, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return ReadSnapshot(a.fsys, s)
+}
+
+// Client returns a Client answering with the documents of the archive as of
+// asOf, feeds are resolved through the archived gbfs.json
+func (a *Archive) Client(asOf time.Time) gbfs.Client {
+	return gbfs.NewFetcherClient(a.rootURL(), a.Fetcher(asOf))
+}
+
+// Fetcher returns a Fetcher reading the documents of the archive as of asOf
+func (a *Archive) Fetcher(asOf time.Time) gbfs.Fetcher {
+	return &archiveFetcher{a: a, asOf: asOf}
+}
+
+// rootURL returns the URL the archived gbfs.json was fetched from
+func (a *Archive) rootURL() string {
+	if a.manifest.RootURL == "" {
+		return "gbfs.json"
+	}
+	return a.manifest.RootURL
+}
+
+// archiveFetcher maps feed URLs to their archived feed
+type archiveFetcher struct {
+	a    *Archive
+	asOf time.Time
+
+	once  sync.Once
+	names map[string]string // feed name by URL
+	err   error
+}
+
+// Fetch satisfies the gbfs.Fetcher interface
+func (af *archiveFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if url == af.a.rootURL() {
+		return af.a.Read("gbfs", af.asOf)
+	}
+
+	feed, err := af.feed(url)
+	if err != nil {
+		return nil, err
+	}
+	return af.a.Read(feed, af.asOf)
+}
+
+// feed returns the name of the feed at url, from the archived gbfs.json or
+// the name of the document
+func (af *archiveFetcher) feed(url string) (string, error) {
+	af.once.Do(func() {
+		raw, err := af.a.Read("gbfs", af.asOf)
+		if err != nil {
+			af.err = err
+			return
+		}
+		var g gbfs.GBFS
+		if af.err = json.Unmarshal(raw, &g); af.err != nil {
+			return
+		}
+		af.names = make(map[string]string)
+		for _, l := range g.Languages() {
+			for _, fd := range g.IterFeeds(l) {
+				af.names[fd.URL.String()] = fd.Name
+			}
+		}
+	})
+
+	if name, ok := af.names[url]; ok {
+		return name, nil
+	}
+	name := strings.TrimSuffix(path.Base(url), ".json")
+	if _, ok := af.a.manifest.Feeds[name]; ok {
+		return name, nil
+	}
+	if af.err != nil {
+		return "", af.err
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoSnapshot, url)
+}
@@ -0,0 +1,116 @@
+package mirror
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// archive mirrors the fixtures three times, station_status is last updated at
+// 1609866247, 1609866257 and 1609866267
+func archive(t *testing.T, gzip bool) *Archive {
+	t.Helper()
+
+	dir := t.TempDir()
+	fx := newFixtures()
+	c := &clock{time.Unix(1609866300, 0)}
+	m := newMirror(dir, fx, c, Options{Gzip: gzip})
+
+	for i := int64(0); i < 3; i++ {
+		fx.replace["station_status.json"] = [2]string{"1609866247", strconv.FormatInt(1609866247+i*10, 10)}
+		_, err := m.Sync(context.Background())
+		require.NoError(t, err)
+		c.advance(10 * time.Second)
+	}
+
+	a, err := OpenArchive(os.DirFS(filepath.Join(dir, "example_city")))
+	require.NoError(t, err)
+	return a
+}
+
+// TestManifestAt ...
+func TestManifestAt(t *testing.T) {
+	mf := archive(t, false).Manifest()
+	require.Len(t, mf.Feeds["station_status"], 3)
+
+	for _, tc := range []struct {
+		asOf     int64
+		expected int64
+	}{
+		{1609866246, 0},
+		{1609866247, 1609866247},
+		{1609866256, 1609866247},
+		{1609866257, 1609866257},
+		{1609866300, 1609866267},
+	} {
+		t.Run(strconv.FormatInt(tc.asOf, 10), func(t *testing.T) {
+			s, ok := mf.At("station_status", time.Unix(tc.asOf, 0))
+			if tc.expected == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.expected, s.LastUpdated.Unix())
+		})
+	}
+
+	_, ok := mf.At("vehicle_status", time.Unix(1609866300, 0))
+	assert.False(t, ok)
+}
+
+// TestArchiveClient ...
+func TestArchiveClient(t *testing.T) {
+	for _, gzip := range []bool{false, true} {
+		t.Run(strconv.FormatBool(gzip), func(t *testing.T) {
+			a := archive(t, gzip)
+
+			for asOf, expected := range map[int64]int64{
+				1609866247: 1609866247,
+				1609866260: 1609866257,
+				1609866300: 1609866267,
+			} {
+				g, err := a.Client(time.Unix(asOf, 0)).GBFS()
+				require.NoError(t, err)
+
+				en, err := g.MatchLanguage()
+				require.NoError(t, err)
+
+				ss, err := g.StationStatus(en)
+				require.NoError(t, err)
+				assert.Equal(t, expected, ss.LastUpdated.Unix())
+
+				si, err := g.SystemInformation(en)
+				require.NoError(t, err)
+				assert.Equal(t, f.ID("example_city"), si.Data.SystemID)
+			}
+
+			// nothing was archived yet
+			_, err := a.Client(time.Unix(1609866246, 0)).GBFS()
+			assert.ErrorIs(t, err, ErrNoSnapshot)
+		})
+	}
+}
+
+// TestArchiveFetchAll ...
+func TestArchiveFetchAll(t *testing.T) {
+	a := archive(t, false)
+
+	g, err := a.Client(time.Unix(1609866260, 0)).GBFS()
+	require.NoError(t, err)
+	en, err := g.MatchLanguage()
+	require.NoError(t, err)
+
+	s, err := g.FetchAll(context.Background(), en, gbfs.FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Err())
+	assert.Equal(t, int64(1609866257), s.StationStatus.LastUpdated.Unix())
+}
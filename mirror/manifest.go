@@ -0,0 +1,127 @@
+package mirror
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// ManifestName is the name of the manifest in a system directory
+const ManifestName = "manifest.json"
+
+// Manifest indexes the snapshots of a system, snapshots of each feed are
+// ordered by LastUpdated
+type Manifest struct {
+	SystemID string                `json:"system_id"`
+	RootURL  string                `json:"root_url"`
+	Language f.Language            `json:"language"`
+	Feeds    map[string][]Snapshot `json:"feeds"`
+}
+
+// Snapshot is an archived document
+type Snapshot struct {
+	LastUpdated f.Timestamp      `json:"last_updated"`
+	TTL         f.NonNegativeInt `json:"ttl"`
+	Version     string           `json:"version"`
+	FetchedAt   f.Timestamp      `json:"fetched_at"`
+	// Path is slash separated and relative to the system directory
+	Path string `json:"path"`
+	// Size of the document before compression
+	Size int `json:"size"`
+}
+
+// ReadManifest reads the manifest of the system directory fsys
+func ReadManifest(fsys fs.FS) (*Manifest, error) {
+	raw, err := fs.ReadFile(fsys, ManifestName)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(Manifest)
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, err
+	}
+	if m.Feeds == nil {
+		m.Feeds = make(map[string][]Snapshot)
+	}
+	return m, nil
+}
+
+// ReadSnapshot returns the document of s from the system directory fsys,
+// decompressing it when gzipped
+func ReadSnapshot(fsys fs.FS, s Snapshot) ([]byte, error) {
+	raw, err := fs.ReadFile(fsys, s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if path.Ext(s.Path) != ".gz" {
+		return raw, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// Latest returns the most recent snapshot of feed
+func (m *Manifest) Latest(feed string) (Snapshot, bool) {
+	ss := m.Feeds[feed]
+	if len(ss) == 0 {
+		return Snapshot{}, false
+	}
+	return ss[len(ss)-1], true
+}
+
+// At returns the latest snapshot of feed last updated at or before asOf
+func (m *Manifest) At(feed string, asOf time.Time) (Snapshot, bool) {
+	ss := m.Feeds[feed]
+	i := search(ss, asOf.Unix()+1)
+	if i == 0 {
+		return Snapshot{}, false
+	}
+	return ss[i-1], true
+}
+
+// clone returns a deep copy of the manifest
+func (m *Manifest) clone() *Manifest {
+	c := *m
+	c.Feeds = make(map[string][]Snapshot, len(m.Feeds))
+	for feed, ss := range m.Feeds {
+		c.Feeds[feed] = append([]Snapshot(nil), ss...)
+	}
+	return &c
+}
+
+// has reports whether a snapshot of feed was last updated at unix
+func (m *Manifest) has(feed string, unix int64) bool {
+	ss := m.Feeds[feed]
+	i := search(ss, unix)
+	return i < len(ss) && ss[i].LastUpdated.Unix() == unix
+}
+
+// add inserts s keeping the snapshots of feed ordered
+func (m *Manifest) add(feed string, s Snapshot) {
+	ss := m.Feeds[feed]
+	i := search(ss, s.LastUpdated.Unix())
+	ss = append(ss, Snapshot{})
+	copy(ss[i+1:], ss[i:])
+	ss[i] = s
+	m.Feeds[feed] = ss
+}
+
+// search returns the index of the first snapshot last updated at or after unix
+func search(ss []Snapshot, unix int64) int {
+	return sort.Search(len(ss), func(i int) bool {
+		return ss[i].LastUpdated.Unix() >= unix
+	})
+}
@@ -0,0 +1,393 @@
+// Package mirror archives the feeds of a GBFS system to disk
+//
+// Documents are written to <system>/<feed>/<last_updated>.json[.gz] beneath
+// the mirror directory and indexed by <system>/manifest.json. An Archive serves
+// them back through the typed API as of a past instant
+package mirror
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultInterval is the delay before refetching a document with no TTL, or
+// one that could not be fetched
+const DefaultInterval = gbfs.DefaultRefreshDuration
+
+// ErrNoSystem is returned when the system directory can not be named
+var ErrNoSystem = errors.New("no system id")
+
+// ErrInvalidFeed is returned for feeds whose name can not name a directory
+var ErrInvalidFeed = errors.New("invalid feed name")
+
+// Options configure a Mirror
+type Options struct {
+	// Dir is the directory system directories are created in, the working
+	// directory when empty
+	Dir string
+	// System names the system directory, the system_id of system_information
+	// is used when empty
+	System string
+	// Languages are the preferred languages, the first language of gbfs.json
+	// is mirrored when empty
+	Languages []language.Tag
+	// Feeds restricts the feeds mirrored by name, gbfs.json is always mirrored
+	Feeds []string
+	// Gzip compresses the documents written
+	Gzip bool
+	// Fetcher retrieves documents, over HTTP with http.DefaultClient when nil
+	Fetcher gbfs.Fetcher
+	// OnError is called by Run with the error of each Sync
+	OnError func(error)
+}
+
+// Mirror periodically archives the feeds of a system, respecting the TTL of
+// each document
+type Mirror struct {
+	rootURL string
+	opts    Options
+	now     func() time.Time
+
+	// serializes Sync, protects disc and lang
+	syncing sync.Mutex
+	disc    *gbfs.GBFS
+	lang    f.Language
+
+	mu       sync.Mutex
+	manifest *Manifest
+	next     map[string]time.Time // next fetch of each feed
+}
+
+// New returns a Mirror of the system discovered at rootURL
+func New(rootURL string, opts Options) *Mirror {
+	if opts.Fetcher == nil {
+		opts.Fetcher = gbfs.NewHTTPFetcher(nil)
+	}
+	return &Mirror{
+		rootURL: rootURL,
+		opts:    opts,
+		now:     time.Now,
+		next:    make(map[string]time.Time),
+	}
+}
+
+// document is a fetched document waiting to be written
+type document struct {
+	feed string
+	raw  []byte
+	hdr  gbfs.Output
+}
+
+// Run syncs whenever a document is due until ctx is done
+func (m *Mirror) Run(ctx context.Context) error {
+	for {
+		if _, err := m.Sync(ctx); err != nil && m.opts.OnError != nil {
+			m.opts.OnError(err)
+		}
+
+		wait := m.NextSync().Sub(m.now())
+		if wait < 0 {
+			wait = 0
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// NextSync returns the time the next document is due
+func (m *Mirror) NextSync() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var next time.Time
+	for _, t := range m.next {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	if next.IsZero() {
+		return m.now()
+	}
+	return next
+}
+
+// Manifest returns a copy of the manifest, nil before the first Sync
+func (m *Mirror) Manifest() *Manifest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.manifest == nil {
+		return nil
+	}
+	return m.manifest.clone()
+}
+
+// Sync fetches the documents that are due and writes those that changed,
+// returning the snapshots written. The errors of individual feeds are
+// returned as gbfs.FetchErrors
+func (m *Mirror) Sync(ctx context.Context) ([]Snapshot, error) {
+	m.syncing.Lock()
+	defer m.syncing.Unlock()
+
+	now := m.now()
+	errs := make(gbfs.FetchErrors)
+
+	var docs []document
+	if m.due("gbfs", now) {
+		d, err := m.fetch(ctx, "gbfs", m.rootURL, now)
+		if err != nil {
+			return nil, err
+		}
+		var g gbfs.GBFS
+		if err := json.Unmarshal(d.raw, &g); err != nil {
+			m.schedule("gbfs", now.Add(DefaultInterval))
+			return nil, err
+		}
+		lang, err := g.MatchLanguage(m.opts.Languages...)
+		if err != nil {
+			m.schedule("gbfs", now.Add(DefaultInterval))
+			return nil, err
+		}
+		m.disc, m.lang = &g, lang
+		docs = append(docs, d)
+	}
+
+	for _, fd := range m.feeds() {
+		if !isSegment(fd.Name) {
+			errs[fd.Name] = fmt.Errorf("%w: %q", ErrInvalidFeed, fd.Name)
+			continue
+		}
+		if !m.due(fd.Name, now) {
+			continue
+		}
+		d, err := m.fetch(ctx, fd.Name, fd.URL.String(), now)
+		if err != nil {
+			errs[fd.Name] = err
+			continue
+		}
+		docs = append(docs, d)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.manifest == nil {
+		if err := m.open(docs); err != nil {
+			// refetch everything once the system can be named
+			m.next = make(map[string]time.Time)
+			return nil, err
+		}
+	}
+
+	written, err := m.write(docs, now)
+	if err != nil {
+		return written, err
+	}
+	if len(errs) > 0 {
+		return written, errs
+	}
+	return written, nil
+}
+
+// due reports whether feed should be fetched at now
+func (m *Mirror) due(feed string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, ok := m.next[feed]
+	return !ok || !now.Before(next)
+}
+
+// schedule sets the next fetch of feed
+func (m *Mirror) schedule(feed string, next time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next[feed] = next
+}
+
+// feeds returns the feeds to mirror
+func (m *Mirror) feeds() []gbfs.Feed {
+	feeds := m.disc.IterFeeds(m.lang)
+	if len(m.opts.Feeds) == 0 {
+		return feeds
+	}
+
+	want := make(map[string]bool, len(m.opts.Feeds))
+	for _, name := range m.opts.Feeds {
+		want[name] = true
+	}
+	selected := make([]gbfs.Feed, 0, len(m.opts.Feeds))
+	for _, fd := range feeds {
+		if want[fd.Name] {
+			selected = append(selected, fd)
+		}
+	}
+	return selected
+}
+
+// fetch retrieves the document of feed and schedules its next fetch, a
+// document without last_updated is dated now
+func (m *Mirror) fetch(ctx context.Context, feed, url string, now time.Time) (document, error) {
+	m.schedule(feed, now.Add(DefaultInterval))
+
+	raw, err := m.opts.Fetcher.Fetch(ctx, url)
+	if err != nil {
+		return document{}, err
+	}
+
+	d := document{feed: feed, raw: raw}
+	if err := json.Unmarshal(raw, &d.hdr); err != nil {
+		return document{}, err
+	}
+	if d.hdr.LastUpdated.IsZero() {
+		d.hdr.LastUpdated = f.NewTimestamp(now, f.UnixSeconds)
+	}
+	if d.hdr.TTL > 0 {
+		m.schedule(feed, now.Add(time.Duration(d.hdr.TTL)*time.Second))
+	}
+	return d, nil
+}
+
+// isSegment reports whether name is a single path segment, which can name a
+// directory beneath the mirror directory
+func isSegment(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// open loads the manifest of the system directory or starts a new one, the
+// system is named after the system_information document when not set. m.mu
+// must be held
+func (m *Mirror) open(docs []document) error {
+	system := m.opts.System
+	if system == "" {
+		for _, d := range docs {
+			if d.feed != "system_information" {
+				continue
+			}
+			var si gbfs.SystemInformation
+			if err := json.Unmarshal(d.raw, &si); err != nil {
+				return err
+			}
+			system = string(si.Data.SystemID)
+		}
+	}
+	if !isSegment(system) {
+		return fmt.Errorf("%w: %q", ErrNoSystem, system)
+	}
+
+	dir := filepath.Join(m.opts.Dir, system)
+	mf, err := ReadManifest(os.DirFS(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		mf, err = &Manifest{SystemID: system, Feeds: make(map[string][]Snapshot)}, nil
+	}
+	if err != nil {
+		return err
+	}
+	mf.RootURL, mf.Language = m.rootURL, m.lang
+	m.manifest = mf
+	return nil
+}
+
+// write archives the documents not already in the manifest, m.mu must be held
+func (m *Mirror) write(docs []document, now time.Time) ([]Snapshot, error) {
+	dir := filepath.Join(m.opts.Dir, m.manifest.SystemID)
+
+	var written []Snapshot
+	for _, d := range docs {
+		unix := d.hdr.LastUpdated.Unix()
+		if m.manifest.has(d.feed, unix) {
+			continue
+		}
+
+		name := path.Join(d.feed, fmt.Sprintf("%d.json", unix))
+		data := d.raw
+		if m.opts.Gzip {
+			name += ".gz"
+			var err error
+			if data, err = compress(d.raw); err != nil {
+				return written, err
+			}
+		}
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), data); err != nil {
+			return written, err
+		}
+
+		s := Snapshot{
+			LastUpdated: d.hdr.LastUpdated.WithEncoding(f.UnixSeconds),
+			TTL:         d.hdr.TTL,
+			Version:     d.hdr.Version,
+			FetchedAt:   f.NewTimestamp(now, f.UnixSeconds),
+			Path:        name,
+			Size:        len(d.raw),
+		}
+		m.manifest.add(d.feed, s)
+		written = append(written, s)
+	}
+
+	if len(written) == 0 {
+		return nil, nil
+	}
+	raw, err := json.MarshalIndent(m.manifest, "", "  ")
+	if err != nil {
+		return written, err
+	}
+	return written, writeFile(filepath.Join(dir, ManifestName), raw)
+}
+
+func compress(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile atomically replaces name with data
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,321 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// fixtures serves the testdata fixtures by file name, counting fetches
+type fixtures struct {
+	mu      sync.Mutex
+	fetches map[string]int
+	replace map[string][2]string // per file string replacement
+	fail    map[string]error
+}
+
+func newFixtures() *fixtures {
+	return &fixtures{
+		fetches: make(map[string]int),
+		replace: make(map[string][2]string),
+		fail:    make(map[string]error),
+	}
+}
+
+func (fx *fixtures) Fetch(_ context.Context, url string) ([]byte, error) {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+
+	name := filepath.Base(url)
+	fx.fetches[name]++
+	if err := fx.fail[name]; err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := fx.replace[name]; ok {
+		raw = []byte(strings.ReplaceAll(string(raw), r[0], r[1]))
+	}
+	return raw, nil
+}
+
+func (fx *fixtures) total() int {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+
+	n := 0
+	for _, c := range fx.fetches {
+		n += c
+	}
+	return n
+}
+
+// clock is a controllable time source
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newMirror(dir string, fx *fixtures, c *clock, opts Options) *Mirror {
+	opts.Dir, opts.Fetcher = dir, fx
+	m := New("https://example.com/gbfs/gbfs.json", opts)
+	m.now = c.now
+	return m
+}
+
+// TestMirrorSync ...
+func TestMirrorSync(t *testing.T) {
+	dir := t.TempDir()
+	fx := newFixtures()
+	c := &clock{time.Unix(1609866300, 0)}
+	m := newMirror(dir, fx, c, Options{})
+
+	assert.Nil(t, m.Manifest())
+
+	// everything is written on the first sync
+	written, err := m.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, written, 10)
+	assert.Equal(t, 10, fx.total())
+
+	mf := m.Manifest()
+	require.NotNil(t, mf)
+	assert.Equal(t, "example_city", mf.SystemID)
+	assert.Equal(t, "en", mf.Language.String())
+
+	s, ok := mf.Latest("station_status")
+	require.True(t, ok)
+	assert.Equal(t, "station_status/1609866247.json", s.Path)
+	assert.Equal(t, int64(1609866247), s.LastUpdated.Unix())
+	assert.EqualValues(t, 10, s.TTL)
+	assert.FileExists(t, filepath.Join(dir, "example_city", "station_status", "1609866247.json"))
+	assert.FileExists(t, filepath.Join(dir, "example_city", "gbfs", "1609866247.json"))
+	assert.FileExists(t, filepath.Join(dir, "example_city", ManifestName))
+
+	// nothing is due
+	written, err = m.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, written)
+	assert.Equal(t, 10, fx.total())
+	assert.Equal(t, c.t.Add(10*time.Second), m.NextSync())
+
+	// only the feeds with a 10s TTL are due, and unchanged
+	c.advance(10 * time.Second)
+	written, err = m.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, written)
+	assert.Equal(t, 12, fx.total())
+	assert.Equal(t, 2, fx.fetches["station_status.json"])
+
+	// a new last_updated is written
+	fx.replace["station_status.json"] = [2]string{"1609866247", "1609866257"}
+	c.advance(10 * time.Second)
+	written, err = m.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.Equal(t, "station_status/1609866257.json", written[0].Path)
+	assert.Len(t, m.Manifest().Feeds["station_status"], 2)
+
+	// a new mirror resumes from the manifest
+	resumed := newMirror(dir, newFixtures(), c, Options{})
+	written, err = resumed.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, written, "every document is in the manifest")
+
+	mf, err = ReadManifest(os.DirFS(filepath.Join(dir, "example_city")))
+	require.NoError(t, err)
+	assert.Equal(t, resumed.Manifest(), mf)
+}
+
+// TestMirrorGzip ...
+func TestMirrorGzip(t *testing.T) {
+	dir := t.TempDir()
+	m := newMirror(dir, newFixtures(), &clock{time.Unix(1609866300, 0)}, Options{
+		System: "city",
+		Feeds:  []string{"station_status"},
+		Gzip:   true,
+	})
+
+	written, err := m.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, written, 2)
+
+	s, ok := m.Manifest().Latest("station_status")
+	require.True(t, ok)
+	assert.Equal(t, "station_status/1609866247.json.gz", s.Path)
+
+	raw, err := ReadSnapshot(os.DirFS(filepath.Join(dir, "city")), s)
+	require.NoError(t, err)
+	expected, err := os.ReadFile(filepath.Join("..", "testdata", "station_status.json"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, raw)
+	assert.Equal(t, len(expected), s.Size)
+}
+
+// TestMirrorErrors ...
+func TestMirrorErrors(t *testing.T) {
+	dir := t.TempDir()
+	c := &clock{time.Unix(1609866300, 0)}
+
+	t.Run("feed", func(t *testing.T) {
+		fx := newFixtures()
+		fx.fail["station_status.json"] = errors.New("unavailable")
+
+		written, err := newMirror(dir, fx, c, Options{}).Sync(context.Background())
+		assert.Len(t, written, 9)
+
+		var fe gbfs.FetchErrors
+		require.True(t, errors.As(err, &fe))
+		assert.Contains(t, fe, "station_status")
+	})
+
+	t.Run("no system", func(t *testing.T) {
+		fx := newFixtures()
+		fx.fail["system_information.json"] = errors.New("unavailable")
+		m := newMirror(dir, fx, c, Options{})
+
+		_, err := m.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoSystem)
+
+		// everything is refetched once system_information is available
+		delete(fx.fail, "system_information.json")
+		_, err = m.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 20, fx.total())
+	})
+
+	t.Run("feed name", func(t *testing.T) {
+		fx := newFixtures()
+		fx.replace["gbfs.json"] = [2]string{`"name": "system_hours"`, `"name": "../system_hours"`}
+
+		written, err := newMirror(dir, fx, c, Options{System: "names"}).Sync(context.Background())
+		assert.Len(t, written, 9)
+
+		var fe gbfs.FetchErrors
+		require.True(t, errors.As(err, &fe))
+		assert.ErrorIs(t, fe["../system_hours"], ErrInvalidFeed)
+		assert.Equal(t, 0, fx.fetches["system_hours.json"])
+		assert.NoDirExists(t, filepath.Join(dir, "system_hours"))
+	})
+
+	t.Run("system name", func(t *testing.T) {
+		_, err := newMirror(dir, newFixtures(), c, Options{System: ".."}).Sync(context.Background())
+		assert.ErrorIs(t, err, ErrNoSystem)
+	})
+
+	t.Run("discovery", func(t *testing.T) {
+		fx := newFixtures()
+		fx.fail["gbfs.json"] = errors.New("unavailable")
+
+		_, err := newMirror(dir, fx, c, Options{}).Sync(context.Background())
+		assert.EqualError(t, err, "unavailable")
+		assert.Equal(t, 1, fx.total())
+	})
+}
+
+// TestMirrorNoLastUpdated checks documents without last_updated are dated
+// when fetched
+func TestMirrorNoLastUpdated(t *testing.T) {
+	fx := newFixtures()
+	fx.replace["system_hours.json"] = [2]string{`"last_updated": 1609866247,`, ""}
+	m := newMirror(t.TempDir(), fx, &clock{time.Unix(1609866300, 0)}, Options{
+		System: "city",
+		Feeds:  []string{"system_hours"},
+	})
+
+	_, err := m.Sync(context.Background())
+	require.NoError(t, err)
+
+	s, ok := m.Manifest().Latest("system_hours")
+	require.True(t, ok)
+	assert.Equal(t, "system_hours/1609866300.json", s.Path)
+	assert.Equal(t, int64(1609866300), s.LastUpdated.Unix())
+}
+
+// blockingFetcher blocks fetches until release is closed
+type blockingFetcher struct {
+	gbfs.Fetcher
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b blockingFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.Fetcher.Fetch(ctx, url)
+}
+
+// TestMirrorSyncUnlocked checks the manifest and next sync are available
+// while documents are fetched
+func TestMirrorSyncUnlocked(t *testing.T) {
+	c := &clock{time.Unix(1609866300, 0)}
+	m := newMirror(t.TempDir(), newFixtures(), c, Options{System: "city", Feeds: []string{"station_status"}})
+	_, err := m.Sync(context.Background())
+	require.NoError(t, err)
+
+	bf := blockingFetcher{Fetcher: m.opts.Fetcher, started: make(chan struct{}, 1), release: make(chan struct{})}
+	m.opts.Fetcher = bf
+	c.advance(10 * time.Second)
+
+	done := make(chan error)
+	go func() {
+		_, err := m.Sync(context.Background())
+		done <- err
+	}()
+	<-bf.started
+
+	returned := make(chan struct{})
+	go func() {
+		m.Manifest()
+		m.NextSync()
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("blocked by the in-flight fetch")
+	}
+
+	close(bf.release)
+	assert.NoError(t, <-done)
+}
+
+// TestMirrorRun ...
+func TestMirrorRun(t *testing.T) {
+	dir := t.TempDir()
+	fx := newFixtures()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+	m := New("https://example.com/gbfs/gbfs.json", Options{
+		Dir:     dir,
+		Fetcher: fx,
+		OnError: func(err error) { errs = append(errs, err) },
+	})
+
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "example_city", ManifestName))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, errs)
+}
@@ -1,11 +1,15 @@
 package gbfs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"golang.org/x/text/language"
+
 	f "github.com/marz619/gbfs-go/fields"
 )
 
@@ -25,8 +29,48 @@ func (o *Output) set(c client) {
 }
 
 // get satisfies client interface
-func (o *Output) get(url string, dst any) error {
-	return o.c.get(url, dst)
+func (o *Output) get(ctx context.Context, url string, dst any) error {
+	return o.c.get(ctx, url, dst)
+}
+
+// output satisfies document interface
+func (o *Output) output() *Output {
+	return o
+}
+
+// document is implemented by every feed type through the embedded Output
+type document interface {
+	output() *Output
+}
+
+// newDocument returns a pointer to a new document of the feed type, false is
+// returned for unknown feeds
+func newDocument(name string) (any, bool) {
+	switch name {
+	case "gbfs":
+		return new(GBFS), true
+	case "gbfs_versions":
+		return new(Versions), true
+	case "system_information":
+		return new(SystemInformation), true
+	case "station_information":
+		return new(StationInformation), true
+	case "station_status":
+		return new(StationStatus), true
+	case "free_bike_status":
+		return new(FreeBikeStatus), true
+	case "system_hours":
+		return new(SystemHours), true
+	case "system_calendar":
+		return new(SystemCalendar), true
+	case "system_regions":
+		return new(SystemRegions), true
+	case "system_pricing_plans":
+		return new(SystemPricingPlans), true
+	case "system_alerts":
+		return new(SystemAlerts), true
+	}
+	return nil, false
 }
 
 // LastUpdatedRFC3339 returns LastUpdated timestamp as a RFC3339 formatted value
@@ -58,24 +102,12 @@ func (f Feed) fetch(c client) (any, error) {
 		return f.store, nil
 	}
 
-	var s any
-
-	switch f.name() {
-	case "station_information":
-		s = new(StationInformation)
-	case "station_status":
-		s = new(StationStatus)
-	case "system regions":
-		s = new(SystemRegions)
-	case "system_information":
-		s = new(SystemInformation)
-	case "system_pricing_plans":
-		s = new(SystemPricingPlans)
-	default:
+	s, ok := newDocument(f.name())
+	if !ok {
 		panic("unhandled feed value")
 	}
 
-	err := c.get(f.url(), s)
+	err := c.get(context.Background(), f.url(), s)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +147,14 @@ func (f *Feeds) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON satisfies json.Marshaler interface
+func (f Feeds) MarshalJSON() ([]byte, error) {
+	if f.feeds == nil {
+		return json.Marshal([]Feed{})
+	}
+	return json.Marshal(f.feeds)
+}
+
 // Names returns the names of feeds
 func (f Feeds) Names() []string {
 	return f.names
@@ -133,18 +173,55 @@ type GBFS struct {
 	} `json:"data"`
 }
 
-// Languages ...
+// Languages returns the languages of the feeds ordered by their BCP 47 tag
 func (g GBFS) Languages() []f.Language {
 	ls := make([]f.Language, 0, len(g.Data))
 	for l := range g.Data {
 		ls = append(ls, l)
 	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].String() < ls[j].String() })
 	return ls
 }
 
 // ErrNoFeed ...
 var ErrNoFeed = errors.New("no feed for language")
 
+// ErrNoLanguage ...
+var ErrNoLanguage = errors.New("no matching language")
+
+// MatchLanguage returns the available language that best matches the
+// preferred tags (e.g. fr-CA matches fr), the first of Languages is returned
+// when no preference is given
+func (g GBFS) MatchLanguage(preferred ...language.Tag) (f.Language, error) {
+	ls := g.Languages()
+	if len(ls) == 0 {
+		return f.Language{}, ErrNoLanguage
+	}
+	if len(preferred) == 0 {
+		return ls[0], nil
+	}
+
+	tags := make([]language.Tag, 0, len(ls))
+	for _, l := range ls {
+		tags = append(tags, l.Tag)
+	}
+	_, i, conf := language.NewMatcher(tags).Match(preferred...)
+	if conf == language.No {
+		return f.Language{}, fmt.Errorf("%w: %v", ErrNoLanguage, preferred)
+	}
+	return ls[i], nil
+}
+
+// FeedsFor returns the Feeds of the language that best matches the preferred
+// tags, see MatchLanguage
+func (g GBFS) FeedsFor(preferred ...language.Tag) (Feeds, error) {
+	l, err := g.MatchLanguage(preferred...)
+	if err != nil {
+		return Feeds{}, err
+	}
+	return g.Feeds(l), nil
+}
+
 // IterFeeds allows a client to range over the feeds for this GBFS feed
 func (g GBFS) IterFeeds(l f.Language) []Feed {
 	return g.Data[l].Feeds.feeds
@@ -159,171 +236,249 @@ func (g GBFS) Feeds(l f.Language) Feeds {
 type Versions struct {
 	Output
 	Data struct {
-		Versions []struct {
-			Version string `json:"version"`
-			URL     f.URL  `json:"url"`
-		} `json:"versions"`
+		Versions []Version `json:"versions"`
 	} `json:"data"`
 }
 
+// Version is an entry of Versions
+type Version struct {
+	Version string `json:"version"`
+	URL     f.URL  `json:"url"`
+}
+
 // SystemInformation https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_informationjson
+//
+// Optional fields of struct types, here and in the other feeds, are pointers
+// which are nil when the field is absent: encoding/json never omits a struct
+// value, so a zero f.URL, f.Email or f.Date would be encoded as null where the
+// spec expects no field at all
 type SystemInformation struct {
 	Output
 	Data struct {
-		SystemID         f.ID          `json:"system_id"`
-		Language         f.Language    `json:"language"`
-		Name             string        `json:"name"`
-		ShortName        string        `json:"short_string"`
-		Operator         string        `json:"operator"`
-		URL              f.URL         `json:"url"`
-		PurchaseURL      f.URL         `json:"purchase_url"`
-		StartDate        f.Date        `json:"start_date"`
-		PhoneNumber      f.PhoneNumber `json:"phone_number"`
-		Email            f.Email       `json:"email"`
-		FeedContactEmail f.Email       `json:"feed_contact_email"`
-		Timezone         f.Timezone    `json:"timezone"`
-		LicenseURL       f.URL         `json:"license_url"`
-		RentalApps       map[f.Mobile]struct {
-			StoreURI     f.URI `json:"store_uri"`
-			DiscoveryURI f.URI `json:"discovery_uri"`
-		} `json:"rental_apps"`
+		SystemID         f.ID                   `json:"system_id"`
+		Language         f.Language             `json:"language"`
+		Name             string                 `json:"name"`
+		ShortName        string                 `json:"short_name,omitempty"`
+		Operator         string                 `json:"operator,omitempty"`
+		URL              *f.URL                 `json:"url,omitempty"`
+		PurchaseURL      *f.URL                 `json:"purchase_url,omitempty"`
+		StartDate        *f.Date                `json:"start_date,omitempty"`
+		PhoneNumber      f.PhoneNumber          `json:"phone_number,omitempty"`
+		Email            *f.Email               `json:"email,omitempty"`
+		FeedContactEmail *f.Email               `json:"feed_contact_email,omitempty"`
+		Timezone         f.Timezone             `json:"timezone"`
+		LicenseURL       *f.URL                 `json:"license_url,omitempty"`
+		RentalApps       map[f.Mobile]RentalApp `json:"rental_apps,omitempty"`
 	} `json:"data"`
 }
 
+// RentalApp is the app of a platform in SystemInformation
+type RentalApp struct {
+	StoreURI     f.URI `json:"store_uri"`
+	DiscoveryURI f.URI `json:"discovery_uri"`
+}
+
 // StationInformation https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#station_informationjson
 type StationInformation struct {
 	Output
 	Data struct {
-		Stations []struct {
-			StationID     f.ID             `json:"station_id"`
-			Name          string           `json:"name"`
-			ShortName     string           `json:"short_name"`
-			Latitutde     f.Latitude       `json:"lat"`
-			Longitude     f.Longitude      `json:"lon"`
-			Address       string           `json:"address"`
-			CrossStreet   string           `json:"cross_street"`
-			RegionID      f.ID             `json:"region_id"`
-			PostCode      string           `json:"post_code"`
-			RentalMethods []f.RentalMethod `json:"rental_methods"`
-			Capacity      f.NonNegativeInt `json:"capacity"`
-			RentalURIs    struct {
-				Android f.URI `json:"android"`
-				IOS     f.URI `json:"ios"`
-				Web     f.URL `json:"web"`
-			} `json:"rental_uris"`
-		} `json:"stations"`
+		Stations []Station `json:"stations"`
 	} `json:"data"`
 }
 
+// Station is an entry of StationInformation
+type Station struct {
+	StationID     f.ID              `json:"station_id"`
+	Name          string            `json:"name"`
+	ShortName     string            `json:"short_name,omitempty"`
+	Latitutde     f.Latitude        `json:"lat"`
+	Longitude     f.Longitude       `json:"lon"`
+	Address       string            `json:"address,omitempty"`
+	CrossStreet   string            `json:"cross_street,omitempty"`
+	RegionID      f.ID              `json:"region_id,omitempty"`
+	PostCode      string            `json:"post_code,omitempty"`
+	RentalMethods []f.RentalMethod  `json:"rental_methods,omitempty"`
+	Capacity      *f.NonNegativeInt `json:"capacity,omitempty"`
+	RentalURIs    *RentalURIs       `json:"rental_uris,omitempty"`
+}
+
+// Point returns the location of the Station
+func (s Station) Point() f.Point {
+	return f.Point{Lat: s.Latitutde, Lon: s.Longitude}
+}
+
+// BBox returns the bounding box of all stations
+func (s StationInformation) BBox() f.BBox {
+	b := f.NewBBox()
+	for _, st := range s.Data.Stations {
+		b = b.Extend(st.Point())
+	}
+	return b
+}
+
+// RentalURIs https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#station_informationjson
+//
+// The spec defines the same optional object for stations and bikes, it is
+// named so both share it and referenced by pointer so it is omitted when absent
+type RentalURIs struct {
+	Android *f.URI `json:"android,omitempty"`
+	IOS     *f.URI `json:"ios,omitempty"`
+	Web     *f.URL `json:"web,omitempty"`
+}
+
 // StationStatus https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#station_statusjson
 type StationStatus struct {
 	Output
 	Data struct {
-		Stations []struct {
-			StationID         f.ID             `json:"station_id"`
-			NumBikesAvailable f.NonNegativeInt `json:"num_bikes_available"`
-			NumBikesDisabled  f.NonNegativeInt `json:"num_bikes_disabled"`
-			NumDocksAvailable f.NonNegativeInt `json:"num_docs_available"`
-			NumDocsDisabled   f.NonNegativeInt `json:"num_docs_disabled"`
-			IsInstalled       bool             `json:"is_installed"`
-			IsRenting         bool             `json:"is_renting"`
-			IsReturning       bool             `json:"is_returning"`
-			LastReported      f.Timestamp      `json:"last_reported"`
-		} `json:"stations"`
+		Stations []StationState `json:"stations"`
 	} `json:"data"`
 }
 
+// StationState is an entry of StationStatus
+type StationState struct {
+	StationID         f.ID              `json:"station_id"`
+	NumBikesAvailable f.NonNegativeInt  `json:"num_bikes_available"`
+	NumBikesDisabled  *f.NonNegativeInt `json:"num_bikes_disabled,omitempty"`
+	NumDocksAvailable f.NonNegativeInt  `json:"num_docks_available"`
+	NumDocsDisabled   *f.NonNegativeInt `json:"num_docks_disabled,omitempty"`
+	IsInstalled       bool              `json:"is_installed"`
+	IsRenting         bool              `json:"is_renting"`
+	IsReturning       bool              `json:"is_returning"`
+	LastReported      f.Timestamp       `json:"last_reported"`
+	// added in v2.1
+	VehicleTypesAvailable []VehicleTypeCount `json:"vehicle_types_available,omitempty"`
+}
+
+// VehicleTypeCount https://github.com/NABSA/gbfs/blob/v2.1/gbfs.md#station_statusjson
+type VehicleTypeCount struct {
+	VehicleTypeID f.ID             `json:"vehicle_type_id"`
+	Count         f.NonNegativeInt `json:"count"`
+}
+
 // FreeBikeStatus https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#free_bike_statusjson
 type FreeBikeStatus struct {
 	Output
 	Data struct {
-		Bikes []struct {
-			BikeID     f.ID        `json:"bike_id"`
-			Latitude   f.Latitude  `json:"latitude"`
-			Longitude  f.Longitude `json:"longitude"`
-			IsReserved bool        `json:"is_reserved"`
-			IsDisabled bool        `json:"is_disabled"`
-			RentalURIs struct {
-				Android f.URI `json:"android"`
-				IOS     f.URI `json:"ios"`
-				Web     f.URL `json:"web"`
-			} `json:"rental_uris"`
-		} `json:"bikes"`
+		Bikes []Bike `json:"bikes"`
 	} `json:"data"`
 }
 
+// Bike is an entry of FreeBikeStatus
+type Bike struct {
+	BikeID     f.ID        `json:"bike_id"`
+	Latitude   f.Latitude  `json:"lat"`
+	Longitude  f.Longitude `json:"lon"`
+	IsReserved bool        `json:"is_reserved"`
+	IsDisabled bool        `json:"is_disabled"`
+	RentalURIs *RentalURIs `json:"rental_uris,omitempty"`
+	// added in v2.1
+	VehicleTypeID f.ID `json:"vehicle_type_id,omitempty"`
+}
+
+// Point returns the location of the Bike
+func (b Bike) Point() f.Point {
+	return f.Point{Lat: b.Latitude, Lon: b.Longitude}
+}
+
+// BBox returns the bounding box of all bikes
+func (s FreeBikeStatus) BBox() f.BBox {
+	b := f.NewBBox()
+	for _, bike := range s.Data.Bikes {
+		b = b.Extend(bike.Point())
+	}
+	return b
+}
+
 // SystemHours https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_hoursjson
 type SystemHours struct {
 	Output
 	Data struct {
-		RentalHours []struct {
-			UserTypes []f.UserType  `json:"user_types"`
-			Days      []f.DayOfWeek `json:"days"`
-			StartTime f.Time        `json:"start_time"`
-			EndTime   f.Time        `json:"end_time"`
-		} `json:"rental_hours"`
+		RentalHours []RentalHours `json:"rental_hours"`
 	} `json:"data"`
 }
 
+// RentalHours is an entry of SystemHours
+type RentalHours struct {
+	UserTypes []f.UserType  `json:"user_types"`
+	Days      []f.DayOfWeek `json:"days"`
+	StartTime f.Time        `json:"start_time"`
+	EndTime   f.Time        `json:"end_time"`
+}
+
 // SystemCalendar https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_calendarjson
 type SystemCalendar struct {
 	Output
 	Data struct {
-		Calendars []struct {
-			StartDay   f.Day   `json:"start_day"`
-			StartMonth f.Month `json:"start_month"`
-			StartYear  f.Year  `json:"start_year"`
-			EndDay     f.Day   `json:"eend_day"`
-			EndMonth   f.Month `json:"end_month"`
-			EndYear    f.Year  `json:"end_year"`
-		} `json:"calendar"`
+		Calendars []Calendar `json:"calendars"`
 	} `json:"data"`
 }
 
+// Calendar is an entry of SystemCalendar
+type Calendar struct {
+	StartDay   f.Day   `json:"start_day"`
+	StartMonth f.Month `json:"start_month"`
+	StartYear  f.Year  `json:"start_year,omitempty"`
+	EndDay     f.Day   `json:"end_day"`
+	EndMonth   f.Month `json:"end_month"`
+	EndYear    f.Year  `json:"end_year,omitempty"`
+}
+
 // SystemRegions https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_regionsjson
 type SystemRegions struct {
 	Output
 	Data struct {
-		Regions []struct {
-			RegionID f.ID   `json:"region_id"`
-			Name     string `json:"name"`
-		} `json:"regions"`
+		Regions []Region `json:"regions"`
 	} `json:"data"`
 }
 
+// Region is an entry of SystemRegions
+type Region struct {
+	RegionID f.ID   `json:"region_id"`
+	Name     string `json:"name"`
+}
+
 // SystemPricingPlans https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_pricing_plansjson
 type SystemPricingPlans struct {
 	Output
 	Data struct {
-		Plans []struct {
-			PlanID      f.ID       `json:"plan_id"`
-			URL         f.URL      `json:"url"`
-			Name        string     `json:"name"`
-			Currency    f.Currency `json:"currency"`
-			Price       f.Price    `json:"price"`
-			IsTaxable   bool       `json:"is_taxable"`
-			Description string     `json:"description"`
-		} `json:"plans"`
+		Plans []PricingPlan `json:"plans"`
 	} `json:"data"`
 }
 
+// PricingPlan is an entry of SystemPricingPlans
+type PricingPlan struct {
+	PlanID      f.ID       `json:"plan_id"`
+	URL         *f.URL     `json:"url,omitempty"`
+	Name        string     `json:"name"`
+	Currency    f.Currency `json:"currency"`
+	Price       f.Price    `json:"price"`
+	IsTaxable   bool       `json:"is_taxable"`
+	Description string     `json:"description"`
+}
+
 // SystemAlerts https://github.com/NABSA/gbfs/blob/v2.0/gbfs.md#system_alertsjson
 type SystemAlerts struct {
 	Output
-	Data []struct {
-		Alerts []struct {
-			AlertID f.ID        `json:"alert_id"`
-			Type    f.AlertType `json:"type"`
-			Times   []struct {
-				Start f.Timestamp `json:"start"`
-				End   f.Timestamp `json:"end"`
-			} `json:"times"`
-			StationIds  []f.ID      `json:"station_ids"`
-			RegionIds   []f.ID      `json:"region_ids"`
-			URL         f.URL       `json:"url"`
-			Summary     string      `json:"summary"`
-			Description string      `json:"description"`
-			LastUpdated f.Timestamp `json:"last_updated"`
-		} `json:"alerts"`
+	Data struct {
+		Alerts []Alert `json:"alerts"`
 	} `json:"data"`
 }
+
+// Alert is an entry of SystemAlerts
+type Alert struct {
+	AlertID     f.ID         `json:"alert_id"`
+	Type        f.AlertType  `json:"type"`
+	Times       []AlertTime  `json:"times,omitempty"`
+	StationIds  []f.ID       `json:"station_ids,omitempty"`
+	RegionIds   []f.ID       `json:"region_ids,omitempty"`
+	URL         *f.URL       `json:"url,omitempty"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description,omitempty"`
+	LastUpdated *f.Timestamp `json:"last_updated,omitempty"`
+}
+
+// AlertTime is a period an Alert is in effect, End is nil when open ended
+type AlertTime struct {
+	Start f.Timestamp  `json:"start"`
+	End   *f.Timestamp `json:"end,omitempty"`
+}
@@ -0,0 +1,163 @@
+package gbfs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// TestOutputRoundTrip decodes each fixture and encodes it again expecting the
+// fixture, fields dropped or renamed by the types fail the comparison
+func TestOutputRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		file string
+		new  func() any
+	}{
+		{"gbfs.json", func() any { return new(GBFS) }},
+		{"gbfs_versions.json", func() any { return new(Versions) }},
+		{"system_information.json", func() any { return new(SystemInformation) }},
+		{"station_information.json", func() any { return new(StationInformation) }},
+		{"station_status.json", func() any { return new(StationStatus) }},
+		{"free_bike_status.json", func() any { return new(FreeBikeStatus) }},
+		{"system_hours.json", func() any { return new(SystemHours) }},
+		{"system_calendar.json", func() any { return new(SystemCalendar) }},
+		{"system_regions.json", func() any { return new(SystemRegions) }},
+		{"system_pricing_plans.json", func() any { return new(SystemPricingPlans) }},
+		{"system_alerts.json", func() any { return new(SystemAlerts) }},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			require.NoError(t, err)
+
+			doc := tc.new()
+			require.NoError(t, json.Unmarshal(raw, doc))
+
+			encoded, err := json.Marshal(doc)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(raw), string(encoded))
+		})
+	}
+}
+
+// loadFixture decodes the testdata fixture name into dst
+func loadFixture(t *testing.T, name string, dst any) {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, dst))
+}
+
+// TestGBFSLanguages ...
+func TestGBFSLanguages(t *testing.T) {
+	var g GBFS
+	loadFixture(t, "gbfs.json", &g)
+
+	// languages are ordered
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []string{"en", "fr"}, languageStrings(g.Languages()))
+	}
+
+	for _, tc := range []struct {
+		name      string
+		preferred []language.Tag
+		expected  string
+		err       error
+	}{
+		{"no preference", nil, "en", nil},
+		{"exact", []language.Tag{language.French}, "fr", nil},
+		{"region", []language.Tag{language.CanadianFrench}, "fr", nil},
+		{"script", []language.Tag{language.MustParse("en-Latn-GB")}, "en", nil},
+		{"fallback", []language.Tag{language.German, language.French}, "fr", nil},
+		{"no match", []language.Tag{language.German}, "", ErrNoLanguage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := g.MatchLanguage(tc.preferred...)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, l.String())
+
+			feeds, err := g.FeedsFor(tc.preferred...)
+			require.NoError(t, err)
+			assert.Equal(t, g.Feeds(l).Names(), feeds.Names())
+		})
+	}
+
+	_, err := GBFS{}.FeedsFor(language.English)
+	assert.ErrorIs(t, err, ErrNoLanguage)
+}
+
+func languageStrings(ls []f.Language) []string {
+	ss := make([]string, 0, len(ls))
+	for _, l := range ls {
+		ss = append(ss, l.String())
+	}
+	return ss
+}
+
+// TestPoints ...
+func TestPoints(t *testing.T) {
+	var si StationInformation
+	loadFixture(t, "station_information.json", &si)
+	var fbs FreeBikeStatus
+	loadFixture(t, "free_bike_status.json", &fbs)
+
+	for _, tc := range []struct {
+		name     string
+		actual   f.Point
+		expected f.Point
+	}{
+		{"station 7000", si.Data.Stations[0].Point(), f.Point{Lat: 43.639832, Lon: -79.395954}},
+		{"station 7002", si.Data.Stations[2].Point(), f.Point{Lat: 43.667333, Lon: -79.399429}},
+		{"bike ghi789", fbs.Data.Bikes[0].Point(), f.Point{Lat: 43.6505, Lon: -79.3832}},
+		{"bike jkl012", fbs.Data.Bikes[1].Point(), f.Point{Lat: 43.6565, Lon: -79.3807}},
+		{"zero station", Station{}.Point(), f.Point{}},
+		{"zero bike", Bike{}.Point(), f.Point{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.actual)
+		})
+	}
+}
+
+// TestFeedBBox ...
+func TestFeedBBox(t *testing.T) {
+	var si StationInformation
+	loadFixture(t, "station_information.json", &si)
+	var fbs FreeBikeStatus
+	loadFixture(t, "free_bike_status.json", &fbs)
+
+	for _, tc := range []struct {
+		name     string
+		actual   f.BBox
+		expected f.BBox
+	}{
+		{
+			"stations",
+			si.BBox(),
+			f.BBox{Min: f.Point{Lat: 43.639832, Lon: -79.399429}, Max: f.Point{Lat: 43.667333, Lon: -79.38355}},
+		},
+		{
+			"bikes",
+			fbs.BBox(),
+			f.BBox{Min: f.Point{Lat: 43.6505, Lon: -79.3832}, Max: f.Point{Lat: 43.6565, Lon: -79.3807}},
+		},
+		{"no stations", StationInformation{}.BBox(), f.NewBBox()},
+		{"no bikes", FreeBikeStatus{}.BBox(), f.NewBBox()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.actual)
+			assert.Equal(t, tc.expected.IsEmpty(), tc.actual.IsEmpty())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Health of a proxied system, a system is healthy while the last fetch of
+// each of its feeds succeeded
+type Health struct {
+	System  string                `json:"system"`
+	URL     string                `json:"url"`
+	Healthy bool                  `json:"healthy"`
+	Feeds   map[string]FeedHealth `json:"feeds"`
+}
+
+// FeedHealth of a feed, keyed by gbfs or <language>/<feed> in Health
+type FeedHealth struct {
+	URL         string     `json:"url"`
+	Cached      bool       `json:"cached"`
+	Stale       bool       `json:"stale"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	Errors      int        `json:"consecutive_errors"`
+}
+
+// health returns the Health of s at now
+func (s *system) health(now time.Time) Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := Health{
+		System:  s.id,
+		URL:     s.rootURL,
+		Healthy: true,
+		Feeds:   make(map[string]FeedHealth, len(s.entries)),
+	}
+	for key, e := range s.entries {
+		fh := FeedHealth{
+			URL:    e.url,
+			Cached: e.body != nil,
+			Stale:  e.body != nil && !now.Before(e.expires),
+			Errors: e.errors,
+		}
+		if e.body != nil {
+			fh.LastSuccess, fh.Expires = timePtr(e.fetchedAt), timePtr(e.expires)
+		}
+		if e.err != nil {
+			fh.LastError, fh.LastErrorAt = e.err.Error(), timePtr(e.failedAt)
+		}
+		h.Healthy = h.Healthy && e.errors == 0
+		h.Feeds[key] = fh
+	}
+	return h
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// writeHealth responds with v, 503 when not healthy
+func writeHealth(w http.ResponseWriter, r *http.Request, healthy bool, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
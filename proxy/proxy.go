@@ -0,0 +1,601 @@
+// Package proxy re-serves upstream GBFS systems from a cache
+//
+// Documents are fetched once per ttl however many consumers request them,
+// the last good document is served while the upstream fails and the feed URLs
+// of gbfs.json are rewritten to point at the proxy
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// DefaultMinTTL is the shortest time a document is cached for
+const DefaultMinTTL = 5 * time.Second
+
+// DefaultMaxStale is how long a document is served past its ttl while the
+// upstream fails
+const DefaultMaxStale = time.Hour
+
+// Discovery is the feed name of gbfs.json
+const Discovery = "gbfs"
+
+// Cache states of a response, set in the X-Cache header
+const (
+	Hit   = "HIT"
+	Miss  = "MISS"
+	Stale = "STALE"
+)
+
+var (
+	// ErrInvalidBaseURL is returned when the base URL is not absolute
+	ErrInvalidBaseURL = errors.New("base URL must be absolute")
+	// ErrInvalidSystem is returned when a system id is empty or not a single
+	// path segment
+	ErrInvalidSystem = errors.New("invalid system id")
+	// ErrUnknownSystem is returned for a system that was not added
+	ErrUnknownSystem = errors.New("unknown system")
+	// ErrMalformed is returned when the upstream document is not a JSON object
+	ErrMalformed = errors.New("malformed document")
+)
+
+// Options configure a Proxy
+type Options struct {
+	// BaseURL the proxy is served at, feed URLs of gbfs.json point below it.
+	// Derived from the request host when empty, gbfs.json then varies by Host
+	BaseURL string
+	// TrustForwarded derives the base URL from the X-Forwarded-Proto and
+	// X-Forwarded-Host headers when BaseURL is empty. Only set it behind a
+	// reverse proxy overwriting them, clients control them otherwise
+	TrustForwarded bool
+	// Fetcher retrieves upstream documents, gbfs.NewHTTPFetcher(nil) when nil
+	Fetcher gbfs.Fetcher
+	// Convert wraps Fetcher, e.g. with the Fetcher method of a
+	// convert.Converter so upstream documents are served converted
+	Convert func(gbfs.Fetcher) gbfs.Fetcher
+	// MinTTL raises shorter ttls and delays retries of failed fetches,
+	// DefaultMinTTL when zero
+	MinTTL time.Duration
+	// MaxStale is how long a document is served past its ttl while the
+	// upstream fails, DefaultMaxStale when zero
+	MaxStale time.Duration
+	// OnError is called with every failed upstream fetch
+	OnError func(system, feed string, err error)
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// Proxy is a http.Handler serving each system at <base URL>/<system>/, with
+// gbfs.json at <system>/gbfs.json, feeds at <system>/<language>/<feed>.json,
+// or <system>/<feed>.json from 3.0, and health reports at /health and
+// <system>/health. Languages are served by their canonical BCP 47 tag
+type Proxy struct {
+	base *url.URL
+	opts Options
+
+	mu      sync.RWMutex
+	systems map[string]*system
+}
+
+// New returns a Proxy without systems
+func New(opts Options) (*Proxy, error) {
+	var base *url.URL
+	if opts.BaseURL != "" {
+		var err error
+		if base, err = url.Parse(strings.TrimSuffix(opts.BaseURL, "/")); err != nil {
+			return nil, err
+		}
+		if !base.IsAbs() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidBaseURL, opts.BaseURL)
+		}
+	}
+
+	if opts.Fetcher == nil {
+		opts.Fetcher = gbfs.NewHTTPFetcher(nil)
+	}
+	if opts.Convert != nil {
+		opts.Fetcher = opts.Convert(opts.Fetcher)
+	}
+	if opts.MinTTL == 0 {
+		opts.MinTTL = DefaultMinTTL
+	}
+	if opts.MaxStale == 0 {
+		opts.MaxStale = DefaultMaxStale
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Proxy{base: base, opts: opts, systems: make(map[string]*system)}, nil
+}
+
+// Add proxies the system with gbfs.json at rootURL as id, replacing a system
+// previously added as id and its cache
+func (p *Proxy) Add(id, rootURL string) error {
+	if id == "" || id == "health" || strings.ContainsAny(id, "/?#") {
+		return fmt.Errorf("%w: %q", ErrInvalidSystem, id)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.systems[id] = &system{
+		id:      id,
+		rootURL: rootURL,
+		entries: map[string]*entry{Discovery: {url: rootURL}},
+	}
+	return nil
+}
+
+// Remove stops proxying the system id
+func (p *Proxy) Remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.systems, id)
+}
+
+// Health returns the health of the system id
+func (p *Proxy) Health(id string) (Health, error) {
+	s, ok := p.system(id)
+	if !ok {
+		return Health{}, fmt.Errorf("%w: %q", ErrUnknownSystem, id)
+	}
+	return s.health(p.opts.Now()), nil
+}
+
+// HealthAll returns the health of every system ordered by id
+func (p *Proxy) HealthAll() []Health {
+	p.mu.RLock()
+	systems := make([]*system, 0, len(p.systems))
+	for _, s := range p.systems {
+		systems = append(systems, s)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(systems, func(i, j int) bool { return systems[i].id < systems[j].id })
+	now := p.opts.Now()
+	hs := make([]Health, 0, len(systems))
+	for _, s := range systems {
+		hs = append(hs, s.health(now))
+	}
+	return hs
+}
+
+// ServeHTTP satisfies the http.Handler interface
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel := r.URL.Path
+	if p.base != nil {
+		var ok bool
+		if rel, ok = below(r.URL.Path, p.base.Path); !ok {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+	if len(parts) == 1 && parts[0] == "health" {
+		hs := p.HealthAll()
+		healthy := true
+		for _, h := range hs {
+			healthy = healthy && h.Healthy
+		}
+		writeHealth(w, r, healthy, hs)
+		return
+	}
+
+	s, ok := p.system(parts[0])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var key string
+	switch {
+	case len(parts) == 2 && parts[1] == "health":
+		h := s.health(p.opts.Now())
+		writeHealth(w, r, h.Healthy, h)
+		return
+	case len(parts) == 2 && strings.HasSuffix(parts[1], ".json"):
+		// gbfs.json, or a feed from 3.0
+		key = strings.TrimSuffix(parts[1], ".json")
+	case len(parts) == 3 && strings.HasSuffix(parts[2], ".json"):
+		key = parts[1] + "/" + strings.TrimSuffix(parts[2], ".json")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	res, err := p.get(r.Context(), s, key)
+	if errors.Is(err, gbfs.ErrNoFeed) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	h := w.Header()
+	body := res.body
+	if key == Discovery {
+		base, vary := p.baseURL(r)
+		if body, err = rewrite(body, base+"/"+s.id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		for _, v := range vary {
+			h.Add("Vary", v)
+		}
+	}
+
+	maxAge := int(res.expires.Sub(p.opts.Now()).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	h.Set("X-Cache", res.cache)
+	if res.cache == Stale {
+		h.Set("Warning", `110 - "Response is Stale"`)
+	}
+	h.Set("Content-Length", fmt.Sprint(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// below returns the path of p relative to base, reporting whether p is base or
+// one of its descendants
+func below(p, base string) (string, bool) {
+	rel := strings.TrimPrefix(p, base)
+	if base != "" && (len(rel) == len(p) || rel != "" && rel[0] != '/') {
+		return "", false
+	}
+	return rel, true
+}
+
+// system returns the system added as id
+func (p *Proxy) system(id string) (*system, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s, ok := p.systems[id]
+	return s, ok
+}
+
+// baseURL returns the URL the proxy is served at for r and the request headers
+// it was derived from
+func (p *Proxy) baseURL(r *http.Request) (string, []string) {
+	if p.base != nil {
+		return p.base.String(), nil
+	}
+
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if !p.opts.TrustForwarded {
+		return scheme + "://" + host, []string{"Host"}
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+		host = fwd
+	}
+	return scheme + "://" + host, []string{"Host", "X-Forwarded-Proto", "X-Forwarded-Host"}
+}
+
+// result of a cache lookup
+type result struct {
+	body    []byte
+	cache   string
+	expires time.Time
+}
+
+// get returns the document of key, from the cache while fresh
+func (p *Proxy) get(ctx context.Context, s *system, key string) (result, error) {
+	e, err := p.entry(ctx, s, key)
+	if err != nil {
+		return result{}, err
+	}
+
+	if res, err, ok := s.cached(e, p.opts.Now(), p.opts.MaxStale); ok {
+		return res, err
+	}
+
+	// a single fetch per entry, concurrent requests wait for its result
+	e.fetch.Lock()
+	defer e.fetch.Unlock()
+	if res, err, ok := s.cached(e, p.opts.Now(), p.opts.MaxStale); ok {
+		return res, err
+	}
+
+	body, ttl, err := p.fetch(ctx, e.url)
+	if err != nil && ctx.Err() != nil {
+		return result{}, err
+	}
+	if err == nil && key == Discovery {
+		err = s.route(body)
+	}
+	if err != nil && p.opts.OnError != nil {
+		p.opts.OnError(s.id, key, err)
+	}
+
+	now := p.opts.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		e.err, e.failedAt = err, now
+		e.errors++
+		e.retryAt = now.Add(p.opts.MinTTL)
+		if res, ok := e.stale(now, p.opts.MaxStale); ok {
+			return res, nil
+		}
+		return result{}, err
+	}
+
+	e.body, e.fetchedAt, e.expires = body, now, now.Add(ttl)
+	e.err, e.errors, e.retryAt = nil, 0, time.Time{}
+	return result{body: body, cache: Miss, expires: e.expires}, nil
+}
+
+// entry returns the cache entry of key, the routes are reloaded from gbfs.json
+// once its ttl elapsed. The last routes are kept while gbfs.json fails
+func (p *Proxy) entry(ctx context.Context, s *system, key string) (*entry, error) {
+	if key != Discovery {
+		if _, err := p.get(ctx, s, Discovery); err != nil {
+			s.mu.Lock()
+			routed := s.routed
+			s.mu.Unlock()
+			if !routed {
+				return nil, err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", gbfs.ErrNoFeed, key)
+	}
+	return e, nil
+}
+
+// fetch returns the upstream document at u and how long to cache it for
+func (p *Proxy) fetch(ctx context.Context, u string) ([]byte, time.Duration, error) {
+	body, err := p.opts.Fetcher.Fetch(ctx, u)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var doc struct {
+		TTL int `json:"ttl"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, 0, fmt.Errorf("%w: %s: %v", ErrMalformed, u, err)
+	}
+
+	ttl := time.Duration(doc.TTL) * time.Second
+	if ttl < p.opts.MinTTL {
+		ttl = p.opts.MinTTL
+	}
+	return body, ttl, nil
+}
+
+// system is a proxied system and its cache
+type system struct {
+	id      string
+	rootURL string
+
+	mu      sync.Mutex
+	entries map[string]*entry // by gbfs or <language>/<feed>
+	routed  bool
+}
+
+// entry caches an upstream document, fields other than fetch are guarded by
+// system.mu
+type entry struct {
+	url   string
+	fetch sync.Mutex
+
+	body      []byte
+	fetchedAt time.Time
+	expires   time.Time
+
+	err      error
+	failedAt time.Time
+	errors   int
+	retryAt  time.Time
+}
+
+// cached returns the cached result of e when no fetch is due, reporting
+// whether it did
+func (s *system) cached(e *entry, now time.Time, maxStale time.Duration) (result, error, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.body != nil && e.errors == 0 && now.Before(e.expires) {
+		return result{body: e.body, cache: Hit, expires: e.expires}, nil, true
+	}
+	if e.errors > 0 && now.Before(e.retryAt) {
+		if res, ok := e.stale(now, maxStale); ok {
+			return res, nil, true
+		}
+		return result{}, e.err, true
+	}
+	return result{}, nil, false
+}
+
+// stale returns the last document of e unless it expired more than maxStale
+// ago
+func (e *entry) stale(now time.Time, maxStale time.Duration) (result, bool) {
+	if e.body == nil || now.After(e.expires.Add(maxStale)) {
+		return result{}, false
+	}
+	return result{body: e.body, cache: Stale, expires: e.retryAt}, true
+}
+
+// discoveryDoc is gbfs.json, feeds are listed per language before 3.0
+type discoveryDoc struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// feedList lists the feeds of a language, or of a system from 3.0
+type feedList struct {
+	Feeds []map[string]json.RawMessage `json:"feeds"`
+}
+
+// isV3 reports whether version lists feeds without languages
+func isV3(version string) bool {
+	return strings.HasPrefix(version, "3.")
+}
+
+// canonical returns the canonical BCP 47 tag of lang, lang when invalid
+func canonical(lang string) string {
+	t, err := language.Parse(lang)
+	if err != nil {
+		return lang
+	}
+	return t.String()
+}
+
+// route replaces the feed entries of s with the feeds of gbfs.json, keyed by
+// <canonical language>/<feed> or <feed> from 3.0
+func (s *system) route(body []byte) error {
+	var doc discoveryDoc
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, s.rootURL, err)
+	}
+
+	urls := make(map[string]string)
+	add := func(prefix string, fl feedList) error {
+		for _, fd := range fl.Feeds {
+			var name, u string
+			if err := json.Unmarshal(fd["name"], &name); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrMalformed, s.rootURL, err)
+			}
+			if err := json.Unmarshal(fd["url"], &u); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrMalformed, s.rootURL, err)
+			}
+			urls[prefix+name] = u
+		}
+		return nil
+	}
+	if isV3(doc.Version) {
+		var fl feedList
+		if err := json.Unmarshal(doc.Data, &fl); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMalformed, s.rootURL, err)
+		}
+		if err := add("", fl); err != nil {
+			return err
+		}
+	} else {
+		var data map[string]feedList
+		if err := json.Unmarshal(doc.Data, &data); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMalformed, s.rootURL, err)
+		}
+		for lang, fl := range data {
+			if err := add(canonical(lang)+"/", fl); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := map[string]*entry{Discovery: s.entries[Discovery]}
+	for key, u := range urls {
+		if key == Discovery {
+			continue
+		}
+		if e, ok := s.entries[key]; ok && e.url == u {
+			entries[key] = e
+			continue
+		}
+		entries[key] = &entry{url: u}
+	}
+	s.entries, s.routed = entries, true
+	return nil
+}
+
+// rewrite returns gbfs.json with the feed URLs below base, as routed by
+// system.route
+func rewrite(body []byte, base string) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	var version string
+	if v, ok := doc["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, err
+		}
+	}
+
+	urls := func(raw json.RawMessage, prefix string) (json.RawMessage, error) {
+		var d map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		var fl feedList
+		if err := json.Unmarshal(raw, &fl); err != nil {
+			return nil, err
+		}
+		for _, fd := range fl.Feeds {
+			var name string
+			if err := json.Unmarshal(fd["name"], &name); err != nil {
+				return nil, err
+			}
+			u, err := json.Marshal(prefix + name + ".json")
+			if err != nil {
+				return nil, err
+			}
+			fd["url"] = u
+		}
+		feeds, err := json.Marshal(fl.Feeds)
+		if err != nil {
+			return nil, err
+		}
+		d["feeds"] = feeds
+		return json.Marshal(d)
+	}
+
+	var err error
+	if isV3(version) {
+		doc["data"], err = urls(doc["data"], base+"/")
+	} else {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(doc["data"], &data); err != nil {
+			return nil, err
+		}
+		for lang, d := range data {
+			if data[lang], err = urls(d, base+"/"+canonical(lang)+"/"); err != nil {
+				return nil, err
+			}
+		}
+		doc["data"], err = json.Marshal(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
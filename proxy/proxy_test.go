@@ -0,0 +1,392 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+	"github.com/marz619/gbfs-go/convert"
+	f "github.com/marz619/gbfs-go/fields"
+	"github.com/marz619/gbfs-go/gbfstest"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// setup returns an upstream serving a system proxied as city
+func setup(t *testing.T) (*gbfstest.Server, *Proxy, *httptest.Server, *clock) {
+	t.Helper()
+
+	upstream := gbfstest.NewServer(gbfstest.NewSystem(3, 2))
+	t.Cleanup(upstream.Close)
+
+	c := &clock{now: time.Unix(1609866247, 0)}
+	p, err := New(Options{Fetcher: gbfs.NewHTTPFetcher(upstream.Client()), Now: c.Now})
+	require.NoError(t, err)
+	require.NoError(t, p.Add("city", upstream.RootURL()))
+
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return upstream, p, srv, c
+}
+
+func get(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, body
+}
+
+// TestProxyClient ...
+func TestProxyClient(t *testing.T) {
+	upstream, _, srv, _ := setup(t)
+
+	g, err := gbfs.NewClient(srv.URL+"/city/gbfs.json", nil).GBFS()
+	require.NoError(t, err)
+	en, err := g.MatchLanguage()
+	require.NoError(t, err)
+	for _, fd := range g.IterFeeds(en) {
+		assert.Equal(t, srv.URL+"/city/en/"+fd.Name+".json", fd.URL.String())
+	}
+
+	s, err := g.FetchAll(context.Background(), en, gbfs.FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Err())
+	assert.Equal(t, f.ID("gbfstest"), s.SystemInformation.Data.SystemID)
+	assert.Len(t, s.FreeBikeStatus.Data.Bikes, 2)
+	assert.Equal(t, 1, upstream.Hits(gbfstest.Discovery))
+}
+
+// TestProxyCache ...
+func TestProxyCache(t *testing.T) {
+	upstream, _, srv, c := setup(t)
+	url := srv.URL + "/city/en/station_status.json"
+
+	res, _ := get(t, url)
+	assert.Equal(t, Miss, res.Header.Get("X-Cache"))
+	assert.Equal(t, "public, max-age=60", res.Header.Get("Cache-Control"))
+
+	c.advance(30 * time.Second)
+	res, _ = get(t, url)
+	assert.Equal(t, Hit, res.Header.Get("X-Cache"))
+	assert.Equal(t, "public, max-age=30", res.Header.Get("Cache-Control"))
+	assert.Equal(t, 1, upstream.Hits("station_status"))
+
+	c.advance(30 * time.Second)
+	res, _ = get(t, url)
+	assert.Equal(t, Miss, res.Header.Get("X-Cache"))
+	assert.Equal(t, 2, upstream.Hits("station_status"))
+
+	res, _ = get(t, srv.URL+"/city/en/vehicle_status.json")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res, _ = get(t, srv.URL+"/town/gbfs.json")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+// TestProxyConcurrent ...
+func TestProxyConcurrent(t *testing.T) {
+	upstream, _, srv, _ := setup(t)
+	upstream.SetFault("station_status", gbfstest.Fault{Latency: 50 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Get(srv.URL + "/city/en/station_status.json")
+			if assert.NoError(t, err) {
+				res.Body.Close()
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, upstream.Hits("station_status"))
+}
+
+// TestProxyStale ...
+func TestProxyStale(t *testing.T) {
+	upstream, p, srv, c := setup(t)
+	url := srv.URL + "/city/en/station_status.json"
+
+	_, fresh := get(t, url)
+	h, err := p.Health("city")
+	require.NoError(t, err)
+	assert.True(t, h.Healthy)
+
+	upstream.SetFault("station_status", gbfstest.Fault{StatusCode: http.StatusInternalServerError})
+	c.advance(time.Minute)
+
+	res, body := get(t, url)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, Stale, res.Header.Get("X-Cache"))
+	assert.NotEmpty(t, res.Header.Get("Warning"))
+	assert.Equal(t, fresh, body)
+
+	// failed fetches are retried after MinTTL
+	get(t, url)
+	assert.Equal(t, 2, upstream.Hits("station_status"))
+	c.advance(DefaultMinTTL)
+	get(t, url)
+	assert.Equal(t, 3, upstream.Hits("station_status"))
+
+	res, body = get(t, srv.URL+"/city/health")
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	var health Health
+	require.NoError(t, json.Unmarshal(body, &health))
+	assert.False(t, health.Healthy)
+	fh := health.Feeds["en/station_status"]
+	assert.True(t, fh.Stale)
+	assert.Equal(t, 2, fh.Errors)
+	assert.Contains(t, fh.LastError, "HTTP<500>")
+	assert.True(t, health.Feeds["gbfs"].Cached)
+
+	// past MaxStale
+	c.advance(DefaultMaxStale)
+	res, _ = get(t, url)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+
+	// recovery
+	upstream.ClearFaults()
+	c.advance(DefaultMinTTL)
+	res, _ = get(t, url)
+	assert.Equal(t, Miss, res.Header.Get("X-Cache"))
+	res, _ = get(t, srv.URL+"/health")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+// TestProxyMalformed ...
+func TestProxyMalformed(t *testing.T) {
+	upstream, p, srv, _ := setup(t)
+
+	var errs []string
+	p.opts.OnError = func(system, feed string, err error) {
+		errs = append(errs, system+" "+feed)
+		assert.ErrorIs(t, err, ErrMalformed)
+	}
+
+	upstream.SetFault(gbfstest.Discovery, gbfstest.Fault{Malformed: true})
+	res, _ := get(t, srv.URL+"/city/en/system_information.json")
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.Equal(t, []string{"city gbfs"}, errs)
+
+	hs := p.HealthAll()
+	require.Len(t, hs, 1)
+	assert.False(t, hs[0].Healthy)
+}
+
+// TestProxyBaseURL ...
+func TestProxyBaseURL(t *testing.T) {
+	upstream := gbfstest.NewServer(gbfstest.NewSystem(1, 0))
+	defer upstream.Close()
+
+	_, err := New(Options{BaseURL: "/gbfs"})
+	assert.ErrorIs(t, err, ErrInvalidBaseURL)
+
+	p, err := New(Options{BaseURL: "https://bikes.example.com/gbfs/", Fetcher: gbfs.NewHTTPFetcher(upstream.Client())})
+	require.NoError(t, err)
+	assert.ErrorIs(t, p.Add("a/b", upstream.RootURL()), ErrInvalidSystem)
+	require.NoError(t, p.Add("city", upstream.RootURL()))
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gbfs/city/gbfs.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var g gbfs.GBFS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &g))
+	en, err := g.MatchLanguage()
+	require.NoError(t, err)
+	assert.Equal(t, "https://bikes.example.com/gbfs/city/en/station_status.json", g.Feeds(en).URL("station_status").String())
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/city/gbfs.json", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestProxyForwarded ...
+func TestProxyForwarded(t *testing.T) {
+	upstream := gbfstest.NewServer(gbfstest.NewSystem(1, 0))
+	defer upstream.Close()
+
+	feedURL := func(p *Proxy) (string, http.Header) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "http://proxy.local/city/gbfs.json", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "evil.example.com")
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var g gbfs.GBFS
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &g))
+		en, err := g.MatchLanguage()
+		require.NoError(t, err)
+		return g.Feeds(en).URL("station_status").String(), rec.Header()
+	}
+
+	for _, trust := range []bool{false, true} {
+		p, err := New(Options{Fetcher: gbfs.NewHTTPFetcher(upstream.Client()), TrustForwarded: trust})
+		require.NoError(t, err)
+		require.NoError(t, p.Add("city", upstream.RootURL()))
+
+		u, h := feedURL(p)
+		if !trust {
+			// forwarded headers are ignored by default
+			assert.Equal(t, "http://proxy.local/city/en/station_status.json", u)
+			assert.Equal(t, []string{"Host"}, h.Values("Vary"))
+			continue
+		}
+		assert.Equal(t, "https://evil.example.com/city/en/station_status.json", u)
+		assert.Equal(t, []string{"Host", "X-Forwarded-Proto", "X-Forwarded-Host"}, h.Values("Vary"))
+	}
+}
+
+// TestProxyRoutes checks the routes follow gbfs.json once its ttl elapsed
+func TestProxyRoutes(t *testing.T) {
+	upstream, _, srv, c := setup(t)
+
+	res, _ := get(t, srv.URL+"/city/en/station_status.json")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	upstream.Update(func(sys *gbfstest.System) { sys.Languages = []string{"en", "fr"} })
+	res, _ = get(t, srv.URL+"/city/fr/station_status.json")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	c.advance(time.Minute)
+	res, _ = get(t, srv.URL+"/city/fr/station_status.json")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, upstream.Hits(gbfstest.Discovery))
+
+	// the last routes are kept while gbfs.json fails
+	upstream.SetFault(gbfstest.Discovery, gbfstest.Fault{StatusCode: http.StatusInternalServerError})
+	c.advance(DefaultMaxStale + time.Minute)
+	res, _ = get(t, srv.URL+"/city/fr/station_status.json")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+// TestProxyConvert ...
+func TestProxyConvert(t *testing.T) {
+	upstream := gbfstest.NewServer(gbfstest.NewSystem(2, 0))
+	defer upstream.Close()
+
+	c, err := convert.New("2.3", convert.Options{})
+	require.NoError(t, err)
+	p, err := New(Options{Fetcher: gbfs.NewHTTPFetcher(upstream.Client()), Convert: c.Fetcher})
+	require.NoError(t, err)
+	require.NoError(t, p.Add("city", upstream.RootURL()))
+
+	for _, path := range []string{"/city/gbfs.json", "/city/en/station_status.json"} {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+
+		var out gbfs.Output
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+		assert.Equal(t, "2.3", out.Version, path)
+	}
+}
+
+// docsFetcher serves documents by URL
+func docsFetcher(docs map[string]string) gbfs.Fetcher {
+	return gbfs.FetcherFunc(func(_ context.Context, url string) ([]byte, error) {
+		doc, ok := docs[url]
+		if !ok {
+			return nil, gbfs.ErrNoFeed
+		}
+		return []byte(doc), nil
+	})
+}
+
+// serveJSON requests path from p and decodes the response into dst
+func serveJSON(t *testing.T, p *Proxy, path string, dst any) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, rec.Code, path)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), dst))
+}
+
+// TestProxyLanguages checks feeds of a language key which is not canonical
+// are served at the rewritten URL
+func TestProxyLanguages(t *testing.T) {
+	p, err := New(Options{BaseURL: "https://bikes.example.com/sys", Fetcher: docsFetcher(map[string]string{
+		"https://up/gbfs.json": `{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{
+			"fr-ca":{"feeds":[{"name":"station_status","url":"https://up/fr-ca/station_status.json"}]}
+		}}`,
+		"https://up/fr-ca/station_status.json": `{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{"stations":[]}}`,
+	})})
+	require.NoError(t, err)
+	require.NoError(t, p.Add("city", "https://up/gbfs.json"))
+
+	var g gbfs.GBFS
+	serveJSON(t, p, "/sys/city/gbfs.json", &g)
+	fr, err := g.MatchLanguage()
+	require.NoError(t, err)
+	u := g.Feeds(fr).URL("station_status")
+	assert.Equal(t, "https://bikes.example.com/sys/city/fr-CA/station_status.json", u.String())
+
+	var ss gbfs.StationStatus
+	serveJSON(t, p, u.Path, &ss)
+
+	// the base path is matched by segment
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sysX/city/gbfs.json", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestProxyV3 checks 3.0 systems are passed through
+func TestProxyV3(t *testing.T) {
+	p, err := New(Options{BaseURL: "https://bikes.example.com", Fetcher: docsFetcher(map[string]string{
+		"https://up/gbfs.json": `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{
+			"feeds":[{"name":"vehicle_status","url":"https://up/vehicle_status.json"}]
+		}}`,
+		"https://up/vehicle_status.json": `{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"vehicles":[]}}`,
+	})})
+	require.NoError(t, err)
+	require.NoError(t, p.Add("city", "https://up/gbfs.json"))
+
+	var g struct {
+		Data struct {
+			Feeds []struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"feeds"`
+		} `json:"data"`
+	}
+	serveJSON(t, p, "/city/gbfs.json", &g)
+	require.Len(t, g.Data.Feeds, 1)
+	assert.Equal(t, "https://bikes.example.com/city/vehicle_status.json", g.Data.Feeds[0].URL)
+
+	var vs map[string]any
+	serveJSON(t, p, "/city/vehicle_status.json", &vs)
+	assert.Equal(t, "3.0", vs["version"])
+}
@@ -0,0 +1,471 @@
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// Update is a freshly decoded feed document, or the error encountered while
+// refreshing it
+type Update struct {
+	Feed     string
+	Language f.Language
+	// Document is a pointer to the feed type (e.g. *StationStatus), unknown
+	// feeds are decoded as *json.RawMessage
+	Document any
+	Err      error
+}
+
+// DropPolicy enum decides what happens to an Update when the buffer of a
+// Subscription is full
+type DropPolicy uint8
+
+const (
+	_ DropPolicy = iota
+	// DropOldest discards the oldest buffered Update, subscribers always
+	// receive the latest document
+	DropOldest
+	// DropNewest discards the Update being delivered
+	DropNewest
+	// Block waits for the subscriber, delaying the refresh of the feed
+	Block
+)
+
+// DefaultSubscriptionBuffer is the number of updates buffered per
+// Subscription
+const DefaultSubscriptionBuffer = 1
+
+type subscribeOptions struct {
+	buffer int
+	policy DropPolicy
+	lang   f.Language
+}
+
+// SubscribeOption configures a Subscription
+type SubscribeOption func(*subscribeOptions)
+
+// WithBuffer sets the number of updates buffered by the Subscription
+func WithBuffer(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.buffer = n
+	}
+}
+
+// WithDropPolicy sets what happens to updates when the buffer is full
+func WithDropPolicy(p DropPolicy) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.policy = p
+	}
+}
+
+// WithLanguage sets the preferred language of the feed, the best matching
+// language of the discovery document is used (e.g. fr-CA uses fr). By default
+// the first language of the discovery document is used
+func WithLanguage(l f.Language) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.lang = l
+	}
+}
+
+// Subscription receives the updates of a feed until unsubscribed
+type Subscription struct {
+	c      *clientImpl
+	key    feedKey
+	ch     chan Update
+	policy DropPolicy
+	done   chan struct{}
+	once   sync.Once
+	// closes done
+	stopOnce sync.Once
+	// protects closed and ch from being closed during a send
+	mu     sync.RWMutex
+	closed bool
+	// closed once the latest document is delivered to an unbuffered
+	// subscriber, later updates wait for it
+	replayed chan struct{}
+}
+
+// Updates returns the channel of updates, it is closed once unsubscribed
+func (s *Subscription) Updates() <-chan Update {
+	return s.ch
+}
+
+// Unsubscribe stops the delivery of updates and closes the Updates channel,
+// the feed stops refreshing once it has no subscribers
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.stop()
+		s.c.unsubscribe(s)
+
+		// wait for in-flight sends to give up
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
+
+// stop stops blocking on the subscriber, updates are still delivered to the
+// buffer until unsubscribed
+func (s *Subscription) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// send delivers u according to the DropPolicy, after the latest document
+// delivered on subscribing
+func (s *Subscription) send(ctx context.Context, u Update) {
+	if s.replayed != nil {
+		select {
+		case <-s.replayed:
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+	s.deliver(ctx, u)
+}
+
+// deliver delivers u according to the DropPolicy
+func (s *Subscription) deliver(ctx context.Context, u Update) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- u:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+	case DropNewest:
+		select {
+		case s.ch <- u:
+		default:
+		}
+	default:
+		for {
+			select {
+			case s.ch <- u:
+				return
+			default:
+			}
+			// make room by discarding the oldest update
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	}
+}
+
+// feedKey identifies a refreshed feed, an empty lang is the default language
+type feedKey struct {
+	lang string
+	name string
+}
+
+// refresher refreshes a feed for its subscribers while running, all fields
+// are protected by the client mutex
+type refresher struct {
+	key    feedKey
+	subs   map[*Subscription]struct{}
+	last   *Update
+	quit   chan struct{}      // closed to stop scheduling refreshes
+	cancel context.CancelFunc // cancels the in-flight fetch
+}
+
+// running reports whether refreshes are scheduled
+func (r *refresher) running() bool {
+	return r.quit != nil
+}
+
+// stop stops scheduling refreshes, the in-flight fetch is cancelled when
+// cancel is set
+func (r *refresher) stop(cancel bool) {
+	if r.quit != nil {
+		close(r.quit)
+		r.quit = nil
+	}
+	if cancel && r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// Subscribe satisfies the AutoRefreshClient interface
+func (c *clientImpl) Subscribe(feed string, opts ...SubscribeOption) *Subscription {
+	return c.subscribe(feed, nil, opts...)
+}
+
+// SubscribeFunc satisfies the AutoRefreshClient interface, fn is called from a
+// single goroutine in the order updates are received
+func (c *clientImpl) SubscribeFunc(feed string, fn func(Update), opts ...SubscribeOption) *Subscription {
+	return c.subscribe(feed, fn, opts...)
+}
+
+// subscribe returns a Subscription to feed, updates are passed to fn when set
+func (c *clientImpl) subscribe(feed string, fn func(Update), opts ...SubscribeOption) *Subscription {
+	o := subscribeOptions{buffer: DefaultSubscriptionBuffer, policy: DropOldest}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// dropping the oldest update requires room for one
+	if o.policy == DropOldest && o.buffer < 1 {
+		o.buffer = 1
+	}
+	if o.buffer < 0 {
+		o.buffer = 0
+	}
+
+	key := feedKey{name: feed}
+	if o.lang != (f.Language{}) {
+		key.lang = o.lang.String()
+	}
+
+	s := &Subscription{
+		c:      c,
+		key:    key,
+		ch:     make(chan Update, o.buffer),
+		policy: o.policy,
+		done:   make(chan struct{}),
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// a stopped client delivers nothing
+	if c.state == Closed {
+		s.once.Do(func() {
+			s.stop()
+			s.closed = true
+			close(s.ch)
+		})
+		return s
+	}
+
+	r, ok := c.feeds[key]
+	if !ok {
+		r = &refresher{key: key, subs: make(map[*Subscription]struct{})}
+		c.feeds[key] = r
+	}
+
+	// deliver the latest document right away, ahead of any update published
+	// once registered
+	switch {
+	case r.last == nil:
+	case cap(s.ch) > 0:
+		s.ch <- *r.last
+	default:
+		s.replayed = make(chan struct{})
+		c.wg.Add(1)
+		go func(u Update) {
+			defer c.wg.Done()
+			defer close(s.replayed)
+			s.deliver(c.ctx, u)
+		}(*r.last)
+	}
+	r.subs[s] = struct{}{}
+	if fn != nil {
+		c.fns.Add(1)
+		go func() {
+			defer c.fns.Done()
+			for u := range s.Updates() {
+				fn(u)
+			}
+		}()
+	}
+	if c.state == Refreshing && !r.running() {
+		c.start(r)
+	}
+	return s
+}
+
+func (c *clientImpl) unsubscribe(s *Subscription) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	r, ok := c.feeds[s.key]
+	if !ok {
+		return
+	}
+	delete(r.subs, s)
+	if len(r.subs) == 0 {
+		r.stop(true)
+		delete(c.feeds, s.key)
+	}
+}
+
+// start runs the refresher, c.m must be held
+func (c *clientImpl) start(r *refresher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.quit, r.cancel = make(chan struct{}), cancel
+
+	c.wg.Add(1)
+	go func(quit <-chan struct{}) {
+		defer c.wg.Done()
+		defer cancel()
+		c.run(ctx, quit, r)
+	}(r.quit)
+}
+
+// run refreshes the feed until quit is closed or ctx is done, the next
+// refresh is scheduled after the TTL of the document or
+// DefaultRefreshDuration
+func (c *clientImpl) run(ctx context.Context, quit <-chan struct{}, r *refresher) {
+	for {
+		u, ttl := c.refresh(ctx, r.key)
+		if ctx.Err() != nil {
+			return
+		}
+		c.publish(ctx, r, u)
+
+		wait := DefaultRefreshDuration
+		if u.Err == nil && ttl > 0 {
+			wait = ttl
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-quit:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// refresh fetches the feed returning the Update and the TTL of the document
+func (c *clientImpl) refresh(ctx context.Context, key feedKey) (Update, time.Duration) {
+	u := Update{Feed: key.name}
+
+	url, lang, err := c.resolve(ctx, key)
+	if err != nil {
+		u.Err = err
+		return u, 0
+	}
+	u.Language = lang
+
+	doc, ok := newDocument(key.name)
+	if !ok {
+		doc = new(json.RawMessage)
+	}
+	if u.Err = c.get(ctx, url, doc); u.Err != nil {
+		return u, 0
+	}
+	u.Document = doc
+
+	var ttl time.Duration
+	if d, ok := doc.(document); ok {
+		ttl = time.Duration(d.output().TTL) * time.Second
+	}
+	return u, ttl
+}
+
+// resolve returns the URL of the feed from the discovery document, which is
+// refetched once its TTL has elapsed
+func (c *clientImpl) resolve(ctx context.Context, key feedKey) (string, f.Language, error) {
+	g, err := c.discovery(ctx)
+	if err != nil {
+		return "", f.Language{}, err
+	}
+
+	var preferred []language.Tag
+	if key.lang != "" {
+		preferred = append(preferred, language.Make(key.lang))
+	}
+	lang, err := g.MatchLanguage(preferred...)
+	if key.name == "gbfs" {
+		return c.rootURL, lang, nil
+	}
+	if err != nil {
+		return "", lang, &noFeedError{err}
+	}
+
+	u := g.Feeds(lang).URL(key.name)
+	if u.URL == nil {
+		return "", lang, ErrNoFeed
+	}
+	return u.String(), lang, nil
+}
+
+// noFeedError wraps the error resolving the language of a feed, it matches
+// ErrNoFeed
+type noFeedError struct {
+	err error
+}
+
+func (e *noFeedError) Error() string {
+	return ErrNoFeed.Error() + ": " + e.err.Error()
+}
+
+// Is reports whether target is ErrNoFeed
+func (e *noFeedError) Is(target error) bool {
+	return target == ErrNoFeed
+}
+
+// Unwrap returns the language error
+func (e *noFeedError) Unwrap() error {
+	return e.err
+}
+
+// discovery returns the cached discovery document
+func (c *clientImpl) discovery(ctx context.Context) (GBFS, error) {
+	c.dm.Lock()
+	defer c.dm.Unlock()
+
+	if c.disc != nil && time.Now().Before(c.discT) {
+		return *c.disc, nil
+	}
+
+	g, err := c.gbfs(ctx)
+	if err != nil {
+		return g, err
+	}
+
+	ttl := time.Duration(g.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultRefreshDuration
+	}
+	c.disc, c.discT = &g, time.Now().Add(ttl)
+	return g, nil
+}
+
+// publish records u as the latest Update and delivers it to subscribers,
+// nothing is published once the refresher is cancelled (e.g. paused)
+func (c *clientImpl) publish(ctx context.Context, r *refresher, u Update) {
+	c.m.Lock()
+	// ctx is cancelled under c.m, the refresh is discarded when it completed
+	// as the refresher was being cancelled
+	if ctx.Err() != nil {
+		c.m.Unlock()
+		return
+	}
+	if u.Err == nil {
+		r.last = &u
+	}
+	subs := make([]*Subscription, 0, len(r.subs))
+	for s := range r.subs {
+		subs = append(subs, s)
+	}
+	c.m.Unlock()
+
+	for _, s := range subs {
+		s.send(ctx, u)
+	}
+}
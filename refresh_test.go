@@ -0,0 +1,372 @@
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// fixtureServer serves the testdata fixtures, feed URLs in gbfs.json are
+// rewritten to point at the server
+func fixtureServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var hits int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		name := filepath.Base(r.URL.Path)
+		raw, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		body := strings.ReplaceAll(string(raw), "https://example.com", srv.URL)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func receive(t *testing.T, s *Subscription) Update {
+	t.Helper()
+
+	select {
+	case u, ok := <-s.Updates():
+		require.True(t, ok, "subscription closed")
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+	return Update{}
+}
+
+// TestSubscribe ...
+func TestSubscribe(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+	s := c.Subscribe("station_status")
+
+	// nothing is delivered while paused
+	select {
+	case <-s.Updates():
+		t.Fatal("update received while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, Refreshing, c.Resume())
+	assert.Equal(t, Noop, c.Resume())
+
+	u := receive(t, s)
+	require.NoError(t, u.Err)
+	assert.Equal(t, "station_status", u.Feed)
+	assert.Equal(t, "en", u.Language.String())
+
+	ss, ok := u.Document.(*StationStatus)
+	require.True(t, ok)
+	assert.Len(t, ss.Data.Stations, 3)
+
+	// a late subscriber receives the latest document right away, queued
+	// ahead of later updates
+	late := c.Subscribe("station_status")
+	assert.Len(t, late.Updates(), 1)
+	assert.Equal(t, ss, receive(t, late).Document)
+
+	s.Unsubscribe()
+	late.Unsubscribe()
+	_, ok = <-s.Updates()
+	assert.False(t, ok)
+
+	assert.Equal(t, Paused, c.Pause())
+}
+
+// TestSubscribeErrors ...
+func TestSubscribeErrors(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+	defer c.Pause()
+
+	c.Resume()
+
+	unknown := c.Subscribe("vehicle_status")
+	defer unknown.Unsubscribe()
+	assert.ErrorIs(t, receive(t, unknown).Err, ErrNoFeed)
+
+	// free_bike_status is not advertised in french
+	fr := c.Subscribe("free_bike_status", WithLanguage(mustLanguage(t, "fr")))
+	defer fr.Unsubscribe()
+	assert.ErrorIs(t, receive(t, fr).Err, ErrNoFeed)
+
+	// german is not advertised at all
+	de := c.Subscribe("system_information", WithLanguage(mustLanguage(t, "de")))
+	defer de.Unsubscribe()
+	err := receive(t, de).Err
+	assert.ErrorIs(t, err, ErrNoFeed)
+	assert.ErrorIs(t, err, ErrNoLanguage)
+}
+
+// TestSubscribeFunc ...
+func TestSubscribeFunc(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+	defer c.Pause()
+
+	updates := make(chan Update, 1)
+	s := c.SubscribeFunc("system_information", func(u Update) { updates <- u }, WithDropPolicy(Block))
+	defer s.Unsubscribe()
+
+	c.Resume()
+
+	select {
+	case u := <-updates:
+		require.NoError(t, u.Err)
+		assert.IsType(t, &SystemInformation{}, u.Document)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not called")
+	}
+}
+
+// TestSubscriptionDropPolicy ...
+func TestSubscriptionDropPolicy(t *testing.T) {
+	c := NewAutoRefreshClient("", nil).(*clientImpl)
+
+	oldest := c.Subscribe("a", WithBuffer(2))
+	newest := c.Subscribe("a", WithBuffer(2), WithDropPolicy(DropNewest))
+
+	r := c.feeds[feedKey{name: "a"}]
+	for _, feed := range []string{"1", "2", "3"} {
+		c.publish(bgCtx, r, Update{Feed: feed})
+	}
+
+	assert.Equal(t, "2", (<-oldest.Updates()).Feed)
+	assert.Equal(t, "3", (<-oldest.Updates()).Feed)
+	assert.Equal(t, "1", (<-newest.Updates()).Feed)
+	assert.Equal(t, "2", (<-newest.Updates()).Feed)
+
+	oldest.Unsubscribe()
+	newest.Unsubscribe()
+	assert.Empty(t, c.feeds)
+}
+
+var bgCtx = context.Background()
+
+func mustLanguage(t *testing.T, s string) f.Language {
+	t.Helper()
+
+	var l f.Language
+	require.NoError(t, json.Unmarshal([]byte(`"`+s+`"`), &l))
+	return l
+}
+
+// TestAutoRefreshClientConcurrency is meant to be run with -race
+func TestAutoRefreshClientConcurrency(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				switch (i + j) % 4 {
+				case 0:
+					c.Pause()
+				case 1:
+					c.Resume()
+				case 2:
+					s := c.Subscribe("station_status", WithDropPolicy(DropPolicy(1+j%3)))
+					s.Unsubscribe()
+				case 3:
+					s := c.SubscribeFunc("system_information", func(Update) {})
+					defer s.Unsubscribe()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, Noop, c.Resume())
+	assert.Equal(t, Noop, c.Pause())
+
+	// subscribing to a closed client yields a closed Subscription
+	_, ok := <-c.Subscribe("station_status").Updates()
+	assert.False(t, ok)
+}
+
+// blockingServer serves a discovery document once release is closed, started
+// receives each time a request is in-flight
+func blockingServer(t *testing.T) (srv *httptest.Server, release, started chan struct{}) {
+	t.Helper()
+
+	release, started = make(chan struct{}), make(chan struct{}, 1)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, release, started
+}
+
+// TestAutoRefreshClientStop ...
+func TestAutoRefreshClientStop(t *testing.T) {
+	t.Run("graceful", func(t *testing.T) {
+		srv, release, started := blockingServer(t)
+
+		c := NewAutoRefreshClient(srv.URL, srv.Client())
+		s := c.Subscribe("gbfs")
+		c.Resume()
+		<-started
+
+		stopped := make(chan error)
+		go func() { stopped <- c.Stop(context.Background()) }()
+
+		// Stop waits for the in-flight fetch
+		select {
+		case <-stopped:
+			t.Fatal("Stop returned with a fetch in-flight")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.NoError(t, <-stopped)
+
+		// the in-flight fetch is delivered before the subscription closes
+		u, ok := <-s.Updates()
+		require.True(t, ok)
+		assert.NoError(t, u.Err)
+		_, ok = <-s.Updates()
+		assert.False(t, ok)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		srv, release, started := blockingServer(t)
+		defer close(release)
+
+		c := NewAutoRefreshClient(srv.URL, srv.Client())
+		s := c.Subscribe("gbfs")
+		c.Resume()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, c.Stop(ctx), context.DeadlineExceeded)
+		_, ok := <-s.Updates()
+		assert.False(t, ok)
+	})
+}
+
+// TestAutoRefreshClientStopBlocked checks Stop does not wait for a Block
+// subscriber which never reads
+func TestAutoRefreshClientStopBlocked(t *testing.T) {
+	for name, stop := range map[string]func(AutoRefreshClient) error{
+		"close": func(c AutoRefreshClient) error { return c.Close() },
+		"deadline": func(c AutoRefreshClient) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			return c.Stop(ctx)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv, _ := fixtureServer(t)
+
+			c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+			first := c.Subscribe("system_information")
+			c.Resume()
+			require.NoError(t, receive(t, first).Err)
+
+			// the latest document is delivered to a subscriber never reading
+			blocked := c.Subscribe("system_information", WithDropPolicy(Block), WithBuffer(0))
+			called := make(chan struct{})
+			c.SubscribeFunc("system_information", func(Update) {
+				select {
+				case <-called:
+				default:
+					close(called)
+				}
+			})
+			<-called
+
+			stopped := make(chan error, 1)
+			go func() { stopped <- stop(c) }()
+
+			select {
+			case err := <-stopped:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("Stop did not return")
+			}
+			_, ok := <-blocked.Updates()
+			assert.False(t, ok)
+		})
+	}
+}
+
+// TestSubscribeLanguageMatch ...
+func TestSubscribeLanguageMatch(t *testing.T) {
+	srv, _ := fixtureServer(t)
+
+	c := NewAutoRefreshClient(srv.URL+"/gbfs/gbfs.json", srv.Client())
+	defer c.Close()
+
+	s := c.Subscribe("system_information", WithLanguage(mustLanguage(t, "fr-CA")))
+	c.Resume()
+
+	u := receive(t, s)
+	require.NoError(t, u.Err)
+	assert.Equal(t, "fr", u.Language.String())
+}
+
+// TestPublishAfterPause checks a refresh completing as the client is paused
+// is not delivered
+func TestPublishAfterPause(t *testing.T) {
+	c := NewAutoRefreshClient("https://example.com/gbfs.json", nil).(*clientImpl)
+	s := c.Subscribe("gbfs")
+	defer s.Unsubscribe()
+
+	c.m.Lock()
+	r := c.feeds[feedKey{name: "gbfs"}]
+	c.m.Unlock()
+
+	// the refresher context is cancelled by Pause
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.publish(ctx, r, Update{Feed: "gbfs", Document: new(GBFS)})
+
+	select {
+	case <-s.Updates():
+		t.Fatal("update received after pause")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.m.Lock()
+	assert.Nil(t, r.last)
+	c.m.Unlock()
+}
@@ -0,0 +1,357 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// KeywordError is the error of a value not matching a keyword of its schema
+type KeywordError struct {
+	Keyword string
+	Message string
+}
+
+func (e *KeywordError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns ErrMismatch
+func (e *KeywordError) Unwrap() error {
+	return ErrMismatch
+}
+
+// checker collects the errors of an instance
+type checker struct {
+	root   *node
+	strict bool
+	errs   []gbfs.FieldError
+}
+
+// fail records the error of the value at path
+func (c *checker) fail(path, keyword, format string, args ...any) {
+	c.errs = append(c.errs, gbfs.FieldError{
+		Field: path,
+		Err:   &KeywordError{Keyword: keyword, Message: fmt.Sprintf(format, args...)},
+	})
+}
+
+// matches reports whether v matches n, without recording errors
+func (c *checker) matches(n *node, v any) bool {
+	sub := &checker{root: c.root, strict: c.strict}
+	sub.check(n, v, "")
+	return len(sub.errs) == 0
+}
+
+// check records the errors of the value v at path against n
+func (c *checker) check(n *node, v any, path string) {
+	if n == nil {
+		return
+	}
+	if n.always != nil {
+		if !*n.always {
+			c.fail(path, "false", "not allowed")
+		}
+		return
+	}
+	if n.Ref != "" {
+		c.check(c.resolve(n.Ref), v, path)
+		return
+	}
+
+	if len(n.Type) > 0 && !hasType(n.Type, v) {
+		c.fail(path, "type", "must be %s, not %s", strings.Join(n.Type, " or "), typeOf(v))
+		return
+	}
+	if n.enum != nil {
+		found := false
+		for _, e := range n.enum {
+			found = found || equal(e, v)
+		}
+		if !found {
+			c.fail(path, "enum", "must be one of %s", values(n.Enum))
+		}
+	}
+	if n.Const != nil && !equal(n.konst, v) {
+		c.fail(path, "const", "must be %s", n.Const)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		c.object(n, v, path)
+	case []any:
+		c.array(n, v, path)
+	case string:
+		c.string(n, v, path)
+	case json.Number:
+		c.number(n, v, path)
+	}
+
+	for _, s := range n.AllOf {
+		c.check(s, v, path)
+	}
+	if len(n.AnyOf) > 0 {
+		found := false
+		for _, s := range n.AnyOf {
+			found = found || c.matches(s, v)
+		}
+		if !found {
+			c.fail(path, "anyOf", "must match a schema of anyOf")
+		}
+	}
+	if len(n.OneOf) > 0 {
+		matched := 0
+		for _, s := range n.OneOf {
+			if c.matches(s, v) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			c.fail(path, "oneOf", "must match exactly one schema of oneOf, matches %d", matched)
+		}
+	}
+	if n.Not != nil && c.matches(n.Not, v) {
+		c.fail(path, "not", "must not match the schema of not")
+	}
+	if n.If != nil {
+		if c.matches(n.If, v) {
+			c.check(n.Then, v, path)
+		} else {
+			c.check(n.Else, v, path)
+		}
+	}
+}
+
+// resolve returns the schema of a local $ref, refs are resolved when
+// compiled
+func (c *checker) resolve(ref string) *node {
+	return resolve(c.root, ref)
+}
+
+func (c *checker) object(n *node, o map[string]any, path string) {
+	for _, key := range n.Required {
+		if _, ok := o[key]; !ok {
+			c.fail(join(path, key), "required", "required field is missing")
+		}
+	}
+	if n.MinProperties != nil && len(o) < *n.MinProperties {
+		c.fail(path, "minProperties", "must have at least %d properties", *n.MinProperties)
+	}
+	if n.MaxProperties != nil && len(o) > *n.MaxProperties {
+		c.fail(path, "maxProperties", "must have at most %d properties", *n.MaxProperties)
+	}
+
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, dep := range n.Dependencies[k] {
+			if _, ok := o[dep]; !ok {
+				c.fail(join(path, dep), "dependencies", "required with %s", k)
+			}
+		}
+
+		known := false
+		if s, ok := n.Properties[k]; ok {
+			known = true
+			c.check(s, o[k], join(path, k))
+		}
+		for p, re := range n.patterns {
+			if re.MatchString(k) {
+				known = true
+				c.check(n.PatternProperties[p], o[k], join(path, k))
+			}
+		}
+		if !known && n.AdditionalProperties == nil && c.strict && len(n.Properties) > 0 {
+			c.fail(join(path, k), "additionalProperties", "unknown field")
+			continue
+		}
+		if !known && n.AdditionalProperties != nil {
+			if a := n.AdditionalProperties; a.always != nil && !*a.always {
+				c.fail(join(path, k), "additionalProperties", "unknown field")
+				continue
+			}
+			c.check(n.AdditionalProperties, o[k], join(path, k))
+		}
+	}
+}
+
+func (c *checker) array(n *node, a []any, path string) {
+	if n.MinItems != nil && len(a) < *n.MinItems {
+		c.fail(path, "minItems", "must have at least %d items", *n.MinItems)
+	}
+	if n.MaxItems != nil && len(a) > *n.MaxItems {
+		c.fail(path, "maxItems", "must have at most %d items", *n.MaxItems)
+	}
+	if n.UniqueItems {
+	unique:
+		for i := range a {
+			for j := 0; j < i; j++ {
+				if equal(a[i], a[j]) {
+					c.fail(path, "uniqueItems", "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+	for i, item := range a {
+		c.check(n.Items, item, path+"["+strconv.Itoa(i)+"]")
+	}
+}
+
+func (c *checker) string(n *node, s string, path string) {
+	length := utf8.RuneCountInString(s)
+	if n.MinLength != nil && length < *n.MinLength {
+		c.fail(path, "minLength", "must be at least %d characters", *n.MinLength)
+	}
+	if n.MaxLength != nil && length > *n.MaxLength {
+		c.fail(path, "maxLength", "must be at most %d characters", *n.MaxLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		c.fail(path, "pattern", "must match %s", n.Pattern)
+	}
+	if n.Format != "" && !validFormat(n.Format, s) {
+		c.fail(path, "format", "must be a valid %s", n.Format)
+	}
+}
+
+func (c *checker) number(n *node, num json.Number, path string) {
+	f, err := num.Float64()
+	if err != nil {
+		c.fail(path, "type", "invalid number %s", num)
+		return
+	}
+	if n.Minimum != nil && f < *n.Minimum {
+		c.fail(path, "minimum", "must be >= %v", *n.Minimum)
+	}
+	if n.Maximum != nil && f > *n.Maximum {
+		c.fail(path, "maximum", "must be <= %v", *n.Maximum)
+	}
+	if n.ExclusiveMinimum != nil && f <= *n.ExclusiveMinimum {
+		c.fail(path, "exclusiveMinimum", "must be > %v", *n.ExclusiveMinimum)
+	}
+	if n.ExclusiveMaximum != nil && f >= *n.ExclusiveMaximum {
+		c.fail(path, "exclusiveMaximum", "must be < %v", *n.ExclusiveMaximum)
+	}
+}
+
+// validFormat reports whether s is of format, unknown formats are valid
+func validFormat(format, s string) bool {
+	switch format {
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "email":
+		_, err := mail.ParseAddress(s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}
+	return true
+}
+
+// hasType reports whether v is of one of the JSON types ts
+func hasType(ts types, v any) bool {
+	actual := typeOf(v)
+	for _, t := range ts {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON type of v, numbers without a fractional part are
+// integers
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal reports whether the JSON values a and b are equal, numbers are
+// compared by value
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		bn, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := a.Float64()
+		bf, berr := bn.Float64()
+		return aerr == nil && berr == nil && af == bf
+	case []any:
+		bs, ok := b.([]any)
+		if !ok || len(a) != len(bs) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bo, ok := b.(map[string]any)
+		if !ok || len(a) != len(bo) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := bo[k]
+			if !ok || !equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// values returns the raw values joined
+func values(raws []json.RawMessage) string {
+	out := make([]string, 0, len(raws))
+	for _, raw := range raws {
+		out = append(out, string(raw))
+	}
+	return "[" + strings.Join(out, ", ") + "]"
+}
+
+// join returns the path of key in the object at path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// node is a JSON Schema, the subset of draft-07 keywords used by the GBFS
+// schemas is supported
+type node struct {
+	// always is set for the boolean schemas true and false
+	always *bool
+
+	Ref                  string              `json:"$ref"`
+	Definitions          map[string]*node    `json:"definitions"`
+	Type                 types               `json:"type"`
+	Enum                 []json.RawMessage   `json:"enum"`
+	Const                json.RawMessage     `json:"const"`
+	Properties           map[string]*node    `json:"properties"`
+	PatternProperties    map[string]*node    `json:"patternProperties"`
+	AdditionalProperties *node               `json:"additionalProperties"`
+	Required             []string            `json:"required"`
+	MinProperties        *int                `json:"minProperties"`
+	MaxProperties        *int                `json:"maxProperties"`
+	Dependencies         map[string][]string `json:"dependencies"`
+	Items                *node               `json:"items"`
+	MinItems             *int                `json:"minItems"`
+	MaxItems             *int                `json:"maxItems"`
+	UniqueItems          bool                `json:"uniqueItems"`
+	Minimum              *float64            `json:"minimum"`
+	Maximum              *float64            `json:"maximum"`
+	ExclusiveMinimum     *float64            `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64            `json:"exclusiveMaximum"`
+	MinLength            *int                `json:"minLength"`
+	MaxLength            *int                `json:"maxLength"`
+	Pattern              string              `json:"pattern"`
+	Format               string              `json:"format"`
+	AllOf                []*node             `json:"allOf"`
+	AnyOf                []*node             `json:"anyOf"`
+	OneOf                []*node             `json:"oneOf"`
+	Not                  *node               `json:"not"`
+	If                   *node               `json:"if"`
+	Then                 *node               `json:"then"`
+	Else                 *node               `json:"else"`
+
+	pattern  *regexp.Regexp
+	patterns map[string]*regexp.Regexp
+	enum     []any
+	konst    any
+}
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (n *node) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "true" || string(data) == "false" {
+		b := string(data) == "true"
+		n.always = &b
+		return nil
+	}
+
+	type plain node
+	return json.Unmarshal(data, (*plain)(n))
+}
+
+// types of the type keyword, a string or an array of strings
+type types []string
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (t *types) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = types{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// compile parses the schema raw and prepares its keywords
+func compile(raw []byte) (*node, error) {
+	var root node
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	if err := root.prepare(&root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// resolve returns the schema of a local $ref in root, nil when missing
+func resolve(root *node, ref string) *node {
+	if ref == "#" {
+		return root
+	}
+	return root.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+}
+
+// prepare compiles the patterns and decodes the values of n and its
+// subschemas, rejecting the $refs not resolved in root
+func (n *node) prepare(root *node) error {
+	if n == nil || n.always != nil {
+		return nil
+	}
+
+	var err error
+	if n.Pattern != "" {
+		if n.pattern, err = regexp.Compile(n.Pattern); err != nil {
+			return err
+		}
+	}
+	if len(n.PatternProperties) > 0 {
+		n.patterns = make(map[string]*regexp.Regexp, len(n.PatternProperties))
+		for p := range n.PatternProperties {
+			if n.patterns[p], err = regexp.Compile(p); err != nil {
+				return err
+			}
+		}
+	}
+	for _, raw := range n.Enum {
+		v, err := decode(raw)
+		if err != nil {
+			return err
+		}
+		n.enum = append(n.enum, v)
+	}
+	if n.Const != nil {
+		if n.konst, err = decode(n.Const); err != nil {
+			return err
+		}
+	}
+	if n.Ref != "" && n.Ref != "#" && !strings.HasPrefix(n.Ref, "#/definitions/") {
+		return fmt.Errorf("unsupported $ref %q", n.Ref)
+	}
+	if n.Ref != "" && resolve(root, n.Ref) == nil {
+		return fmt.Errorf("unresolved $ref %q", n.Ref)
+	}
+
+	children := []*node{n.AdditionalProperties, n.Items, n.Not, n.If, n.Then, n.Else}
+	for _, m := range []map[string]*node{n.Definitions, n.Properties, n.PatternProperties} {
+		for _, c := range m {
+			children = append(children, c)
+		}
+	}
+	for _, list := range [][]*node{n.AllOf, n.AnyOf, n.OneOf} {
+		children = append(children, list...)
+	}
+	for _, c := range children {
+		if err := c.prepare(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode returns the JSON value raw, numbers are json.Number
+func decode(raw []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,189 @@
+// Package schema validates raw GBFS documents against JSON Schemas of the
+// specification
+//
+// The schemas of each version are embedded, following the layout of the
+// official gbfs-json-schema repository, and checked with an in-house
+// implementation of the subset of JSON Schema draft-07 they use so validation
+// works offline. Raw documents are validated, catching the fields decoding
+// into the types of the gbfs package would silently drop. The schemas allow
+// fields they do not list, ValidateStrict rejects them so a misspelled
+// optional field is reported too
+package schema
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marz619/gbfs-go"
+)
+
+//go:generate go run ../cmd/gbfsgen -schemas . -out ../spec
+
+//go:embed v1.1/*.json v2.0/*.json
+var files embed.FS
+
+var (
+	// ErrNoSchema is returned for feeds and versions without an embedded
+	// schema
+	ErrNoSchema = errors.New("no schema")
+	// ErrMismatch is wrapped by the errors of the fields not matching their
+	// schema
+	ErrMismatch = errors.New("does not match schema")
+)
+
+// Schema of a feed in a version
+type Schema struct {
+	Version string
+	Feed    string
+	root    *node
+}
+
+var loaded sync.Map // *Schema by <version>/<feed>
+
+// Versions returns the versions with embedded schemas
+func Versions() []string {
+	entries, _ := fs.ReadDir(files, ".")
+	vs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		vs = append(vs, strings.TrimPrefix(e.Name(), "v"))
+	}
+	sort.Strings(vs)
+	return vs
+}
+
+// Feeds returns the feeds with a schema in version
+func Feeds(version string) []string {
+	entries, _ := fs.ReadDir(files, "v"+version)
+	feeds := make([]string, 0, len(entries))
+	for _, e := range entries {
+		feeds = append(feeds, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return feeds
+}
+
+// Load returns the schema of feed in version, e.g. station_status in 2.0
+func Load(version, feed string) (*Schema, error) {
+	key := version + "/" + feed
+	if s, ok := loaded.Load(key); ok {
+		return s.(*Schema), nil
+	}
+
+	raw, err := files.ReadFile("v" + version + "/" + feed + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoSchema, feed, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, err := compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s %s: %w", feed, version, err)
+	}
+
+	s, _ := loaded.LoadOrStore(key, &Schema{Version: version, Feed: feed, root: root})
+	return s.(*Schema), nil
+}
+
+// Validate returns a *gbfs.ValidationError listing the fields of doc not
+// matching the schema, or the error decoding doc
+func (s *Schema) Validate(doc []byte) error {
+	return s.validate(doc, false)
+}
+
+// ValidateStrict is Validate also rejecting the fields of objects not listed
+// in their properties
+func (s *Schema) ValidateStrict(doc []byte) error {
+	return s.validate(doc, true)
+}
+
+func (s *Schema) validate(doc []byte, strict bool) error {
+	inst, err := decode(doc)
+	if err != nil {
+		return err
+	}
+
+	c := &checker{root: s.root, strict: strict}
+	c.check(s.root, inst, "")
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return &gbfs.ValidationError{Feed: s.Feed, Errors: c.errs}
+}
+
+// Validate validates doc of feed against the schema of its version, 1.0
+// documents do not have a schema
+func Validate(feed string, doc []byte) error {
+	s, err := schemaOf(feed, doc)
+	if err != nil {
+		return err
+	}
+	return s.Validate(doc)
+}
+
+// ValidateStrict is Validate also rejecting the fields the schema does not
+// list
+func ValidateStrict(feed string, doc []byte) error {
+	s, err := schemaOf(feed, doc)
+	if err != nil {
+		return err
+	}
+	return s.ValidateStrict(doc)
+}
+
+// schemaOf returns the schema of feed in the version of doc
+func schemaOf(feed string, doc []byte) (*Schema, error) {
+	inst, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	version := "1.0"
+	if o, ok := inst.(map[string]any); ok {
+		if v, ok := o["version"].(string); ok {
+			version = v
+		}
+	}
+
+	return Load(version, feed)
+}
+
+// Fetcher returns a gbfs.Fetcher validating the documents retrieved by fe
+// before they are decoded, feeds are named after the document of their URL.
+// Invalid documents are passed to onInvalid and returned, or fail the fetch
+// when onInvalid is nil. Documents without a schema, e.g. of versions after
+// 2.0, are returned unchecked and passed to onInvalid with an error wrapping
+// ErrNoSchema
+func Fetcher(fe gbfs.Fetcher, onInvalid func(url string, err error)) gbfs.Fetcher {
+	return gbfs.FetcherFunc(func(ctx context.Context, rawURL string) ([]byte, error) {
+		doc, err := fe.Fetch(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		err = Validate(feedOf(rawURL), doc)
+		switch {
+		case err == nil, errors.Is(err, ErrNoSchema) && onInvalid == nil:
+			return doc, nil
+		case onInvalid != nil:
+			onInvalid(rawURL, err)
+			return doc, nil
+		}
+		return nil, err
+	})
+}
+
+// feedOf returns the name of the feed at rawURL
+func feedOf(rawURL string) string {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		p = u.Path
+	}
+	return strings.TrimSuffix(path.Base(p), ".json")
+}
@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+)
+
+// fieldErrors returns the errors of a *gbfs.ValidationError as strings
+func fieldErrors(t *testing.T, err error) []string {
+	t.Helper()
+
+	var ve *gbfs.ValidationError
+	require.True(t, errors.As(err, &ve), "%v", err)
+	out := make([]string, 0, len(ve.Errors))
+	for _, fe := range ve.Errors {
+		out = append(out, fe.Field+": "+fe.Err.Error())
+	}
+	return out
+}
+
+// TestVersions ...
+func TestVersions(t *testing.T) {
+	assert.Equal(t, []string{"1.1", "2.0"}, Versions())
+	for _, v := range Versions() {
+		feeds := Feeds(v)
+		assert.Len(t, feeds, 11, v)
+		for _, feed := range feeds {
+			_, err := Load(v, feed)
+			assert.NoError(t, err, "%s %s", v, feed)
+		}
+	}
+	assert.Empty(t, Feeds("9.9"))
+
+	for _, tc := range [][2]string{{"1.0", "gbfs"}, {"2.0", "bogus"}, {"3.0", "gbfs"}} {
+		_, err := Load(tc[0], tc[1])
+		assert.True(t, errors.Is(err, ErrNoSchema), "%v", tc)
+	}
+}
+
+// TestTestdata ...
+func TestTestdata(t *testing.T) {
+	entries, err := os.ReadDir("../testdata")
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		raw, err := os.ReadFile("../testdata/" + e.Name())
+		require.NoError(t, err)
+		assert.NoError(t, ValidateStrict(strings.TrimSuffix(e.Name(), ".json"), raw), e.Name())
+	}
+}
+
+// TestRoundTrip checks the gbfs types keep every field of the fixtures
+func TestRoundTrip(t *testing.T) {
+	for feed, dst := range map[string]any{
+		"gbfs":                 new(gbfs.GBFS),
+		"gbfs_versions":        new(gbfs.Versions),
+		"system_information":   new(gbfs.SystemInformation),
+		"station_information":  new(gbfs.StationInformation),
+		"station_status":       new(gbfs.StationStatus),
+		"free_bike_status":     new(gbfs.FreeBikeStatus),
+		"system_hours":         new(gbfs.SystemHours),
+		"system_calendar":      new(gbfs.SystemCalendar),
+		"system_regions":       new(gbfs.SystemRegions),
+		"system_pricing_plans": new(gbfs.SystemPricingPlans),
+		"system_alerts":        new(gbfs.SystemAlerts),
+	} {
+		t.Run(feed, func(t *testing.T) {
+			raw, err := os.ReadFile("../testdata/" + feed + ".json")
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(raw, dst))
+
+			out, err := json.Marshal(dst)
+			require.NoError(t, err)
+			s, err := Load("2.0", feed)
+			require.NoError(t, err)
+			assert.NoError(t, s.Validate(out))
+		})
+	}
+}
+
+// TestValidate ...
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		feed     string
+		doc      string
+		expected []string
+	}{
+		"misspelled field": {
+			"station_status",
+			`{"last_updated":1609866247,"ttl":10,"version":"2.0","data":{"stations":[
+				{"station_id":"a","num_bikes_available":1,"num_docs_available":2,"is_installed":true,"is_renting":true,"is_returning":true,"last_reported":1609866200}
+			]}}`,
+			[]string{
+				"data.stations[0].num_docks_available: required field is missing",
+			},
+		},
+		"types": {
+			"free_bike_status",
+			`{"last_updated":1.5,"ttl":10,"version":"2.0","data":{"bikes":[
+				{"bike_id":"a","lat":"43","lon":-79.4,"is_reserved":0,"is_disabled":false}
+			]}}`,
+			[]string{
+				"last_updated: must be integer, not number",
+				"data.bikes[0].is_reserved: must be boolean, not integer",
+				"data.bikes[0].lat: must be number, not string",
+			},
+		},
+		"ranges": {
+			"system_regions",
+			`{"last_updated":1609866247,"ttl":-1,"version":"2.0","data":{"regions":[{"region_id":"1","name":"a","extra":true}]}}`,
+			[]string{"ttl: must be >= 0"},
+		},
+		"unknown feed": {
+			"gbfs",
+			`{"last_updated":1609866247,"ttl":0,"version":"2.0","data":{"en":{"feeds":[{"name":"bogus","url":"https://example.com/bogus.json"}]}}}`,
+			[]string{`data.en.feeds[0].name: must be one of ["gbfs_versions", "system_information", "station_information", "station_status", "free_bike_status", "system_hours", "system_calendar", "system_regions", "system_pricing_plans", "system_alerts"]`},
+		},
+		"version": {
+			"system_regions",
+			`{"last_updated":1609866247,"ttl":0,"version":"1.1","data":{"regions":[{"region_id":"1","name":"a"}]}}`,
+			nil,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.feed, []byte(tc.doc))
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrMismatch))
+			assert.ElementsMatch(t, tc.expected, fieldErrors(t, err))
+		})
+	}
+
+	assert.True(t, errors.Is(Validate("gbfs", []byte(`{"ttl":0,"data":{}}`)), ErrNoSchema))
+	assert.Error(t, Validate("gbfs", []byte(`{"ttl":`)))
+}
+
+// TestValidateStrict ...
+func TestValidateStrict(t *testing.T) {
+	doc := []byte(`{"last_updated":1609866247,"ttl":10,"version":"2.0","data":{"stations":[
+		{"station_id":"a","num_bikes_available":1,"num_docks_available":2,"num_docs_disabled":0,"is_installed":true,"is_renting":true,"is_returning":true,"last_reported":1609866200}
+	]}}`)
+
+	assert.NoError(t, Validate("station_status", doc))
+	err := ValidateStrict("station_status", doc)
+	assert.True(t, errors.Is(err, ErrMismatch))
+	assert.Equal(t, []string{"data.stations[0].num_docs_disabled: unknown field"}, fieldErrors(t, err))
+
+	// objects without properties, e.g. of languages, allow any field
+	assert.NoError(t, ValidateStrict("gbfs", []byte(`{"last_updated":1609866247,"ttl":0,"version":"2.0","data":{"fr":{"feeds":[]}}}`)))
+}
+
+// TestCompile ...
+func TestCompile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		schema   string
+		expected string
+	}{
+		"unresolved ref": {`{"properties":{"a":{"$ref":"#/definitions/missing"}}}`, `unresolved $ref "#/definitions/missing"`},
+		"remote ref":     {`{"$ref":"https://example.com/schema.json"}`, `unsupported $ref "https://example.com/schema.json"`},
+		"resolved ref":   {`{"definitions":{"a":{"type":"string"}},"items":{"$ref":"#/definitions/a"}}`, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := compile([]byte(tc.schema))
+			if tc.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tc.expected, err.Error())
+		})
+	}
+}
+
+// TestFetcher ...
+func TestFetcher(t *testing.T) {
+	docs := map[string]string{
+		"https://example.com/en/system_regions.json": `{"last_updated":1609866247,"ttl":-1,"version":"2.0","data":{"regions":[]}}`,
+		"https://example.com/en/vehicle_types.json":  `{"anything":true}`,
+	}
+	fe := gbfs.FetcherFunc(func(_ context.Context, url string) ([]byte, error) {
+		url, _, _ = strings.Cut(url, "?")
+		return []byte(docs[url]), nil
+	})
+	ctx := context.Background()
+
+	_, err := Fetcher(fe, nil).Fetch(ctx, "https://example.com/en/system_regions.json")
+	assert.True(t, errors.Is(err, ErrMismatch))
+	raw, err := Fetcher(fe, nil).Fetch(ctx, "https://example.com/en/vehicle_types.json")
+	assert.NoError(t, err)
+	assert.Equal(t, docs["https://example.com/en/vehicle_types.json"], string(raw))
+
+	var invalid []string
+	raw, err = Fetcher(fe, func(url string, err error) {
+		invalid = append(invalid, url)
+	}).Fetch(ctx, "https://example.com/en/system_regions.json?key=1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, raw)
+	assert.Equal(t, []string{"https://example.com/en/system_regions.json?key=1"}, invalid)
+
+	// documents without a schema are reported unchecked
+	var unchecked error
+	raw, err = Fetcher(fe, func(url string, err error) {
+		unchecked = err
+	}).Fetch(ctx, "https://example.com/en/vehicle_types.json")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, raw)
+	assert.True(t, errors.Is(unchecked, ErrNoSchema), "%v", unchecked)
+}
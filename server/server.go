@@ -0,0 +1,464 @@
+// Package server publishes GBFS feeds over HTTP
+//
+// Documents are produced by a Provider per feed, the Server fills in their
+// last_updated, ttl and version and serves them with caching headers
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultVersion of the documents served
+const DefaultVersion = gbfs.DefaultVersion
+
+// DefaultTTL of the documents served
+const DefaultTTL = 60
+
+var (
+	// ErrInvalidBaseURL is returned when the base URL is not absolute
+	ErrInvalidBaseURL = errors.New("base URL must be absolute")
+	// ErrVersionMismatch is returned when Options.Version is not the version
+	// of Options.Convert
+	ErrVersionMismatch = errors.New("version does not match the converter")
+)
+
+// Provider returns the document of a feed in a language, e.g. a
+// *gbfs.StationStatus. The last_updated of the document is set to the time its
+// content last changed when missing, gbfs.ErrNoFeed responds with a 404
+type Provider func(ctx context.Context, lang f.Language) (any, error)
+
+// Static returns a Provider of doc in every language
+func Static(doc any) Provider {
+	return func(context.Context, f.Language) (any, error) {
+		return doc, nil
+	}
+}
+
+// Converter converts the documents of Providers to a version, e.g. a
+// *convert.Converter
+type Converter interface {
+	Version() string
+	Provider(feed string, p Provider) Provider
+}
+
+// Options configure a Server
+type Options struct {
+	// Version of the documents, the version of Convert or DefaultVersion
+	// when empty
+	Version string
+	// TTL of gbfs.json and feeds registered with a negative TTL, DefaultTTL
+	// when zero
+	TTL int
+	// Languages of the feeds, English when empty
+	Languages []language.Tag
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+	// Convert converts the documents of every feed handled and gbfs.json to
+	// Version, nil serves documents as provided
+	Convert Converter
+}
+
+type feed struct {
+	name     string
+	ttl      int
+	provider Provider
+}
+
+type version struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// Server is a http.Handler serving gbfs.json at <base URL>/gbfs.json and each
+// feed at <base URL>/<language>/<feed>.json
+type Server struct {
+	base  *url.URL
+	opts  Options
+	langs []f.Language
+
+	mu       sync.RWMutex
+	feeds    []feed
+	versions []version
+	stamps   map[string]stamp // by path, of documents without last_updated
+}
+
+// stamp is the last_updated generated for the content of a document
+type stamp struct {
+	sum [sha256.Size]byte
+	at  f.Timestamp
+}
+
+// New returns a Server publishing feeds at baseURL, requests are routed by
+// the path of baseURL
+func New(baseURL string, opts Options) (*Server, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if !base.IsAbs() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+
+	if opts.Convert != nil {
+		if opts.Version == "" {
+			opts.Version = opts.Convert.Version()
+		}
+		if opts.Version != opts.Convert.Version() {
+			return nil, fmt.Errorf("%w: %s and %s", ErrVersionMismatch, opts.Version, opts.Convert.Version())
+		}
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+	if opts.TTL == 0 {
+		opts.TTL = DefaultTTL
+	}
+	if len(opts.Languages) == 0 {
+		opts.Languages = []language.Tag{language.English}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	langs := make([]f.Language, 0, len(opts.Languages))
+	for _, t := range opts.Languages {
+		langs = append(langs, f.Language{Tag: t})
+	}
+	return &Server{base: base, opts: opts, langs: langs, stamps: make(map[string]stamp)}, nil
+}
+
+// Handle registers the provider of feed, documents have a TTL of ttl seconds
+// or Options.TTL when negative
+func (s *Server) Handle(name string, ttl int, p Provider) {
+	if ttl < 0 {
+		ttl = s.opts.TTL
+	}
+	if s.opts.Convert != nil {
+		p = s.opts.Convert.Provider(name, p)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.feeds {
+		if s.feeds[i].name == name {
+			s.feeds[i] = feed{name, ttl, p}
+			return
+		}
+	}
+	s.feeds = append(s.feeds, feed{name, ttl, p})
+}
+
+// AddVersion lists the gbfs.json of another version in gbfs_versions.json,
+// which is served once a version other than Options.Version is added
+func (s *Server) AddVersion(v, gbfsURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.versions {
+		if s.versions[i].Version == v {
+			s.versions[i].URL = gbfsURL
+			return
+		}
+	}
+	s.versions = append(s.versions, version{v, gbfsURL})
+}
+
+// URL returns the URL of feed in lang, gbfs.json and gbfs_versions.json are
+// not per language
+func (s *Server) URL(lang f.Language, name string) string {
+	switch name {
+	case "gbfs", "gbfs_versions":
+		return s.base.String() + "/" + name + ".json"
+	}
+	return s.base.String() + "/" + lang.String() + "/" + name + ".json"
+}
+
+// ServeHTTP satisfies the http.Handler interface
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the base path matches whole segments, /gbfs does not serve /gbfsx
+	rel := strings.TrimPrefix(r.URL.Path, s.base.Path)
+	if s.base.Path != "" && (len(rel) == len(r.URL.Path) || rel != "" && rel[0] != '/') {
+		http.NotFound(w, r)
+		return
+	}
+
+	var (
+		doc any
+		ttl int
+		err error
+	)
+	switch parts := strings.Split(strings.Trim(rel, "/"), "/"); {
+	case len(parts) == 1 && parts[0] == "gbfs.json":
+		doc, err = s.discovery(r.Context())
+		ttl = s.opts.TTL
+	case len(parts) == 1 && parts[0] == "gbfs_versions.json":
+		doc, ttl = s.gbfsVersions()
+	case len(parts) == 2 && strings.HasSuffix(parts[1], ".json"):
+		doc, ttl, err = s.feed(r.Context(), parts[0], strings.TrimSuffix(parts[1], ".json"))
+	default:
+		err = gbfs.ErrNoFeed
+	}
+	if err == nil && doc == nil {
+		err = gbfs.ErrNoFeed
+	}
+	if errors.Is(err, gbfs.ErrNoFeed) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body, lastUpdated, err := s.render(r.URL.Path, doc, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.write(w, r, body, ttl, lastUpdated)
+}
+
+// feed returns the document of name in lang
+func (s *Server) feed(ctx context.Context, lang, name string) (any, int, error) {
+	l, ok := s.language(lang)
+	if !ok {
+		return nil, 0, gbfs.ErrNoFeed
+	}
+
+	s.mu.RLock()
+	var fd feed
+	for _, registered := range s.feeds {
+		if registered.name == name {
+			fd = registered
+		}
+	}
+	s.mu.RUnlock()
+	if fd.provider == nil {
+		return nil, 0, gbfs.ErrNoFeed
+	}
+
+	doc, err := fd.provider(ctx, l)
+	return doc, fd.ttl, err
+}
+
+// language returns the served language tagged s
+func (s *Server) language(tag string) (f.Language, bool) {
+	for _, l := range s.langs {
+		if l.String() == tag {
+			return l, true
+		}
+	}
+	return f.Language{}, false
+}
+
+// discovery returns gbfs.json, listing the feeds per language as of 2.x
+// and converted by Options.Convert
+func (s *Server) discovery(ctx context.Context) (any, error) {
+	doc := s.feedLists()
+	if s.opts.Convert == nil {
+		return doc, nil
+	}
+	return s.opts.Convert.Provider("gbfs", Static(doc))(ctx, s.langs[0])
+}
+
+// feedLists returns the 2.x gbfs.json listing the feeds handled
+func (s *Server) feedLists() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type entry struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	data := make(map[string]map[string][]entry, len(s.langs))
+	for _, l := range s.langs {
+		feeds := make([]entry, 0, len(s.feeds)+1)
+		if len(s.otherVersions()) > 0 {
+			feeds = append(feeds, entry{"gbfs_versions", s.URL(l, "gbfs_versions")})
+		}
+		for _, fd := range s.feeds {
+			feeds = append(feeds, entry{fd.name, s.URL(l, fd.name)})
+		}
+		data[l.String()] = map[string][]entry{"feeds": feeds}
+	}
+	return struct {
+		Version string `json:"version"`
+		Data    any    `json:"data"`
+	}{gbfs.DefaultVersion, data}
+}
+
+// gbfsVersions returns the data of gbfs_versions.json, nil unless other
+// versions were added
+func (s *Server) gbfsVersions() (any, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	others := s.otherVersions()
+	if len(others) == 0 {
+		return nil, 0
+	}
+	versions := append([]version{{s.opts.Version, s.URL(f.Language{}, "gbfs")}}, others...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	type data struct {
+		Versions []version `json:"versions"`
+	}
+	return struct {
+		Data data `json:"data"`
+	}{data{versions}}, s.opts.TTL
+}
+
+// otherVersions returns the versions added other than Options.Version, s.mu
+// must be held
+func (s *Server) otherVersions() []version {
+	var others []version
+	for _, v := range s.versions {
+		if v.Version != s.opts.Version {
+			others = append(others, v)
+		}
+	}
+	return others
+}
+
+// render encodes the doc served at path with its last_updated, ttl and
+// version filled in
+func (s *Server) render(path string, doc any, ttl int) ([]byte, time.Time, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var lastUpdated f.Timestamp
+	if lu, ok := fields["last_updated"]; ok && string(lu) != "null" {
+		if err := json.Unmarshal(lu, &lastUpdated); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	if lastUpdated.IsZero() {
+		lastUpdated = s.stamp(path, raw)
+	}
+
+	for k, v := range map[string]any{
+		"last_updated": lastUpdated,
+		"ttl":          ttl,
+		"version":      s.opts.Version,
+	} {
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	body, err := json.Marshal(fields)
+	return body, lastUpdated.Time, err
+}
+
+// stamp returns the last_updated of the document at path encoded as raw, the
+// time its content last changed so the body and ETag are stable meanwhile
+func (s *Server) stamp(path string, raw []byte) f.Timestamp {
+	sum := sha256.Sum256(raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.stamps[path]; ok && st.sum == sum {
+		return st.at
+	}
+
+	enc := f.UnixSeconds
+	if strings.HasPrefix(s.opts.Version, "3.") {
+		enc = f.RFC3339
+	}
+	st := stamp{sum, f.NewTimestamp(s.opts.Now(), enc)}
+	s.stamps[path] = st
+	return st.at
+}
+
+// write responds with body, honouring conditional requests and gzip. The
+// ETag is weak as the identity and gzip encodings of body share it
+func (s *Server) write(w http.ResponseWriter, r *http.Request, body []byte, ttl int, lastUpdated time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", ttl))
+	h.Set("ETag", etag)
+	h.Set("Last-Modified", lastUpdated.UTC().Format(http.TimeFormat))
+	h.Add("Vary", "Accept-Encoding")
+
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatch(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if acceptsGzip(r) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err == nil {
+			h.Set("Content-Encoding", "gzip")
+			body = buf.Bytes()
+		}
+	}
+	h.Set("Content-Length", fmt.Sprint(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// etagMatch reports whether the If-None-Match header matches etag, weakly
+// as If-None-Match does
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, m := range strings.Split(header, ",") {
+		m = strings.TrimPrefix(strings.TrimSpace(m), "W/")
+		if m == etag || m == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether the client accepts gzip encoded responses
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc = strings.TrimSpace(enc)
+		if i := strings.Index(enc, ";"); i >= 0 {
+			if strings.TrimSpace(enc[i+1:]) == "q=0" {
+				continue
+			}
+			enc = strings.TrimSpace(enc[:i])
+		}
+		if enc == "gzip" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+var now = time.Unix(1609866247, 0)
+
+// serve starts a Server at <test server>/gbfs
+func serve(t *testing.T, opts Options) (*Server, *httptest.Server) {
+	t.Helper()
+
+	var s *Server
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	if opts.Now == nil {
+		opts.Now = func() time.Time { return now }
+	}
+	var err error
+	s, err = New(srv.URL+"/gbfs/", opts)
+	require.NoError(t, err)
+
+	var si gbfs.SystemInformation
+	si.Data.SystemID = "city"
+	si.Data.Name = "City Bikes"
+	si.Data.Timezone = f.Timezone{Location: time.UTC}
+	s.Handle("system_information", 3600, func(_ context.Context, l f.Language) (any, error) {
+		doc := si
+		doc.Data.Language = l
+		return &doc, nil
+	})
+
+	var ss gbfs.StationStatus
+	ss.LastUpdated = f.NewTimestamp(now.Add(-time.Minute), f.UnixSeconds)
+	ss.Data.Stations = []gbfs.StationState{{StationID: "1", NumBikesAvailable: 3, IsInstalled: true}}
+	s.Handle("station_status", -1, Static(&ss))
+	return s, srv
+}
+
+func get(t *testing.T, url string, header http.Header) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := (&http.Transport{DisableCompression: true}).RoundTrip(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+// TestServer ...
+func TestServer(t *testing.T) {
+	_, srv := serve(t, Options{Languages: []language.Tag{language.English, language.French}})
+
+	g, err := gbfs.NewClient(srv.URL+"/gbfs/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+	assert.Equal(t, now.Unix(), g.LastUpdated.Unix())
+	assert.EqualValues(t, DefaultTTL, g.TTL)
+	assert.Equal(t, DefaultVersion, g.Version)
+
+	fr, err := g.MatchLanguage(language.CanadianFrench)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"system_information", "station_status"}, g.Feeds(fr).Names())
+	assert.Equal(t, srv.URL+"/gbfs/fr/station_status.json", g.Feeds(fr).URL("station_status").String())
+
+	sys, err := g.FetchAll(context.Background(), fr, gbfs.FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, sys.Err())
+
+	assert.Equal(t, "fr", sys.SystemInformation.Data.Language.String())
+	assert.EqualValues(t, 3600, sys.SystemInformation.TTL)
+	assert.Equal(t, now.Unix(), sys.SystemInformation.LastUpdated.Unix())
+
+	// the last_updated of the provider is kept
+	assert.Equal(t, now.Add(-time.Minute).Unix(), sys.StationStatus.LastUpdated.Unix())
+	assert.EqualValues(t, DefaultTTL, sys.StationStatus.TTL)
+	assert.EqualValues(t, 3, sys.StationStatus.Data.Stations[0].NumBikesAvailable)
+}
+
+// TestServerCaching ...
+func TestServerCaching(t *testing.T) {
+	_, srv := serve(t, Options{})
+	url := srv.URL + "/gbfs/en/system_information.json"
+
+	res := get(t, url, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "public, max-age=3600", res.Header.Get("Cache-Control"))
+	assert.Equal(t, now.UTC().Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+	plain, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	// weak, the gzip encoding shares it
+	etag := res.Header.Get("ETag")
+	require.True(t, strings.HasPrefix(etag, `W/"`), etag)
+
+	res = get(t, url, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	res = get(t, url, http.Header{"If-None-Match": {`"other", ` + strings.TrimPrefix(etag, "W/")}})
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	res = get(t, url, http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = get(t, url, http.Header{"Accept-Encoding": {"br, gzip"}})
+	require.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+	assert.Equal(t, etag, res.Header.Get("ETag"))
+	zr, err := gzip.NewReader(res.Body)
+	require.NoError(t, err)
+	unzipped, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, plain, unzipped)
+
+	res = get(t, url, http.Header{"Accept-Encoding": {"gzip;q=0"}})
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+}
+
+// TestServerLastUpdated checks the generated last_updated, and so the ETag,
+// only changes with the content
+func TestServerLastUpdated(t *testing.T) {
+	var mu sync.Mutex
+	clock := now
+	s, srv := serve(t, Options{Now: func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}})
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(d)
+	}
+	url := srv.URL + "/gbfs/en/system_regions.json"
+
+	var sr gbfs.SystemRegions
+	sr.Data.Regions = []gbfs.Region{{RegionID: "1", Name: "North"}}
+	s.Handle("system_regions", -1, Static(&sr))
+
+	res := get(t, url, nil)
+	etag := res.Header.Get("ETag")
+	assert.Equal(t, now.UTC().Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+
+	advance(time.Minute)
+	res = get(t, url, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	assert.Equal(t, now.UTC().Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+
+	var changed gbfs.SystemRegions
+	changed.Data.Regions = []gbfs.Region{{RegionID: "1", Name: "South"}}
+	s.Handle("system_regions", -1, Static(&changed))
+
+	res = get(t, url, http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEqual(t, etag, res.Header.Get("ETag"))
+	assert.Equal(t, now.Add(time.Minute).UTC().Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+}
+
+// TestServerVersions ...
+func TestServerVersions(t *testing.T) {
+	s, srv := serve(t, Options{})
+	url := srv.URL + "/gbfs/gbfs_versions.json"
+
+	assert.Equal(t, http.StatusNotFound, get(t, url, nil).StatusCode)
+
+	// the served version is not another version
+	s.AddVersion(DefaultVersion, "https://example.com/ignored.json")
+	assert.Equal(t, http.StatusNotFound, get(t, url, nil).StatusCode)
+
+	s.AddVersion("1.1", "https://example.com/v1/gbfs.json")
+
+	g, err := gbfs.NewClient(srv.URL+"/gbfs/gbfs.json", srv.Client()).GBFS()
+	require.NoError(t, err)
+	en, err := g.MatchLanguage()
+	require.NoError(t, err)
+	assert.Equal(t, url, g.Feeds(en).URL("gbfs_versions").String())
+
+	v, err := g.Versions(en)
+	require.NoError(t, err)
+	require.Len(t, v.Data.Versions, 2)
+	assert.Equal(t, "1.1", v.Data.Versions[0].Version)
+	assert.Equal(t, "https://example.com/v1/gbfs.json", v.Data.Versions[0].URL.String())
+	assert.Equal(t, DefaultVersion, v.Data.Versions[1].Version)
+	assert.Equal(t, srv.URL+"/gbfs/gbfs.json", v.Data.Versions[1].URL.String())
+}
+
+// TestServerErrors ...
+func TestServerErrors(t *testing.T) {
+	s, srv := serve(t, Options{})
+	s.Handle("free_bike_status", -1, func(context.Context, f.Language) (any, error) {
+		return nil, gbfs.ErrNoFeed
+	})
+	s.Handle("system_alerts", -1, func(context.Context, f.Language) (any, error) {
+		return nil, errors.New("unavailable")
+	})
+
+	for path, code := range map[string]int{
+		"/gbfs/en/free_bike_status.json": http.StatusNotFound,
+		"/gbfs/en/system_alerts.json":    http.StatusInternalServerError,
+		"/gbfs/fr/station_status.json":   http.StatusNotFound,
+		"/gbfs/en/system_hours.json":     http.StatusNotFound,
+		"/other/gbfs.json":               http.StatusNotFound,
+		"/gbfsx/gbfs.json":               http.StatusNotFound,
+		"/gbfs/en/station_status":        http.StatusNotFound,
+	} {
+		assert.Equal(t, code, get(t, srv.URL+path, nil).StatusCode, path)
+	}
+
+	res, err := srv.Client().Post(srv.URL+"/gbfs/gbfs.json", "application/json", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	_, err = New("/relative", Options{})
+	assert.ErrorIs(t, err, ErrInvalidBaseURL)
+}
@@ -0,0 +1,509 @@
+// Package sim simulates a bike share system, emitting evolving GBFS documents
+// at each tick of a simulated clock
+//
+// Riders take bikes from stations following a daily demand profile and ride
+// them to other stations, while free floating bikes are ridden around the
+// system. Stations may suffer outages and are periodically rebalanced
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+	"github.com/marz619/gbfs-go/gbfstest"
+)
+
+// Config of a Simulator, zero values are replaced by their default
+type Config struct {
+	// Stations in the system, 20 by default
+	Stations int
+	// Capacity of each station, 15 by default
+	Capacity int
+	// Fill is the initial fraction of docks holding a bike, 0.5 by default.
+	// Stations start empty when negative
+	Fill float64
+	// FreeBikes is the number of free floating bikes
+	FreeBikes int
+	// Radius of the system around Center in meters, 3km by default
+	Radius float64
+	// Center of the system, gbfstest.Center by default
+	Center f.Point
+
+	// Demand is the mean number of trips per hour from a station, or of a
+	// free floating bike, at the peak of Profile. 2 by default
+	Demand float64
+	// Profile scales Demand by the time of day, DailyProfile by default
+	Profile func(time.Time) float64
+	// Speed of riders in m/s, 4 by default
+	Speed float64
+
+	// RebalanceEvery is the interval between rebalancing, disabled when zero
+	RebalanceEvery time.Duration
+	// OutageRate is the probability per hour of a station going offline
+	OutageRate float64
+	// OutageDuration is how long outages last, 1h by default
+	OutageDuration time.Duration
+
+	// Location of the system, its name is the timezone of system_information
+	// and the time of day of Profile. UTC by default
+	Location *time.Location
+	// Start of the simulated clock, the current time by default
+	Start time.Time
+	// Tick is the simulated time between steps, 1m by default
+	Tick time.Duration
+	// Seed of the random source
+	Seed int64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Stations == 0 {
+		c.Stations = 20
+	}
+	if c.Capacity == 0 {
+		c.Capacity = 15
+	}
+	switch {
+	case c.Fill == 0:
+		c.Fill = 0.5
+	case c.Fill < 0:
+		c.Fill = 0
+	}
+	if c.Radius == 0 {
+		c.Radius = 3000
+	}
+	if c.Center == (f.Point{}) {
+		c.Center = gbfstest.Center
+	}
+	if c.Demand == 0 {
+		c.Demand = 2
+	}
+	if c.Profile == nil {
+		c.Profile = DailyProfile
+	}
+	if c.Speed == 0 {
+		c.Speed = 4
+	}
+	if c.OutageDuration == 0 {
+		c.OutageDuration = time.Hour
+	}
+	if c.Location == nil {
+		c.Location = time.UTC
+	}
+	if c.Start.IsZero() {
+		c.Start = time.Now()
+	}
+	c.Start = c.Start.In(c.Location)
+	if c.Tick == 0 {
+		c.Tick = time.Minute
+	}
+	return c
+}
+
+// DailyProfile peaks at the morning and evening commutes, in the location of t
+func DailyProfile(t time.Time) float64 {
+	h := float64(t.Hour()) + float64(t.Minute())/60
+	peak := func(at float64) float64 { return math.Exp(-(h - at) * (h - at) / 2) }
+	return math.Min(1, 0.1+peak(8)+peak(17.5))
+}
+
+// Stats counts the events simulated
+type Stats struct {
+	Trips      int // trips started
+	Rerouted   int // trips rerouted from a full or offline station
+	Rebalanced int // bikes moved by rebalancing
+	Outages    int // station outages started
+}
+
+type station struct {
+	id           f.ID
+	point        f.Point
+	bikes        int
+	outageUntil  time.Time
+	lastReported time.Time
+}
+
+func (s *station) online(now time.Time) bool {
+	return !now.Before(s.outageUntil)
+}
+
+type bike struct {
+	id       f.ID
+	point    f.Point
+	riding   bool
+	arriving time.Time
+}
+
+// trip of a docked bike
+type trip struct {
+	to       int
+	point    f.Point // destination
+	arriving time.Time
+}
+
+// Simulator of a bike share system, it is not safe for concurrent use
+type Simulator struct {
+	cfg      Config
+	rand     *rand.Rand
+	now      time.Time
+	stations []*station
+	bikes    []*bike
+	trips    []trip
+	lastBal  time.Time
+	stats    Stats
+}
+
+// New returns a Simulator at cfg.Start
+func New(cfg Config) *Simulator {
+	cfg = cfg.withDefaults()
+	s := &Simulator{
+		cfg:     cfg,
+		rand:    rand.New(rand.NewSource(cfg.Seed)),
+		now:     cfg.Start,
+		lastBal: cfg.Start,
+	}
+
+	for i := 0; i < cfg.Stations; i++ {
+		s.stations = append(s.stations, &station{
+			id:           f.ID(fmt.Sprintf("station-%d", i+1)),
+			point:        s.randomPoint(cfg.Center, cfg.Radius),
+			bikes:        int(math.Round(cfg.Fill * float64(cfg.Capacity))),
+			lastReported: cfg.Start,
+		})
+	}
+	for i := 0; i < cfg.FreeBikes; i++ {
+		s.bikes = append(s.bikes, &bike{
+			id:    f.ID(fmt.Sprintf("bike-%d", i+1)),
+			point: s.randomPoint(cfg.Center, cfg.Radius),
+		})
+	}
+	return s
+}
+
+// Now returns the simulated time
+func (s *Simulator) Now() time.Time {
+	return s.now
+}
+
+// Stats returns the events simulated so far
+func (s *Simulator) Stats() Stats {
+	return s.stats
+}
+
+// Bikes returns the number of bikes of the system, docked, in trips or free
+// floating
+func (s *Simulator) Bikes() int {
+	n := len(s.trips) + len(s.bikes)
+	for _, st := range s.stations {
+		n += st.bikes
+	}
+	return n
+}
+
+// Advance steps the simulation until d has elapsed
+func (s *Simulator) Advance(d time.Duration) {
+	end := s.now.Add(d)
+	for s.now.Before(end) {
+		s.Step()
+	}
+}
+
+// Step advances the simulation by one Tick
+func (s *Simulator) Step() {
+	s.now = s.now.Add(s.cfg.Tick)
+	hours := s.cfg.Tick.Hours()
+	rate := s.cfg.Demand * s.cfg.Profile(s.now) * hours
+
+	s.outages(hours)
+	s.arrivals()
+	s.departures(rate)
+	s.ride(rate)
+	if s.cfg.RebalanceEvery > 0 && s.now.Sub(s.lastBal) >= s.cfg.RebalanceEvery {
+		s.rebalance()
+		s.lastBal = s.now
+	}
+
+	for _, st := range s.stations {
+		if st.online(s.now) {
+			st.lastReported = s.now
+		}
+	}
+}
+
+// outages takes stations offline
+func (s *Simulator) outages(hours float64) {
+	if s.cfg.OutageRate <= 0 {
+		return
+	}
+	for _, st := range s.stations {
+		if st.online(s.now) && s.rand.Float64() < s.cfg.OutageRate*hours {
+			st.outageUntil = s.now.Add(s.cfg.OutageDuration)
+			s.stats.Outages++
+		}
+	}
+}
+
+// arrivals docks the bikes of trips that arrived, rerouting them to the
+// nearest station with a free dock when needed
+func (s *Simulator) arrivals() {
+	riding := s.trips[:0]
+	for _, tr := range s.trips {
+		if tr.arriving.After(s.now) {
+			riding = append(riding, tr)
+			continue
+		}
+
+		st := s.stations[tr.to]
+		if st.online(s.now) && st.bikes < s.cfg.Capacity {
+			st.bikes++
+			continue
+		}
+
+		to, ok := s.nearestDock(tr.point, tr.to)
+		if !ok {
+			// wait for a dock
+			tr.arriving = s.now.Add(s.cfg.Tick)
+			riding = append(riding, tr)
+			continue
+		}
+		s.stats.Rerouted++
+		riding = append(riding, s.trip(tr.point, to))
+	}
+	s.trips = riding
+}
+
+// departures starts trips from stations
+func (s *Simulator) departures(rate float64) {
+	if len(s.stations) < 2 {
+		return
+	}
+	for i, st := range s.stations {
+		if !st.online(s.now) {
+			continue
+		}
+		for n := s.poisson(rate); n > 0 && st.bikes > 0; n-- {
+			to := s.rand.Intn(len(s.stations) - 1)
+			if to >= i {
+				to++
+			}
+			st.bikes--
+			s.trips = append(s.trips, s.trip(st.point, to))
+			s.stats.Trips++
+		}
+	}
+}
+
+// ride moves free floating bikes
+func (s *Simulator) ride(rate float64) {
+	for _, b := range s.bikes {
+		if b.riding {
+			if !b.arriving.After(s.now) {
+				b.riding = false
+			}
+			continue
+		}
+		if s.rand.Float64() >= rate {
+			continue
+		}
+
+		from := b.point
+		b.point = s.randomPoint(s.cfg.Center, s.cfg.Radius)
+		b.riding, b.arriving = true, s.now.Add(s.duration(from.Distance(b.point)))
+		s.stats.Trips++
+	}
+}
+
+// rebalance moves bikes from the fullest stations to the emptiest
+func (s *Simulator) rebalance() {
+	online := make([]*station, 0, len(s.stations))
+	for _, st := range s.stations {
+		if st.online(s.now) {
+			online = append(online, st)
+		}
+	}
+	sort.Slice(online, func(i, j int) bool { return online[i].bikes > online[j].bikes })
+
+	target := int(math.Round(s.cfg.Fill * float64(s.cfg.Capacity)))
+	for i, j := 0, len(online)-1; i < j; {
+		full, empty := online[i], online[j]
+		n := full.bikes - target
+		if m := target - empty.bikes; m < n {
+			n = m
+		}
+		if n <= 0 {
+			break
+		}
+		full.bikes -= n
+		empty.bikes += n
+		s.stats.Rebalanced += n
+		if full.bikes <= target {
+			i++
+		}
+		if empty.bikes >= target {
+			j--
+		}
+	}
+}
+
+// trip returns a trip from p to the station to
+func (s *Simulator) trip(p f.Point, to int) trip {
+	dest := s.stations[to].point
+	return trip{to: to, point: dest, arriving: s.now.Add(s.duration(p.Distance(dest)))}
+}
+
+// duration returns the time to ride meters, at least one Tick
+func (s *Simulator) duration(meters float64) time.Duration {
+	d := time.Duration(meters / s.cfg.Speed * float64(time.Second))
+	if d < s.cfg.Tick {
+		d = s.cfg.Tick
+	}
+	return d
+}
+
+// nearestDock returns the station nearest to p with a free dock, other than
+// the station skip
+func (s *Simulator) nearestDock(p f.Point, skip int) (int, bool) {
+	best, dist := -1, math.Inf(1)
+	for i, st := range s.stations {
+		if i == skip || !st.online(s.now) || st.bikes >= s.cfg.Capacity {
+			continue
+		}
+		if d := p.Distance(st.point); d < dist {
+			best, dist = i, d
+		}
+	}
+	return best, best >= 0
+}
+
+// randomPoint returns a point uniformly distributed within radius of c
+func (s *Simulator) randomPoint(c f.Point, radius float64) f.Point {
+	return c.Destination(360*s.rand.Float64(), radius*math.Sqrt(s.rand.Float64()))
+}
+
+// poisson samples a Poisson distribution of mean lambda
+func (s *Simulator) poisson(lambda float64) int {
+	l, k, p := math.Exp(-lambda), 0, 1.0
+	for {
+		p *= s.rand.Float64()
+		if p <= l {
+			return k
+		}
+		k++
+	}
+}
+
+// output returns the Output of documents at the simulated time
+func (s *Simulator) output() gbfs.Output {
+	return gbfs.Output{
+		LastUpdated: f.NewTimestamp(s.now, f.UnixSeconds),
+		TTL:         f.NonNegativeInt(s.cfg.Tick / time.Second),
+		Version:     gbfstest.Version,
+	}
+}
+
+// StationInformation returns the stations of the system
+func (s *Simulator) StationInformation() gbfs.StationInformation {
+	var si gbfs.StationInformation
+	si.Output = s.output()
+	si.Data.Stations = make([]gbfs.Station, 0, len(s.stations))
+	for i, st := range s.stations {
+		si.Data.Stations = append(si.Data.Stations, gbfs.Station{
+			StationID: st.id,
+			Name:      fmt.Sprintf("Station %d", i+1),
+			Latitutde: st.point.Lat,
+			Longitude: st.point.Lon,
+			Capacity:  f.NewNonNegativeInt(s.cfg.Capacity),
+		})
+	}
+	return si
+}
+
+// StationStatus returns the status of the stations at the simulated time
+func (s *Simulator) StationStatus() gbfs.StationStatus {
+	var ss gbfs.StationStatus
+	ss.Output = s.output()
+	ss.Data.Stations = make([]gbfs.StationState, 0, len(s.stations))
+	for _, st := range s.stations {
+		online := st.online(s.now)
+		ss.Data.Stations = append(ss.Data.Stations, gbfs.StationState{
+			StationID:         st.id,
+			NumBikesAvailable: f.NonNegativeInt(st.bikes),
+			NumDocksAvailable: f.NonNegativeInt(s.cfg.Capacity - st.bikes),
+			IsInstalled:       true,
+			IsRenting:         online,
+			IsReturning:       online,
+			LastReported:      f.NewTimestamp(st.lastReported, f.UnixSeconds),
+		})
+	}
+	return ss
+}
+
+// FreeBikeStatus returns the free floating bikes not being ridden
+func (s *Simulator) FreeBikeStatus() gbfs.FreeBikeStatus {
+	var fb gbfs.FreeBikeStatus
+	fb.Output = s.output()
+	fb.Data.Bikes = make([]gbfs.Bike, 0, len(s.bikes))
+	for _, b := range s.bikes {
+		if b.riding {
+			continue
+		}
+		fb.Data.Bikes = append(fb.Data.Bikes, gbfs.Bike{
+			BikeID:    b.id,
+			Latitude:  b.point.Lat,
+			Longitude: b.point.Lon,
+		})
+	}
+	return fb
+}
+
+// System returns the simulated system for a gbfstest.Server
+func (s *Simulator) System() gbfstest.System {
+	sys := gbfstest.NewSystem(0, 0)
+	sys.Information.Data.SystemID = "sim"
+	sys.Information.Data.Name = "Simulated System"
+	sys.Information.Data.Timezone = f.Timezone{Location: s.cfg.Location}
+	sys.TTL = int(s.cfg.Tick / time.Second)
+	sys.LastUpdated = s.now
+	sys.Stations = s.StationInformation()
+	sys.Status = s.StationStatus()
+	sys.Bikes = s.FreeBikeStatus()
+	return sys
+}
+
+// Publish serves the simulated system with srv
+func (s *Simulator) Publish(srv *gbfstest.Server) {
+	sys := s.System()
+	srv.Update(func(dst *gbfstest.System) { *dst = sys })
+}
+
+// Run steps the simulation every Tick divided by speed until ctx is done,
+// calling fn after each step. A speed of 60 simulates an hour per minute, steps
+// are at most every nanosecond
+func (s *Simulator) Run(ctx context.Context, speed float64, fn func(*Simulator)) error {
+	if speed <= 0 {
+		speed = 1
+	}
+	interval := time.Duration(float64(s.cfg.Tick) / speed)
+	if interval < 1 {
+		interval = 1
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		s.Step()
+		if fn != nil {
+			fn(s)
+		}
+	}
+}
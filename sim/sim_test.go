@@ -0,0 +1,167 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+	"github.com/marz619/gbfs-go/gbfstest"
+)
+
+var start = time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+
+// TestSimulatorWeek ...
+func TestSimulatorWeek(t *testing.T) {
+	s := New(Config{
+		Stations:       30,
+		FreeBikes:      20,
+		RebalanceEvery: 6 * time.Hour,
+		OutageRate:     0.01,
+		Start:          start,
+		Seed:           1,
+	})
+	bikes := s.Bikes()
+	assert.Equal(t, 30*8+20, bikes)
+
+	began := time.Now()
+	online := make(map[bool]int)
+	for d := 0; d < 7*24; d++ {
+		s.Advance(time.Hour)
+
+		// bikes are conserved and documents are consistent
+		require.Equal(t, bikes, s.Bikes())
+		ss := s.StationStatus()
+		for _, st := range ss.Data.Stations {
+			require.EqualValues(t, 15, st.NumBikesAvailable+st.NumDocksAvailable)
+			online[st.IsRenting]++
+		}
+	}
+	assert.Less(t, time.Since(began), 10*time.Second)
+	assert.Equal(t, start.Add(7*24*time.Hour), s.Now())
+
+	stats := s.Stats()
+	assert.Greater(t, stats.Trips, 1000)
+	assert.Greater(t, stats.Rebalanced, 0)
+	assert.Greater(t, stats.Outages, 0)
+	assert.Greater(t, online[true], online[false])
+	assert.Greater(t, online[false], 0)
+}
+
+// TestSimulatorDeterministic ...
+func TestSimulatorDeterministic(t *testing.T) {
+	run := func(seed int64) gbfs.StationStatus {
+		s := New(Config{Start: start, Seed: seed, FreeBikes: 5})
+		s.Advance(24 * time.Hour)
+		return s.StationStatus()
+	}
+	assert.Equal(t, run(1), run(1))
+	assert.NotEqual(t, run(1), run(2))
+}
+
+// TestSimulatorDemand ...
+func TestSimulatorDemand(t *testing.T) {
+	trips := func(from time.Time) int {
+		s := New(Config{Start: from, Seed: 1})
+		s.Advance(time.Hour)
+		return s.Stats().Trips
+	}
+	// the morning commute is busier than the night
+	assert.Greater(t, trips(start.Add(7*time.Hour+30*time.Minute)), trips(start.Add(2*time.Hour)))
+}
+
+// TestSimulatorDocuments ...
+func TestSimulatorDocuments(t *testing.T) {
+	s := New(Config{Stations: 3, FreeBikes: 4, Start: start})
+	s.Advance(time.Hour)
+
+	for _, doc := range []any{s.StationInformation(), s.StationStatus(), s.FreeBikeStatus()} {
+		raw, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		var out gbfs.Output
+		require.NoError(t, json.Unmarshal(raw, &out))
+		assert.Equal(t, s.Now().Unix(), out.LastUpdated.Unix())
+		assert.EqualValues(t, 60, out.TTL)
+	}
+
+	// bikes being ridden are not listed
+	assert.LessOrEqual(t, len(s.FreeBikeStatus().Data.Bikes), 4)
+}
+
+// TestSimulatorConfig ...
+func TestSimulatorConfig(t *testing.T) {
+	t.Run("empty stations", func(t *testing.T) {
+		s := New(Config{Stations: 3, Fill: -1, FreeBikes: 2, Start: start})
+		assert.Equal(t, 2, s.Bikes())
+		for _, st := range s.StationStatus().Data.Stations {
+			assert.EqualValues(t, 0, st.NumBikesAvailable)
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		toronto, err := time.LoadLocation("America/Toronto")
+		require.NoError(t, err)
+
+		s := New(Config{Location: toronto, Start: start})
+		assert.Equal(t, toronto, s.Now().Location())
+		assert.Equal(t, start.Unix(), s.Now().Unix())
+		assert.Equal(t, "America/Toronto", s.System().Information.Data.Timezone.String())
+
+		assert.Equal(t, "UTC", New(Config{}).System().Information.Data.Timezone.String())
+	})
+
+	t.Run("speed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// the interval rounds to zero
+		s := New(Config{Stations: 1, Start: start})
+		err := s.Run(ctx, math.MaxFloat64, func(*Simulator) { cancel() })
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// TestSimulatorRun serves an accelerated simulation with a gbfstest.Server
+func TestSimulatorRun(t *testing.T) {
+	s := New(Config{Stations: 5, Start: start, Seed: 1})
+	srv := gbfstest.NewServer(s.System())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	steps := make(chan time.Time, 1)
+	go func() {
+		_ = s.Run(ctx, 6000, func(s *Simulator) {
+			s.Publish(srv)
+			select {
+			case steps <- s.Now():
+			default:
+			}
+		})
+	}()
+
+	var at time.Time
+	for i := 0; i < 3; i++ {
+		select {
+		case at = <-steps:
+		case <-time.After(5 * time.Second):
+			t.Fatal("simulation is not running")
+		}
+	}
+
+	g, err := srv.NewClient().GBFS()
+	require.NoError(t, err)
+	l, err := g.MatchLanguage()
+	require.NoError(t, err)
+	ss, err := g.StationStatus(l)
+	require.NoError(t, err)
+	assert.Len(t, ss.Data.Stations, 5)
+	assert.False(t, ss.LastUpdated.Before(at))
+}
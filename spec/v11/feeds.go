@@ -0,0 +1,385 @@
+// Code generated by gbfsgen from the GBFS v1.1 JSON Schemas. DO NOT EDIT.
+
+// Package v11 contains the feed types of GBFS v1.1
+//
+// The types of the gbfs package are hand-written, these are generated from
+// the schemas of the version and follow them field for field
+package v11
+
+import (
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// FreeBikeStatus https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#free_bike_statusjson
+//
+// Describes the vehicles that are available for rent (as of v2.0).
+type FreeBikeStatus struct {
+	gbfs.Output
+	// Array that contains one object per bike as defined below.
+	Data struct {
+		Bikes []Bike `json:"bikes"`
+	} `json:"data"`
+}
+
+// Bike is an entry of the bikes of FreeBikeStatus
+type Bike struct {
+	// Unique identifier of a vehicle.
+	BikeID f.ID `json:"bike_id"`
+	// The latitude of the vehicle.
+	Lat f.Latitude `json:"lat"`
+	// The longitude of the vehicle.
+	Lon f.Longitude `json:"lon"`
+	// Is the vehicle currently reserved?
+	IsReserved f.Boolean `json:"is_reserved"`
+	// Is the vehicle currently disabled (broken)?
+	IsDisabled f.Boolean `json:"is_disabled"`
+	// Contains rental URIs for Android, iOS, and web in the android, ios, and
+	// web fields (added in v1.1).
+	RentalURIs *struct {
+		// URI that can be passed to an Android app with an intent (added in
+		// v1.1).
+		Android *f.URI `json:"android,omitempty"`
+		// URI that can be used on iOS to launch the rental app (added in v1.1).
+		IOS *f.URI `json:"ios,omitempty"`
+		// URL that can be used by a web browser to show more information (added
+		// in v1.1).
+		Web *f.URI `json:"web,omitempty"`
+	} `json:"rental_uris,omitempty"`
+}
+
+// GBFS https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#gbfsjson
+//
+// Auto-discovery file that links to all of the other files published by the
+// system.
+type GBFS struct {
+	gbfs.Output
+	// Response data in the form of name:value pairs.
+	Data map[string]struct {
+		// An array of all of the feeds that are published by the auto-discovery
+		// file.
+		Feeds []Feed `json:"feeds"`
+	} `json:"data"`
+}
+
+// Feed is an entry of the feeds of GBFS
+//
+// An array of all of the feeds that are published by the auto-discovery file.
+type Feed struct {
+	// Key identifying the type of feed this is.
+	Name string `json:"name"`
+	// URL for the feed.
+	URL f.URL `json:"url"`
+}
+
+// GBFSVersions https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#gbfs_versionsjson-added-in-v11
+//
+// Lists all feed endpoints published according to versions of the GBFS
+// documentation.
+type GBFSVersions struct {
+	gbfs.Output
+	// Response data in the form of name:value pairs.
+	Data struct {
+		Versions []Version `json:"versions"`
+	} `json:"data"`
+}
+
+// Version is an entry of the versions of GBFSVersions
+type Version struct {
+	// The semantic version of the feed in the form X.Y
+	Version string `json:"version"`
+	// URL of the corresponding gbfs.json endpoint
+	URL f.URL `json:"url"`
+}
+
+// StationInformation https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#station_informationjson
+//
+// List of all stations, their capacities and locations. REQUIRED of systems
+// utilizing docks.
+type StationInformation struct {
+	gbfs.Output
+	// Array that contains one object per station as defined below.
+	Data struct {
+		Stations []Station `json:"stations"`
+	} `json:"data"`
+}
+
+// Station is an entry of the stations of StationInformation
+type Station struct {
+	// Identifier of a station.
+	StationID f.ID `json:"station_id"`
+	// Public name of the station.
+	Name string `json:"name"`
+	// Short name or other type of identifier.
+	ShortName string `json:"short_name,omitempty"`
+	// The latitude of station.
+	Lat f.Latitude `json:"lat"`
+	// The longitude of station.
+	Lon f.Longitude `json:"lon"`
+	// Address (street number and name) where station is located.
+	Address string `json:"address,omitempty"`
+	// Cross street or landmark where the station is located.
+	CrossStreet string `json:"cross_street,omitempty"`
+	// Identifier of the region where the station is located.
+	RegionID f.ID `json:"region_id,omitempty"`
+	// Postal code where station is located.
+	PostCode string `json:"post_code,omitempty"`
+	// Payment methods accepted at this station.
+	RentalMethods []f.RentalMethod `json:"rental_methods,omitempty"`
+	// Number of total docking points installed at this station, both available
+	// and unavailable.
+	Capacity *f.NonNegativeInt `json:"capacity,omitempty"`
+	// Contains rental URIs for Android, iOS, and web in the android, ios, and
+	// web fields (added in v1.1).
+	RentalURIs *struct {
+		// URI that can be passed to an Android app with an intent (added in
+		// v1.1).
+		Android *f.URI `json:"android,omitempty"`
+		// URI that can be used on iOS to launch the rental app (added in v1.1).
+		IOS *f.URI `json:"ios,omitempty"`
+		// URL that can be used by a web browser to show more information (added
+		// in v1.1).
+		Web *f.URI `json:"web,omitempty"`
+	} `json:"rental_uris,omitempty"`
+}
+
+// StationStatus https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#station_statusjson
+//
+// Describes the capacity and rental availability of the station
+type StationStatus struct {
+	gbfs.Output
+	// Array that contains one object per station as defined below.
+	Data struct {
+		Stations []StationStatusStation `json:"stations"`
+	} `json:"data"`
+}
+
+// StationStatusStation is an entry of the stations of StationStatus
+type StationStatusStation struct {
+	// Identifier of a station.
+	StationID f.ID `json:"station_id"`
+	// Number of vehicles of any type physically available for rental at the
+	// station.
+	NumBikesAvailable f.NonNegativeInt `json:"num_bikes_available"`
+	// Number of disabled vehicles of any type at the station.
+	NumBikesDisabled *f.NonNegativeInt `json:"num_bikes_disabled,omitempty"`
+	// Number of functional docks physically at the station.
+	NumDocksAvailable f.NonNegativeInt `json:"num_docks_available"`
+	// Number of empty but disabled docks at the station.
+	NumDocksDisabled *f.NonNegativeInt `json:"num_docks_disabled,omitempty"`
+	// Is the station currently on the street?
+	IsInstalled f.Boolean `json:"is_installed"`
+	// Is the station currently renting vehicles?
+	IsRenting f.Boolean `json:"is_renting"`
+	// Is the station accepting vehicle returns?
+	IsReturning f.Boolean `json:"is_returning"`
+	// The last time this station reported its status to the operator's backend
+	// in POSIX time.
+	LastReported f.Timestamp `json:"last_reported"`
+}
+
+// SystemAlerts https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_alertsjson
+//
+// Describes ad-hoc changes to the system.
+type SystemAlerts struct {
+	gbfs.Output
+	// Array of alert objects.
+	Data struct {
+		Alerts []Alert `json:"alerts"`
+	} `json:"data"`
+}
+
+// Alert is an entry of the alerts of SystemAlerts
+type Alert struct {
+	// Identifier for this alert.
+	AlertID f.ID `json:"alert_id"`
+	// Type of alert.
+	Type f.AlertType `json:"type"`
+	// Array of objects indicating when the alert is in effect.
+	Times []AlertTime `json:"times,omitempty"`
+	// Array of identifiers of the stations for which this alert applies.
+	StationIDs []f.ID `json:"station_ids,omitempty"`
+	// Array of identifiers of the regions for which this alert applies.
+	RegionIDs []f.ID `json:"region_ids,omitempty"`
+	// URL where the customer can learn more information about this alert.
+	URL *f.URL `json:"url,omitempty"`
+	// A short summary of this alert to be displayed to the customer.
+	Summary string `json:"summary"`
+	// Detailed description of the alert.
+	Description string `json:"description,omitempty"`
+	// Indicates the last time the info for the alert was updated.
+	LastUpdated *f.Timestamp `json:"last_updated,omitempty"`
+}
+
+// AlertTime is an entry of the times of Alert
+//
+// Array of objects indicating when the alert is in effect.
+type AlertTime struct {
+	// Start time of the alert.
+	Start f.Timestamp `json:"start"`
+	// End time of the alert.
+	End *f.Timestamp `json:"end,omitempty"`
+}
+
+// SystemCalendar https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_calendarjson
+//
+// Describes the operating calendar for a system.
+type SystemCalendar struct {
+	gbfs.Output
+	// Array of year objects describing the system operational calendar.
+	Data struct {
+		Calendars []Calendar `json:"calendars"`
+	} `json:"data"`
+}
+
+// Calendar is an entry of the calendars of SystemCalendar
+type Calendar struct {
+	// Starting month for the system operations.
+	StartMonth f.Month `json:"start_month"`
+	// Starting day for the system operations.
+	StartDay f.Day `json:"start_day"`
+	// Starting year for the system operations.
+	StartYear f.Year `json:"start_year,omitempty"`
+	// Ending month for the system operations.
+	EndMonth f.Month `json:"end_month"`
+	// Ending day for the system operations.
+	EndDay f.Day `json:"end_day"`
+	// Ending year for the system operations.
+	EndYear f.Year `json:"end_year,omitempty"`
+}
+
+// SystemHours https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_hoursjson
+//
+// Describes the system hours of operation.
+type SystemHours struct {
+	gbfs.Output
+	// Array of objects as defined below.
+	Data struct {
+		RentalHours []RentalHour `json:"rental_hours"`
+	} `json:"data"`
+}
+
+// RentalHour is an entry of the rental_hours of SystemHours
+type RentalHour struct {
+	// An array of member and nonmember value(s) indicating that this set of
+	// rental hours applies to either members or non-members only.
+	UserTypes []f.UserType `json:"user_types"`
+	// An array of abbreviations (first 3 letters) of English names of the days
+	// of the week for which this object applies.
+	Days []f.DayOfWeek `json:"days"`
+	// Start time for the hours of operation of the system.
+	StartTime f.Time `json:"start_time"`
+	// End time for the hours of operation of the system.
+	EndTime f.Time `json:"end_time"`
+}
+
+// SystemInformation https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_informationjson
+//
+// Details including system operator, system location, year implemented, URL,
+// contact info, time zone.
+type SystemInformation struct {
+	gbfs.Output
+	// Response data in the form of name:value pairs.
+	Data struct {
+		// Identifier for this bike share system. This should be globally unique
+		// (even between different systems).
+		SystemID f.ID `json:"system_id"`
+		// The language that will be used throughout the rest of the files. It
+		// must match the value in the gbfs.json file.
+		Language f.Language `json:"language"`
+		// Name of the system to be displayed to customers.
+		Name string `json:"name"`
+		// Optional abbreviation for a system.
+		ShortName string `json:"short_name,omitempty"`
+		// Name of the operator
+		Operator string `json:"operator,omitempty"`
+		// The URL of the bike share system.
+		URL *f.URL `json:"url,omitempty"`
+		// URL where a customer can purchase a membership.
+		PurchaseURL *f.URL `json:"purchase_url,omitempty"`
+		// Date that the system began operations.
+		StartDate *f.Date `json:"start_date,omitempty"`
+		// A single voice telephone number for the specified system that
+		// presents the telephone number as typical for the system's service
+		// area.
+		PhoneNumber f.PhoneNumber `json:"phone_number,omitempty"`
+		// Email address actively monitored by the operator's customer service
+		// department.
+		Email *f.Email `json:"email,omitempty"`
+		// A single contact email address for consumers of this feed to report
+		// technical issues (added in v1.1).
+		FeedContactEmail *f.Email `json:"feed_contact_email,omitempty"`
+		// The time zone where the system is located.
+		Timezone f.Timezone `json:"timezone"`
+		// A fully qualified URL of a page that defines the license terms for
+		// the GBFS data for this system.
+		LicenseURL *f.URL `json:"license_url,omitempty"`
+		// Contains rental app information in the android and ios JSON objects
+		// (added in v1.1).
+		RentalApps *struct {
+			Android *struct {
+				// URI where the rental app can be downloaded from.
+				StoreURI f.URI `json:"store_uri"`
+				// URI that can be used to discover if the rental app is
+				// installed on the device.
+				DiscoveryURI f.URI `json:"discovery_uri"`
+			} `json:"android,omitempty"`
+			IOS *struct {
+				// URI where the rental app can be downloaded from.
+				StoreURI f.URI `json:"store_uri"`
+				// URI that can be used to discover if the rental app is
+				// installed on the device.
+				DiscoveryURI f.URI `json:"discovery_uri"`
+			} `json:"ios,omitempty"`
+		} `json:"rental_apps,omitempty"`
+	} `json:"data"`
+}
+
+// SystemPricingPlans https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_pricing_plansjson
+//
+// Describes the pricing schemes of the system.
+type SystemPricingPlans struct {
+	gbfs.Output
+	// Array of any number of plan objects.
+	Data struct {
+		Plans []Plan `json:"plans"`
+	} `json:"data"`
+}
+
+// Plan is an entry of the plans of SystemPricingPlans
+type Plan struct {
+	// Identifier of a pricing plan in the system.
+	PlanID f.ID `json:"plan_id"`
+	// URL where the customer can learn more about this pricing plan.
+	URL *f.URL `json:"url,omitempty"`
+	// Name of this pricing plan.
+	Name string `json:"name"`
+	// Currency used to pay the fare in ISO 4217 code.
+	Currency f.Currency `json:"currency"`
+	// Fare price.
+	Price f.Price `json:"price"`
+	// Will additional tax be added to the base price?
+	IsTaxable f.Boolean `json:"is_taxable"`
+	// Customer-readable description of the pricing plan.
+	Description string `json:"description"`
+}
+
+// SystemRegions https://github.com/NABSA/gbfs/blob/v1.1/gbfs.md#system_regionsjson
+//
+// Describes regions for a system that is broken up by geographic or political
+// region.
+type SystemRegions struct {
+	gbfs.Output
+	// Array of regions.
+	Data struct {
+		Regions []Region `json:"regions"`
+	} `json:"data"`
+}
+
+// Region is an entry of the regions of SystemRegions
+type Region struct {
+	// Identifier for the region.
+	RegionID f.ID `json:"region_id"`
+	// Public name for this region.
+	Name string `json:"name"`
+}
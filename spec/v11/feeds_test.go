@@ -0,0 +1,25 @@
+package v11
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBooleans ...
+func TestBooleans(t *testing.T) {
+	var ss StationStatus
+	require.NoError(t, json.Unmarshal([]byte(`{"last_updated":1609866247,"ttl":10,"version":"1.1","data":{"stations":[
+		{"station_id":"a","num_bikes_available":1,"num_docks_available":2,"is_installed":1,"is_renting":0,"is_returning":true,"last_reported":1609866200}
+	]}}`), &ss))
+
+	require.Len(t, ss.Data.Stations, 1)
+	st := ss.Data.Stations[0]
+	assert.Equal(t, "a", string(st.StationID))
+	assert.True(t, bool(st.IsInstalled))
+	assert.False(t, bool(st.IsRenting))
+	assert.True(t, bool(st.IsReturning))
+	assert.Equal(t, "1.1", ss.Version)
+}
@@ -0,0 +1,42 @@
+package v20
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go/schema"
+)
+
+// TestRoundTrip checks the generated types keep every field of the fixtures
+func TestRoundTrip(t *testing.T) {
+	for feed, dst := range map[string]any{
+		"gbfs":                 new(GBFS),
+		"gbfs_versions":        new(GBFSVersions),
+		"system_information":   new(SystemInformation),
+		"station_information":  new(StationInformation),
+		"station_status":       new(StationStatus),
+		"free_bike_status":     new(FreeBikeStatus),
+		"system_hours":         new(SystemHours),
+		"system_calendar":      new(SystemCalendar),
+		"system_regions":       new(SystemRegions),
+		"system_pricing_plans": new(SystemPricingPlans),
+		"system_alerts":        new(SystemAlerts),
+	} {
+		t.Run(feed, func(t *testing.T) {
+			raw, err := os.ReadFile("../../testdata/" + feed + ".json")
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(raw, dst))
+
+			out, err := json.Marshal(dst)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(raw), string(out))
+			s, err := schema.Load("2.0", feed)
+			require.NoError(t, err)
+			assert.NoError(t, s.Validate(out))
+		})
+	}
+}
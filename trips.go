@@ -0,0 +1,264 @@
+package gbfs
+
+import (
+	"sort"
+	"time"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// TripOptions tune the heuristics of a TripTracker, zero values are replaced
+// by their defaults
+type TripOptions struct {
+	// MinDistance in meters under which a vehicle that reappears is considered
+	// to not have moved (GPS drift, short reservation)
+	MinDistance float64
+	// MaxSpeed in meters per second above which a movement is flagged as a
+	// rebalancing, riders do not travel in straight lines faster than this
+	MaxSpeed float64
+	// MaxRebalanceSpeed in meters per second above which a vehicle that
+	// reappears under a new ID is not matched to one that disappeared, at
+	// least MaxSpeed
+	MaxRebalanceSpeed float64
+	// MaxDuration after which a vehicle that disappeared is forgotten
+	MaxDuration time.Duration
+}
+
+// Default TripOptions values
+const (
+	DefaultTripMinDistance       = 100.0
+	DefaultTripMaxSpeed          = 25 / 3.6 // 25 km/h
+	DefaultTripMaxRebalanceSpeed = 60 / 3.6 // 60 km/h
+	DefaultTripMaxDuration       = 3 * time.Hour
+)
+
+func (o TripOptions) withDefaults() TripOptions {
+	if o.MinDistance <= 0 {
+		o.MinDistance = DefaultTripMinDistance
+	}
+	if o.MaxSpeed <= 0 {
+		o.MaxSpeed = DefaultTripMaxSpeed
+	}
+	if o.MaxRebalanceSpeed <= 0 {
+		o.MaxRebalanceSpeed = DefaultTripMaxRebalanceSpeed
+	}
+	if o.MaxRebalanceSpeed < o.MaxSpeed {
+		o.MaxRebalanceSpeed = o.MaxSpeed
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = DefaultTripMaxDuration
+	}
+	return o
+}
+
+// Trip is a probable movement of a free floating vehicle inferred from its
+// disappearance and reappearance between FreeBikeStatus documents
+type Trip struct {
+	// VehicleID is the bike_id before the trip, EndVehicleID the one after,
+	// they differ when the ID was rotated
+	VehicleID     f.ID
+	EndVehicleID  f.ID
+	VehicleTypeID f.ID
+	Origin        f.Point
+	Destination   f.Point
+	Start         time.Time
+	End           time.Time
+	// Distance is the straight-line distance in meters
+	Distance float64
+	// IDRotated is set when the vehicle was matched across different IDs
+	IDRotated bool
+	// Rebalance is set when the movement is more likely the operator moving
+	// the vehicle than a rider
+	Rebalance bool
+}
+
+// Duration of the Trip
+func (t Trip) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// Speed returns the straight-line speed in meters per second
+func (t Trip) Speed() float64 {
+	if d := t.Duration().Seconds(); d > 0 {
+		return t.Distance / d
+	}
+	return 0
+}
+
+// sighting is a vehicle as last seen
+type sighting struct {
+	bike Bike
+	at   time.Time
+}
+
+// TripTracker infers trips from consecutive FreeBikeStatus documents
+//
+// Since v2.0 the specification requires bike_id to be rotated after each
+// trip, so a vehicle reappearing under a new ID is matched to the nearest
+// vehicle of the same type that disappeared and could have reached it without
+// exceeding MaxRebalanceSpeed. Vehicles that move while listed, vehicles that
+// were disabled when they disappeared and movements faster than MaxSpeed are
+// flagged as rebalancing
+type TripTracker struct {
+	opts    TripOptions
+	seen    map[f.ID]sighting // listed in the previous document
+	gone    map[f.ID]sighting // disappeared, awaiting a match
+	last    time.Time         // last_updated of the previous document
+	started bool
+}
+
+// NewTripTracker returns a TripTracker
+func NewTripTracker(opts TripOptions) *TripTracker {
+	return &TripTracker{
+		opts: opts.withDefaults(),
+		seen: make(map[f.ID]sighting),
+		gone: make(map[f.ID]sighting),
+	}
+}
+
+// Next returns the trips that ended by the time of fb, the first document is
+// the baseline and yields no trips. Documents that are not newer than the
+// previous one are ignored
+func (t *TripTracker) Next(fb FreeBikeStatus) []Trip {
+	now := fb.LastUpdated.Time
+
+	if t.started && !now.After(t.last) {
+		return nil
+	}
+	t.last = now
+
+	current := make(map[f.ID]sighting, len(fb.Data.Bikes))
+	for _, b := range fb.Data.Bikes {
+		current[b.BikeID] = sighting{b, now}
+	}
+
+	if !t.started {
+		t.started, t.seen = true, current
+		return nil
+	}
+
+	var trips []Trip
+
+	// vehicles moved while listed
+	for id, cur := range current {
+		if prev, ok := t.seen[id]; ok {
+			if trip, ok := t.trip(prev, cur, false); ok {
+				trip.Rebalance = true
+				trips = append(trips, trip)
+			}
+		}
+	}
+
+	// vehicles that disappeared since the previous document
+	for id, prev := range t.seen {
+		if _, ok := current[id]; !ok {
+			t.gone[id] = prev
+		}
+	}
+
+	// vehicles that appeared, same ID first then rotated IDs
+	var appeared []sighting
+	for id, cur := range current {
+		if _, ok := t.seen[id]; ok {
+			continue
+		}
+		if prev, ok := t.gone[id]; ok {
+			delete(t.gone, id)
+			if trip, ok := t.trip(prev, cur, false); ok {
+				trips = append(trips, trip)
+			}
+			continue
+		}
+		appeared = append(appeared, cur)
+	}
+	trips = append(trips, t.matchRotated(appeared)...)
+
+	// forget vehicles that have been gone for too long
+	for id, prev := range t.gone {
+		if now.Sub(prev.at) > t.opts.MaxDuration {
+			delete(t.gone, id)
+		}
+	}
+
+	t.seen = current
+
+	sort.Slice(trips, func(i, j int) bool {
+		if !trips[i].End.Equal(trips[j].End) {
+			return trips[i].End.Before(trips[j].End)
+		}
+		return trips[i].VehicleID < trips[j].VehicleID
+	})
+	return trips
+}
+
+// matchRotated pairs appeared vehicles with disappeared ones, closest pairs
+// first and ties by ID so matches do not depend on map order
+func (t *TripTracker) matchRotated(appeared []sighting) []Trip {
+	type pair struct {
+		from, to sighting
+		distance float64
+	}
+
+	var pairs []pair
+	for _, to := range appeared {
+		for _, from := range t.gone {
+			if from.bike.VehicleTypeID != to.bike.VehicleTypeID {
+				continue
+			}
+			d := from.bike.Point().Distance(to.bike.Point())
+			if elapsed := to.at.Sub(from.at).Seconds(); elapsed <= 0 || d/elapsed > t.opts.MaxRebalanceSpeed {
+				continue
+			}
+			pairs = append(pairs, pair{from, to, d})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].distance != pairs[j].distance {
+			return pairs[i].distance < pairs[j].distance
+		}
+		if pairs[i].from.bike.BikeID != pairs[j].from.bike.BikeID {
+			return pairs[i].from.bike.BikeID < pairs[j].from.bike.BikeID
+		}
+		return pairs[i].to.bike.BikeID < pairs[j].to.bike.BikeID
+	})
+
+	var trips []Trip
+	matched := make(map[f.ID]bool)
+	for _, p := range pairs {
+		if matched[p.to.bike.BikeID] {
+			continue
+		}
+		if _, ok := t.gone[p.from.bike.BikeID]; !ok {
+			continue
+		}
+		delete(t.gone, p.from.bike.BikeID)
+		matched[p.to.bike.BikeID] = true
+
+		if trip, ok := t.trip(p.from, p.to, true); ok {
+			trips = append(trips, trip)
+		}
+	}
+	return trips
+}
+
+// trip returns the Trip between two sightings, movements shorter than
+// MinDistance are not trips
+func (t *TripTracker) trip(from, to sighting, rotated bool) (Trip, bool) {
+	trip := Trip{
+		VehicleID:     from.bike.BikeID,
+		EndVehicleID:  to.bike.BikeID,
+		VehicleTypeID: from.bike.VehicleTypeID,
+		Origin:        from.bike.Point(),
+		Destination:   to.bike.Point(),
+		Start:         from.at,
+		End:           to.at,
+		IDRotated:     rotated,
+	}
+	trip.Distance = trip.Origin.Distance(trip.Destination)
+
+	if trip.Distance < t.opts.MinDistance {
+		return Trip{}, false
+	}
+	trip.Rebalance = from.bike.IsDisabled || trip.Speed() > t.opts.MaxSpeed
+	return trip, true
+}
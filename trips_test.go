@@ -0,0 +1,115 @@
+package gbfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+func bikesAt(unix int64, bikes ...Bike) FreeBikeStatus {
+	var fb FreeBikeStatus
+	fb.LastUpdated = f.NewTimestamp(time.Unix(unix, 0), f.UnixSeconds)
+	fb.Data.Bikes = bikes
+	return fb
+}
+
+func bike(id f.ID, p f.Point) Bike {
+	return Bike{BikeID: id, Latitude: p.Lat, Longitude: p.Lon}
+}
+
+// TestTripTracker ...
+func TestTripTracker(t *testing.T) {
+	origin := f.Point{Lat: 43.65, Lon: -79.38}
+	tt := NewTripTracker(TripOptions{})
+
+	// baseline
+	assert.Empty(t, tt.Next(bikesAt(0,
+		bike("a", origin),
+		bike("b", origin.Destination(90, 500)),
+		bike("c", origin.Destination(180, 500)),
+		bike("d", origin.Destination(270, 500)),
+	)))
+
+	// a and b are rented, c is picked up by a truck, d drifts
+	assert.Empty(t, tt.Next(bikesAt(60,
+		bike("d", origin.Destination(270, 510)),
+	)))
+
+	// a reappears with a rotated ID, b with the same ID, c is dropped 10km
+	// away and d is moved while listed
+	trips := tt.Next(bikesAt(600,
+		bike("a2", origin.Destination(0, 2_000)),
+		bike("b", origin.Destination(90, 1_500)),
+		bike("c", origin.Destination(180, 10_500)),
+		bike("d", origin.Destination(270, 1_000)),
+	))
+	require.Len(t, trips, 4)
+
+	a, b, c, d := trips[0], trips[1], trips[2], trips[3]
+
+	assert.Equal(t, f.ID("a"), a.VehicleID)
+	assert.Equal(t, f.ID("a2"), a.EndVehicleID)
+	assert.True(t, a.IDRotated)
+	assert.False(t, a.Rebalance)
+	assert.Equal(t, 10*time.Minute, a.Duration())
+	assert.InDelta(t, 2_000, a.Distance, 1)
+
+	assert.Equal(t, f.ID("b"), b.EndVehicleID)
+	assert.False(t, b.IDRotated)
+	assert.InDelta(t, 1_000, b.Distance, 1)
+
+	assert.Equal(t, f.ID("c"), c.VehicleID)
+	assert.True(t, c.Rebalance)
+
+	assert.Equal(t, f.ID("d"), d.VehicleID)
+	assert.True(t, d.Rebalance)
+	assert.Equal(t, 9*time.Minute, d.Duration())
+}
+
+// TestTripTrackerRotationSpeed ...
+func TestTripTrackerRotationSpeed(t *testing.T) {
+	origin := f.Point{Lat: 43.65, Lon: -79.38}
+	tt := NewTripTracker(TripOptions{})
+
+	tt.Next(bikesAt(0, bike("a", origin)))
+	tt.Next(bikesAt(60))
+
+	// 20km in 2 minutes is not reachable, the vehicles are not matched
+	assert.Empty(t, tt.Next(bikesAt(120, bike("z", origin.Destination(0, 20_000)))))
+
+	// 5km in 9 minutes is too fast for a rider, the operator moved it
+	tt = NewTripTracker(TripOptions{})
+	tt.Next(bikesAt(0, bike("a", origin)))
+	tt.Next(bikesAt(60))
+	trips := tt.Next(bikesAt(600, bike("a2", origin.Destination(0, 5_000))))
+	require.Len(t, trips, 1)
+	assert.Equal(t, f.ID("a2"), trips[0].EndVehicleID)
+	assert.True(t, trips[0].IDRotated)
+	assert.True(t, trips[0].Rebalance)
+
+	// the disappeared vehicle is forgotten after MaxDuration
+	tt.Next(bikesAt(int64((DefaultTripMaxDuration + time.Minute).Seconds())))
+	assert.NotContains(t, tt.gone, f.ID("a"))
+}
+
+// TestTripTrackerRotationTies checks equidistant matches are decided by ID
+func TestTripTrackerRotationTies(t *testing.T) {
+	origin := f.Point{Lat: 43.65, Lon: -79.38}
+	for i := 0; i < 20; i++ {
+		tt := NewTripTracker(TripOptions{})
+		tt.Next(bikesAt(0, bike("a", origin), bike("b", origin)))
+		tt.Next(bikesAt(60))
+
+		trips := tt.Next(bikesAt(600,
+			bike("y", origin.Destination(0, 1_000)),
+			bike("x", origin.Destination(0, 1_000)),
+		))
+		require.Len(t, trips, 2)
+		assert.Equal(t, [2]f.ID{"a", "x"}, [2]f.ID{trips[0].VehicleID, trips[0].EndVehicleID})
+		assert.Equal(t, [2]f.ID{"b", "y"}, [2]f.ID{trips[1].VehicleID, trips[1].EndVehicleID})
+	}
+}
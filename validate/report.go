@@ -0,0 +1,134 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Severity of an Issue
+type Severity uint8
+
+// Severity constants
+const (
+	_ Severity = iota
+	// Error is a violation of the specification
+	Error
+	// Warning is a likely problem, e.g. a stale document
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return "unknown"
+}
+
+// MarshalJSON satisfies json.Marshaler interface
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON satisifies json.Unmarshaler interface
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	switch str {
+	case "error":
+		*s = Error
+	case "warning":
+		*s = Warning
+	default:
+		return fmt.Errorf("unknown severity %q", str)
+	}
+	return nil
+}
+
+// Issue found in a document
+type Issue struct {
+	// Path of the field, e.g. data.stations[2].lat, empty for the document
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Path, i.Message)
+}
+
+// FeedReport lists the Issues of a feed in a language
+type FeedReport struct {
+	Feed     string `json:"feed"`
+	Language string `json:"language,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// Exists reports whether the document was retrieved
+	Exists bool    `json:"exists"`
+	Issues []Issue `json:"issues"`
+}
+
+// Count returns the number of Issues of severity s
+func (r *FeedReport) Count(s Severity) int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// add reports an Issue at path
+func (r *FeedReport) add(s Severity, path, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Path: path, Severity: s, Message: fmt.Sprintf(format, args...)})
+}
+
+// Report of a system
+type Report struct {
+	URL         string        `json:"url"`
+	Version     string        `json:"version,omitempty"`
+	ValidatedAt time.Time     `json:"validated_at"`
+	Valid       bool          `json:"valid"`
+	Errors      int           `json:"errors"`
+	Warnings    int           `json:"warnings"`
+	Feeds       []*FeedReport `json:"feeds"`
+}
+
+// Feed returns the report of feed in lang
+func (r *Report) Feed(feed, lang string) (*FeedReport, bool) {
+	for _, fr := range r.Feeds {
+		if fr.Feed == feed && fr.Language == lang {
+			return fr, true
+		}
+	}
+	return nil, false
+}
+
+// feed returns the report of feed in lang, added when missing
+func (r *Report) feed(feed, lang string) *FeedReport {
+	if fr, ok := r.Feed(feed, lang); ok {
+		return fr
+	}
+	fr := &FeedReport{Feed: feed, Language: lang, Issues: []Issue{}}
+	r.Feeds = append(r.Feeds, fr)
+	return fr
+}
+
+// tally counts the Issues of the report
+func (r *Report) tally() {
+	r.Errors, r.Warnings = 0, 0
+	for _, fr := range r.Feeds {
+		r.Errors += fr.Count(Error)
+		r.Warnings += fr.Count(Warning)
+	}
+	r.Valid = r.Errors == 0
+}
@@ -0,0 +1,217 @@
+package validate
+
+import (
+	"encoding/json"
+
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// kind of a field, values are checked by decoding them into new()
+type kind struct {
+	name string
+	new  func() any
+}
+
+var (
+	object    = kind{"object", func() any { return new(map[string]json.RawMessage) }}
+	array     = kind{"array", func() any { return new([]json.RawMessage) }}
+	str       = kind{"string", func() any { return new(string) }}
+	boolean   = kind{"boolean", func() any { return new(bool) }}
+	number    = kind{"number", func() any { return new(float64) }}
+	id        = kind{"ID", func() any { return new(f.ID) }}
+	url       = kind{"URL", func() any { return new(f.URL) }}
+	uri       = kind{"URI", func() any { return new(f.URI) }}
+	email     = kind{"email", func() any { return new(f.Email) }}
+	phone     = kind{"phone number", func() any { return new(f.PhoneNumber) }}
+	lang      = kind{"language", func() any { return new(f.Language) }}
+	timezone  = kind{"timezone", func() any { return new(f.Timezone) }}
+	timestamp = kind{"timestamp", func() any { return new(f.Timestamp) }}
+	date      = kind{"date", func() any { return new(f.Date) }}
+	clock     = kind{"time", func() any { return new(f.Time) }}
+	day       = kind{"day", func() any { return new(f.Day) }}
+	month     = kind{"month", func() any { return new(f.Month) }}
+	year      = kind{"year", func() any { return new(f.Year) }}
+	count     = kind{"non-negative integer", func() any { return new(f.NonNegativeInt) }}
+	amount    = kind{"non-negative number", func() any { return new(f.NonNegativeFloat) }}
+	lat       = kind{"latitude", func() any { return new(f.Latitude) }}
+	lon       = kind{"longitude", func() any { return new(f.Longitude) }}
+	currency  = kind{"currency", func() any { return new(f.Currency) }}
+	price     = kind{"price", func() any { return new(f.Price) }}
+	alertType = kind{"alert type", func() any { return new(f.AlertType) }}
+	weekday   = kind{"day of week", func() any { return new(f.DayOfWeek) }}
+	method    = kind{"rental method", func() any { return new(f.RentalMethod) }}
+	userType  = kind{"user type", func() any { return new(f.UserType) }}
+)
+
+// legacyBoolean is a boolean of 1.x documents, which may be 0 or 1
+var legacyBoolean = kind{"boolean", func() any { return new(f.Boolean) }}
+
+// rule of a field at a path, e.g. data.stations[].lat, * matches every key of
+// an object and [] every item of an array
+type rule struct {
+	path     string
+	required bool
+	kind     kind
+}
+
+// header rules of every document
+var header = []rule{
+	{"last_updated", true, timestamp},
+	{"ttl", true, count},
+	{"version", false, str},
+	{"data", true, object},
+}
+
+// rules of each feed of 2.x
+var rules = map[string][]rule{
+	"gbfs": {
+		{"data.*", true, object},
+		{"data.*.feeds", true, array},
+		{"data.*.feeds[]", true, object},
+		{"data.*.feeds[].name", true, str},
+		{"data.*.feeds[].url", true, url},
+	},
+	"gbfs_versions": {
+		{"data.versions", true, array},
+		{"data.versions[].version", true, str},
+		{"data.versions[].url", true, url},
+	},
+	"system_information": {
+		{"data.system_id", true, id},
+		{"data.language", true, lang},
+		{"data.name", true, str},
+		{"data.short_name", false, str},
+		{"data.operator", false, str},
+		{"data.url", false, url},
+		{"data.purchase_url", false, url},
+		{"data.start_date", false, date},
+		{"data.phone_number", false, phone},
+		{"data.email", false, email},
+		{"data.feed_contact_email", false, email},
+		{"data.timezone", true, timezone},
+		{"data.license_url", false, url},
+		{"data.rental_apps", false, object},
+		{"data.rental_apps.*.store_uri", true, uri},
+		{"data.rental_apps.*.discovery_uri", true, uri},
+	},
+	"station_information": {
+		{"data.stations", true, array},
+		{"data.stations[]", true, object},
+		{"data.stations[].station_id", true, id},
+		{"data.stations[].name", true, str},
+		{"data.stations[].short_name", false, str},
+		{"data.stations[].lat", true, lat},
+		{"data.stations[].lon", true, lon},
+		{"data.stations[].address", false, str},
+		{"data.stations[].cross_street", false, str},
+		{"data.stations[].region_id", false, id},
+		{"data.stations[].post_code", false, str},
+		{"data.stations[].rental_methods", false, array},
+		{"data.stations[].rental_methods[]", true, method},
+		{"data.stations[].capacity", false, count},
+		{"data.stations[].rental_uris", false, object},
+		{"data.stations[].rental_uris.android", false, uri},
+		{"data.stations[].rental_uris.ios", false, uri},
+		{"data.stations[].rental_uris.web", false, url},
+	},
+	"station_status": {
+		{"data.stations", true, array},
+		{"data.stations[]", true, object},
+		{"data.stations[].station_id", true, id},
+		{"data.stations[].num_bikes_available", true, count},
+		{"data.stations[].num_bikes_disabled", false, count},
+		{"data.stations[].num_docks_available", true, count},
+		{"data.stations[].num_docks_disabled", false, count},
+		{"data.stations[].is_installed", true, boolean},
+		{"data.stations[].is_renting", true, boolean},
+		{"data.stations[].is_returning", true, boolean},
+		{"data.stations[].last_reported", true, timestamp},
+	},
+	"free_bike_status": {
+		{"data.bikes", true, array},
+		{"data.bikes[]", true, object},
+		{"data.bikes[].bike_id", true, id},
+		{"data.bikes[].lat", true, lat},
+		{"data.bikes[].lon", true, lon},
+		{"data.bikes[].is_reserved", true, boolean},
+		{"data.bikes[].is_disabled", true, boolean},
+		{"data.bikes[].rental_uris", false, object},
+		{"data.bikes[].rental_uris.android", false, uri},
+		{"data.bikes[].rental_uris.ios", false, uri},
+		{"data.bikes[].rental_uris.web", false, url},
+	},
+	"system_hours": {
+		{"data.rental_hours", true, array},
+		{"data.rental_hours[]", true, object},
+		{"data.rental_hours[].user_types", true, array},
+		{"data.rental_hours[].user_types[]", true, userType},
+		{"data.rental_hours[].days", true, array},
+		{"data.rental_hours[].days[]", true, weekday},
+		{"data.rental_hours[].start_time", true, clock},
+		{"data.rental_hours[].end_time", true, clock},
+	},
+	"system_calendar": {
+		{"data.calendars", true, array},
+		{"data.calendars[]", true, object},
+		{"data.calendars[].start_month", true, month},
+		{"data.calendars[].start_day", true, day},
+		{"data.calendars[].start_year", false, year},
+		{"data.calendars[].end_month", true, month},
+		{"data.calendars[].end_day", true, day},
+		{"data.calendars[].end_year", false, year},
+	},
+	"system_regions": {
+		{"data.regions", true, array},
+		{"data.regions[]", true, object},
+		{"data.regions[].region_id", true, id},
+		{"data.regions[].name", true, str},
+	},
+	"system_pricing_plans": {
+		{"data.plans", true, array},
+		{"data.plans[]", true, object},
+		{"data.plans[].plan_id", true, id},
+		{"data.plans[].url", false, url},
+		{"data.plans[].name", true, str},
+		{"data.plans[].currency", true, currency},
+		{"data.plans[].price", true, price},
+		{"data.plans[].is_taxable", true, boolean},
+		{"data.plans[].description", true, str},
+	},
+	"system_alerts": {
+		{"data.alerts", true, array},
+		{"data.alerts[]", true, object},
+		{"data.alerts[].alert_id", true, id},
+		{"data.alerts[].type", true, alertType},
+		{"data.alerts[].times", false, array},
+		{"data.alerts[].times[].start", true, timestamp},
+		{"data.alerts[].times[].end", false, timestamp},
+		{"data.alerts[].station_ids", false, array},
+		{"data.alerts[].station_ids[]", true, id},
+		{"data.alerts[].region_ids", false, array},
+		{"data.alerts[].region_ids[]", true, id},
+		{"data.alerts[].url", false, url},
+		{"data.alerts[].summary", true, str},
+		{"data.alerts[].description", false, str},
+		{"data.alerts[].last_updated", false, timestamp},
+	},
+}
+
+// ids that must be unique in each feed
+var ids = map[string]string{
+	"station_information":  "data.stations[].station_id",
+	"station_status":       "data.stations[].station_id",
+	"free_bike_status":     "data.bikes[].bike_id",
+	"system_regions":       "data.regions[].region_id",
+	"system_pricing_plans": "data.plans[].plan_id",
+	"system_alerts":        "data.alerts[].alert_id",
+}
+
+// realtime feeds, their ttl should be short
+var realtime = map[string]bool{
+	"station_status":   true,
+	"free_bike_status": true,
+}
+
+// MaxRealtimeTTL is the longest ttl of station_status and free_bike_status not
+// reported as a Warning
+const MaxRealtimeTTL = 300
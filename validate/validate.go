@@ -0,0 +1,391 @@
+// Package validate checks GBFS systems against the specification
+//
+// Every feed listed in gbfs.json is retrieved and its fields checked for
+// presence, type and range with the fields package. Required feeds, version
+// consistency, ttls, freshness and references between feeds are checked
+// across the system. Issues are collected in a Report with a JSON encoding
+package validate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marz619/gbfs-go"
+	f "github.com/marz619/gbfs-go/fields"
+)
+
+// DefaultTolerance is the delay past a document's ttl before it is stale
+const DefaultTolerance = time.Minute
+
+// Options configure a validation
+type Options struct {
+	// Fetcher retrieves documents, gbfs.NewHTTPFetcher(nil) when nil, e.g.
+	// gbfs.NewFSFetcher for a system on disk
+	Fetcher gbfs.Fetcher
+	// Languages validated, every language of gbfs.json when empty
+	Languages []string
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+	// Tolerance is the clock skew and delay allowed before a document is
+	// reported stale, DefaultTolerance when zero
+	Tolerance time.Duration
+}
+
+func (o *Options) defaults() {
+	if o.Fetcher == nil {
+		o.Fetcher = gbfs.NewHTTPFetcher(nil)
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.Tolerance == 0 {
+		o.Tolerance = DefaultTolerance
+	}
+}
+
+// System validates the system with gbfs.json at rootURL, an error is returned
+// when gbfs.json cannot be retrieved
+func System(ctx context.Context, rootURL string, opts Options) (*Report, error) {
+	opts.defaults()
+
+	raw, err := opts.Fetcher.Fetch(ctx, rootURL)
+	if err != nil {
+		return nil, err
+	}
+
+	now := opts.Now()
+	r := &Report{URL: rootURL, ValidatedAt: now}
+	root := r.feed("gbfs", "")
+	root.URL = rootURL
+	discovery := check(root, "gbfs", raw, now, opts.Tolerance)
+	r.Version = root.Version
+
+	docs := make(map[string]map[string]any) // by language and feed
+	for _, l := range languages(discovery, opts.Languages) {
+		docs[l] = make(map[string]any)
+		feeds, _ := lookup(discovery, "data", l, "feeds").([]any)
+
+		for i, item := range feeds {
+			fd, _ := item.(map[string]any)
+			name, _ := fd["name"].(string)
+			u, _ := fd["url"].(string)
+			at := "data." + l + ".feeds[" + strconv.Itoa(i) + "]"
+			if name == "" || u == "" {
+				continue
+			}
+			if _, ok := docs[l][name]; ok {
+				root.add(Error, at+".name", "duplicate feed %s", name)
+				continue
+			}
+			if _, ok := rules[name]; !ok && name != "vehicle_types" && name != "geofencing_zones" {
+				root.add(Warning, at+".name", "unknown feed %s", name)
+			}
+
+			fr := r.feed(name, l)
+			fr.URL = u
+			raw, err := opts.Fetcher.Fetch(ctx, u)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				fr.add(Error, "", "not retrieved: %v", err)
+				docs[l][name] = nil
+				continue
+			}
+			docs[l][name] = check(fr, name, raw, now, opts.Tolerance)
+			if fr.Version != "" && r.Version != "" && fr.Version != r.Version {
+				fr.add(Error, "version", "version %s differs from gbfs.json version %s", fr.Version, r.Version)
+			}
+		}
+
+		required(r, l, docs[l])
+		references(r, l, docs[l])
+	}
+
+	r.tally()
+	return r, nil
+}
+
+// Document validates doc of feed on its own, references to other feeds are
+// not checked
+func Document(feed string, doc []byte, opts Options) *FeedReport {
+	opts.defaults()
+	fr := &FeedReport{Feed: feed, Issues: []Issue{}}
+	check(fr, feed, doc, opts.Now(), opts.Tolerance)
+	return fr
+}
+
+// languages returns the languages of gbfs.json to validate
+func languages(discovery any, only []string) []string {
+	data, _ := lookup(discovery, "data").(map[string]any)
+	var out []string
+	for l := range data {
+		keep := len(only) == 0
+		for _, o := range only {
+			keep = keep || o == l
+		}
+		if keep {
+			out = append(out, l)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// check reports the issues of the document of feed, which is returned decoded
+func check(fr *FeedReport, feed string, raw []byte, now time.Time, tolerance time.Duration) any {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var doc any
+	if err := d.Decode(&doc); err != nil {
+		fr.add(Error, "", "malformed document: %v", err)
+		return nil
+	}
+	fr.Exists = true
+
+	if _, ok := doc.(map[string]any); !ok {
+		fr.add(Error, "", "document must be an object")
+		return doc
+	}
+
+	major := 1
+	if v, ok := lookup(doc, "version").(string); ok {
+		fr.Version = v
+		major, _ = strconv.Atoi(strings.SplitN(v, ".", 2)[0])
+	} else {
+		fr.add(Warning, "version", "missing, assumed 1.0")
+	}
+	if major >= 3 {
+		fr.add(Error, "version", "version %s is not supported", fr.Version)
+		return doc
+	}
+
+	for _, rl := range append(header, rules[feed]...) {
+		apply(fr, doc, rl, major)
+	}
+
+	if p, ok := ids[feed]; ok {
+		seen := make(map[string]string)
+		match(doc, p, func(at string, v any, ok bool) {
+			s, isString := v.(string)
+			if !ok || !isString {
+				return
+			}
+			if first, dup := seen[s]; dup {
+				fr.add(Error, at, "duplicate id %s, first at %s", s, first)
+				return
+			}
+			seen[s] = at
+		})
+	}
+
+	freshness(fr, feed, doc, now, tolerance)
+	return doc
+}
+
+// apply reports the values at the path of rl missing or not of its kind
+func apply(fr *FeedReport, doc any, rl rule, major int) {
+	k := rl.kind
+	if k.name == boolean.name && major < 2 {
+		k = legacyBoolean
+	}
+
+	match(doc, rl.path, func(at string, v any, ok bool) {
+		if !ok {
+			if rl.required {
+				fr.add(Error, at, "required field is missing")
+			}
+			return
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			fr.add(Error, at, "%v", err)
+			return
+		}
+		if err := json.Unmarshal(raw, k.new()); err != nil {
+			var te *json.UnmarshalTypeError
+			if errors.As(err, &te) {
+				fr.add(Error, at, "must be a %s, not %s", k.name, te.Value)
+				return
+			}
+			fr.add(Error, at, "invalid %s: %v", k.name, err)
+		}
+	})
+}
+
+// freshness reports stale documents and suspicious ttls
+func freshness(fr *FeedReport, feed string, doc any, now time.Time, tolerance time.Duration) {
+	ttl, err := strconv.Atoi(string(jsonNumber(lookup(doc, "ttl"))))
+	if err != nil {
+		return
+	}
+	if realtime[feed] && ttl > MaxRealtimeTTL {
+		fr.add(Warning, "ttl", "ttl of %ds is long for a realtime feed, at most %ds expected", ttl, MaxRealtimeTTL)
+	}
+
+	raw, err := json.Marshal(lookup(doc, "last_updated"))
+	if err != nil {
+		return
+	}
+	var ts f.Timestamp
+	if err := json.Unmarshal(raw, &ts); err != nil || ts.IsZero() {
+		return
+	}
+	switch age := now.Sub(ts.Time); {
+	case age < -tolerance:
+		fr.add(Warning, "last_updated", "%s is in the future", ts.UTC().Format(time.RFC3339))
+	case age > time.Duration(ttl)*time.Second+tolerance:
+		fr.add(Warning, "last_updated", "stale, last updated %s ago with a ttl of %ds", age.Truncate(time.Second), ttl)
+	}
+}
+
+// required reports the feeds missing from gbfs.json in lang
+func required(r *Report, lang string, docs map[string]any) {
+	root := r.feed("gbfs", "")
+	has := func(name string) bool {
+		_, ok := docs[name]
+		return ok
+	}
+	missing := func(name, format string, args ...any) {
+		fr := r.feed(name, lang)
+		fr.add(Error, "", format, args...)
+		root.add(Error, "data."+lang+".feeds", "%s is missing", name)
+	}
+
+	if !has("system_information") {
+		missing("system_information", "required feed is missing")
+	}
+	switch {
+	case has("station_information") && !has("station_status"):
+		missing("station_status", "required with station_information")
+	case has("station_status") && !has("station_information"):
+		missing("station_information", "required with station_status")
+	case !has("station_information") && !has("free_bike_status"):
+		root.add(Error, "data."+lang+".feeds", "station_information and station_status or free_bike_status are required")
+	}
+}
+
+// references reports ids referring to records missing from another feed
+func references(r *Report, lang string, docs map[string]any) {
+	stations := collect(docs["station_information"], "data.stations[].station_id")
+	regions := collect(docs["system_regions"], "data.regions[].region_id")
+
+	known := func(feed, path string, ids map[string]bool, what string) {
+		if ids == nil || docs[feed] == nil {
+			return
+		}
+		fr := r.feed(feed, lang)
+		match(docs[feed], path, func(at string, v any, ok bool) {
+			if s, isString := v.(string); ok && isString && !ids[s] {
+				fr.add(Error, at, "unknown %s %s", what, s)
+			}
+		})
+	}
+	known("station_status", "data.stations[].station_id", stations, "station")
+	known("station_information", "data.stations[].region_id", regions, "region")
+	known("free_bike_status", "data.bikes[].station_id", stations, "station")
+	known("system_alerts", "data.alerts[].station_ids[]", stations, "station")
+	known("system_alerts", "data.alerts[].region_ids[]", regions, "region")
+
+	if status := collect(docs["station_status"], "data.stations[].station_id"); status != nil && stations != nil {
+		fr := r.feed("station_information", lang)
+		match(docs["station_information"], "data.stations[].station_id", func(at string, v any, ok bool) {
+			if s, isString := v.(string); ok && isString && !status[s] {
+				fr.add(Warning, at, "station %s has no status", s)
+			}
+		})
+	}
+}
+
+// collect returns the string values at path, nil without a document
+func collect(doc any, path string) map[string]bool {
+	if doc == nil {
+		return nil
+	}
+	out := make(map[string]bool)
+	match(doc, path, func(_ string, v any, ok bool) {
+		if s, isString := v.(string); ok && isString {
+			out[s] = true
+		}
+	})
+	return out
+}
+
+// match calls fn with the concrete path and value of every field at path, ok
+// is false for fields missing from an object
+func match(v any, path string, fn func(at string, v any, ok bool)) {
+	walk(v, strings.Split(path, "."), "", fn)
+}
+
+func walk(v any, segs []string, at string, fn func(at string, v any, ok bool)) {
+	o, isObject := v.(map[string]any)
+	if !isObject {
+		return
+	}
+	if at != "" {
+		at += "."
+	}
+
+	seg := segs[0]
+	key := strings.TrimSuffix(seg, "[]")
+	var children []string
+	if key == "*" {
+		for k := range o {
+			children = append(children, k)
+		}
+		sort.Strings(children)
+	} else {
+		children = []string{key}
+	}
+
+	for _, k := range children {
+		child, ok := o[k]
+		ok = ok && child != nil
+		if key != seg {
+			if !ok {
+				continue
+			}
+			items, _ := child.([]any)
+			for i, item := range items {
+				itemAt := at + k + "[" + strconv.Itoa(i) + "]"
+				if len(segs) == 1 {
+					fn(itemAt, item, item != nil)
+					continue
+				}
+				walk(item, segs[1:], itemAt, fn)
+			}
+			continue
+		}
+		if len(segs) == 1 {
+			fn(at+k, child, ok)
+			continue
+		}
+		if ok {
+			walk(child, segs[1:], at+k, fn)
+		}
+	}
+}
+
+// lookup returns the value at keys of v, nil when missing
+func lookup(v any, keys ...string) any {
+	for _, k := range keys {
+		o, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = o[k]
+	}
+	return v
+}
+
+// jsonNumber returns v as a json.Number, empty when it is not one
+func jsonNumber(v any) json.Number {
+	n, _ := v.(json.Number)
+	return n
+}
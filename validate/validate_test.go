@@ -0,0 +1,240 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/marz619/gbfs-go"
+)
+
+var now = time.Unix(1609866250, 0)
+
+// testdata returns the fixtures with the replacements of each file applied
+func testdata(t *testing.T, replace map[string][]string) fstest.MapFS {
+	t.Helper()
+
+	entries, err := os.ReadDir("../testdata")
+	require.NoError(t, err)
+
+	fsys := make(fstest.MapFS, len(entries))
+	for _, e := range entries {
+		raw, err := os.ReadFile("../testdata/" + e.Name())
+		require.NoError(t, err)
+
+		s := string(raw)
+		pairs := replace[e.Name()]
+		for i := 0; i+1 < len(pairs); i += 2 {
+			require.Contains(t, s, pairs[i])
+			s = strings.Replace(s, pairs[i], pairs[i+1], 1)
+		}
+		fsys[e.Name()] = &fstest.MapFile{Data: []byte(s)}
+	}
+	return fsys
+}
+
+func validate(t *testing.T, fsys fstest.MapFS) *Report {
+	t.Helper()
+
+	r, err := System(context.Background(), "gbfs.json", Options{
+		Fetcher: gbfs.NewFSFetcher(fsys, "gbfs.json"),
+		Now:     func() time.Time { return now },
+	})
+	require.NoError(t, err)
+	return r
+}
+
+// issues returns the issues of feed in lang as strings
+func issues(t *testing.T, r *Report, feed, lang string) []string {
+	t.Helper()
+
+	fr, ok := r.Feed(feed, lang)
+	require.True(t, ok, feed)
+	out := make([]string, 0, len(fr.Issues))
+	for _, i := range fr.Issues {
+		out = append(out, i.String())
+	}
+	return out
+}
+
+// TestSystemValid ...
+func TestSystemValid(t *testing.T) {
+	r := validate(t, testdata(t, nil))
+	assert.True(t, r.Valid)
+	assert.Zero(t, r.Errors)
+	assert.Zero(t, r.Warnings)
+	assert.Equal(t, "2.0", r.Version)
+	assert.Len(t, r.Feeds, 13)
+
+	fr, ok := r.Feed("station_status", "fr")
+	require.True(t, ok)
+	assert.True(t, fr.Exists)
+	assert.Equal(t, "https://example.com/gbfs/fr/station_status.json", fr.URL)
+}
+
+// TestSystemInvalid ...
+func TestSystemInvalid(t *testing.T) {
+	r := validate(t, testdata(t, map[string][]string{
+		"station_information.json": {`"lat": 43.639832`, `"lat": 91.5`, `"region_id": "4"`, `"region_id": "9"`},
+		"station_status.json":      {`"station_id": "7000"`, `"station_id": "9999"`, `"is_installed": true,`, ``},
+		"free_bike_status.json":    {`"version": "2.0"`, `"version": "2.1"`, `"is_reserved": false`, `"is_reserved": "no"`},
+		"system_alerts.json":       {`"alert_id": "22"`, `"alert_id": "21"`, `"type": "OTHER"`, `"type": "ALIENS"`},
+		"system_hours.json":        {`"23:59:59"`, `"24:99:00"`},
+	}))
+	assert.False(t, r.Valid)
+
+	assert.Equal(t, []string{
+		"error: data.stations[0].lat: invalid latitude: Latitude must in range [-90.0, 90.0]",
+		"error: data.stations[0].region_id: unknown region 9",
+		"warning: data.stations[0].station_id: station 7000 has no status",
+	}, issues(t, r, "station_information", "en"))
+	assert.Equal(t, []string{
+		"error: data.stations[0].is_installed: required field is missing",
+		"error: data.stations[0].station_id: unknown station 9999",
+	}, issues(t, r, "station_status", "en"))
+	assert.Equal(t, []string{
+		"error: data.bikes[0].is_reserved: must be a boolean, not string",
+		"error: version: version 2.1 differs from gbfs.json version 2.0",
+	}, issues(t, r, "free_bike_status", "en"))
+	assert.Equal(t, []string{
+		"error: data.alerts[1].type: invalid alert type: unknown alert type",
+		"error: data.alerts[1].alert_id: duplicate id 21, first at data.alerts[0].alert_id",
+	}, issues(t, r, "system_alerts", "en"))
+	assert.Len(t, issues(t, r, "system_hours", "en"), 1)
+	// fr shares the station documents, without system_regions
+	assert.Len(t, issues(t, r, "station_information", "fr"), 2)
+	assert.Len(t, issues(t, r, "station_status", "fr"), 2)
+	assert.Equal(t, 12, r.Errors)
+	assert.Equal(t, 2, r.Warnings)
+}
+
+// TestSystemRequiredFeeds ...
+func TestSystemRequiredFeeds(t *testing.T) {
+	r := validate(t, testdata(t, map[string][]string{
+		"gbfs.json": {
+			`{"name": "system_information", "url": "https://example.com/gbfs/fr/system_information.json"},`, ``,
+			`{"name": "station_status", "url": "https://example.com/gbfs/fr/station_status.json"}`, `{"name": "bogus", "url": "https://example.com/gbfs/fr/bogus.json"}`,
+		},
+	}))
+	assert.False(t, r.Valid)
+
+	assert.Equal(t, []string{"error: required feed is missing"}, issues(t, r, "system_information", "fr"))
+	assert.Equal(t, []string{"error: required with station_information"}, issues(t, r, "station_status", "fr"))
+	assert.Equal(t, []string{
+		"warning: data.fr.feeds[1].name: unknown feed bogus",
+		"error: data.fr.feeds: system_information is missing",
+		"error: data.fr.feeds: station_status is missing",
+	}, issues(t, r, "gbfs", ""))
+
+	fr, ok := r.Feed("bogus", "fr")
+	require.True(t, ok)
+	assert.False(t, fr.Exists)
+	require.Len(t, fr.Issues, 1)
+	assert.Contains(t, fr.Issues[0].Message, "not retrieved")
+}
+
+// TestSystemLanguages ...
+func TestSystemLanguages(t *testing.T) {
+	r, err := System(context.Background(), "gbfs.json", Options{
+		Fetcher:   gbfs.NewFSFetcher(testdata(t, nil), "gbfs.json"),
+		Languages: []string{"fr"},
+		Now:       func() time.Time { return now },
+	})
+	require.NoError(t, err)
+	assert.Len(t, r.Feeds, 4)
+
+	_, err = System(context.Background(), "missing.json", Options{Fetcher: gbfs.NewFSFetcher(testdata(t, nil), "gbfs.json")})
+	assert.Error(t, err)
+}
+
+// TestDocument ...
+func TestDocument(t *testing.T) {
+	opts := Options{Now: func() time.Time { return now }}
+
+	for name, tc := range map[string]struct {
+		feed     string
+		doc      string
+		expected []string
+	}{
+		"fresh": {
+			"system_regions",
+			`{"last_updated":1609866247,"ttl":60,"version":"2.0","data":{"regions":[]}}`,
+			[]string{},
+		},
+		"stale": {
+			"system_regions",
+			`{"last_updated":1609860000,"ttl":60,"version":"2.0","data":{"regions":[]}}`,
+			[]string{"warning: last_updated: stale, last updated 1h44m10s ago with a ttl of 60s"},
+		},
+		"future": {
+			"system_regions",
+			`{"last_updated":1609870000,"ttl":60,"version":"2.0","data":{"regions":[]}}`,
+			[]string{"warning: last_updated: 2021-01-05T18:06:40Z is in the future"},
+		},
+		"realtime ttl": {
+			"station_status",
+			`{"last_updated":1609866247,"ttl":3600,"version":"2.0","data":{"stations":[]}}`,
+			[]string{"warning: ttl: ttl of 3600s is long for a realtime feed, at most 300s expected"},
+		},
+		"header": {
+			"system_regions",
+			`{"ttl":-1,"data":{"regions":[{"region_id":"a b"}]}}`,
+			[]string{
+				"warning: version: missing, assumed 1.0",
+				"error: last_updated: required field is missing",
+				"error: ttl: invalid non-negative integer: NonNegativeInt must have value >= 0",
+				"error: data.regions[0].region_id: invalid ID: ID cannot contain spaces",
+				"error: data.regions[0].name: required field is missing",
+			},
+		},
+		"1.x booleans": {
+			"free_bike_status",
+			`{"last_updated":1609866247,"ttl":10,"data":{"bikes":[{"bike_id":"a","lat":1,"lon":2,"is_reserved":0,"is_disabled":1}]}}`,
+			[]string{"warning: version: missing, assumed 1.0"},
+		},
+		"malformed": {
+			"system_regions",
+			`{"last_updated":`,
+			[]string{"error: malformed document: unexpected EOF"},
+		},
+		"3.0": {
+			"system_regions",
+			`{"last_updated":"2021-01-05T17:04:07Z","ttl":60,"version":"3.0","data":{"regions":[]}}`,
+			[]string{"error: version: version 3.0 is not supported"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fr := Document(tc.feed, []byte(tc.doc), opts)
+			actual := make([]string, 0, len(fr.Issues))
+			for _, i := range fr.Issues {
+				actual = append(actual, i.String())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+// TestReportJSON ...
+func TestReportJSON(t *testing.T) {
+	r := validate(t, testdata(t, map[string][]string{
+		"system_regions.json": {`"name": "Annex"`, `"name": 5`},
+	}))
+
+	raw, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `{"path":"data.regions[1].name","severity":"error","message":"must be a string, not number"}`)
+
+	var decoded Report
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, r.Errors, decoded.Errors)
+	fr, ok := decoded.Feed("system_regions", "en")
+	require.True(t, ok)
+	assert.Equal(t, Error, fr.Issues[0].Severity)
+}